@@ -0,0 +1,218 @@
+package cli
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/report"
+)
+
+// assetsCommand manages durable-goods details and the insurance report.
+func assetsCommand() *Command {
+    return &Command{
+        Name:    "assets",
+        Summary: "manage durable goods and the insurance report",
+        Subcommands: []*Command{
+            {Name: "show", Summary: "print an item's asset details", Run: runAssetsShow},
+            {Name: "set", Summary: "record an item's asset details", Run: runAssetsSet},
+            {Name: "clear", Summary: "make an asset a consumable again", Run: runAssetsClear},
+            {Name: "report", Summary: "write the insurance report", Run: runAssetsReport},
+        },
+    }
+}
+
+// runAssetsShow prints an item's durable-goods details.
+func runAssetsShow(env *Env, args []string) error {
+    usage := "assets show [-json] <item id>"
+    flags := env.newFlagSet("assets show", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    a, err := inventory.GetItemAsset(env.Context, db, itemID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("item %d is not an asset", itemID)
+    }
+    if err != nil {
+        return err
+    }
+    rows := [][]string{
+        {"manufacturer", a.Manufacturer},
+        {"model", a.Model},
+        {"serial number", a.SerialNumber},
+        {"purchase date", formatOptionalDate(a.PurchaseDate)},
+        {"purchase price", formatOptionalMoney(a.PurchasePrice)},
+        {"warranty expiry", formatOptionalDate(a.WarrantyExpiry)},
+        {"current value", formatOptionalMoney(a.CurrentValue)},
+    }
+    return env.print(a, []string{"FIELD", "VALUE"}, rows)
+}
+
+// runAssetsSet records an item's durable-goods details, replacing any recorded before.
+func runAssetsSet(env *Env, args []string) error {
+    usage := "assets set [-manufacturer text] [-model text] [-serial text] [-purchased YYYY-MM-DD] [-price amount] [-warranty YYYY-MM-DD] [-value amount] [-json] <item id>"
+    flags := env.newFlagSet("assets set", usage)
+    manufacturer := flags.String("manufacturer", "", "manufacturer or brand")
+    model := flags.String("model", "", "model name or number")
+    serial := flags.String("serial", "", "serial number")
+    purchased := flags.String("purchased", "", "purchase date (YYYY-MM-DD)")
+    price := flags.String("price", "", "purchase price, e.g. 1234.56")
+    warranty := flags.String("warranty", "", "warranty expiry date (YYYY-MM-DD)")
+    value := flags.String("value", "", "current value, e.g. 800")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    a := inventory.ItemAsset{ItemID: itemID, Manufacturer: *manufacturer, Model: *model, SerialNumber: *serial}
+    for flag, date := range map[string]struct {
+        value  string
+        target **time.Time
+    }{"purchased": {*purchased, &a.PurchaseDate}, "warranty": {*warranty, &a.WarrantyExpiry}} {
+        if date.value == "" {
+            continue
+        }
+        t, err := time.Parse(time.DateOnly, date.value)
+        if err != nil {
+            return fmt.Errorf("invalid -%s %q: use YYYY-MM-DD", flag, date.value)
+        }
+        *date.target = &t
+    }
+    for flag, amount := range map[string]struct {
+        value  string
+        target **int64
+    }{"price": {*price, &a.PurchasePrice}, "value": {*value, &a.CurrentValue}} {
+        if amount.value == "" {
+            continue
+        }
+        cents, err := inventory.ParseMoney(amount.value)
+        if err != nil {
+            return fmt.Errorf("invalid -%s: %w", flag, err)
+        }
+        *amount.target = &cents
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    err = inventory.SetItemAsset(env.Context, db, a)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no item with ID %d", itemID)
+    }
+    if err != nil {
+        return err
+    }
+    return env.printMessage(a, "Recorded asset details for item %d.", itemID)
+}
+
+// runAssetsClear discards an item's durable-goods details.
+func runAssetsClear(env *Env, args []string) error {
+    usage := "assets clear [-json] <item id>"
+    flags := env.newFlagSet("assets clear", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    if err := inventory.RemoveItemAsset(env.Context, db, itemID); err != nil {
+        return err
+    }
+    return env.printMessage(map[string]int{"itemID": itemID}, "Item %d is a consumable again.", itemID)
+}
+
+// runAssetsReport writes the insurance report grouped by room.
+func runAssetsReport(env *Env, args []string) error {
+    usage := "assets report [-format pdf|csv|json] [-out file]"
+    flags := env.newFlagSet("assets report", usage)
+    format := flags.String("format", "pdf", "output format: pdf, csv or json")
+    out := flags.String("out", "", "output file (standard output when omitted)")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+    if env.JSON {
+        *format = "json"
+    }
+    if *format != "pdf" && *format != "csv" && *format != "json" {
+        return fmt.Errorf("invalid -format %q: must be pdf, csv or json", *format)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    rep, err := inventory.GetInsuranceReport(env.Context, db)
+    if err != nil {
+        return err
+    }
+
+    w := env.Stdout
+    if *out != "" {
+        f, err := os.Create(*out)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        w = f
+    }
+
+    switch *format {
+    case "pdf":
+        return report.InsurancePDF(w, rep)
+    case "csv":
+        return inventory.WriteInsuranceCSV(w, rep)
+    default:
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(rep)
+    }
+}
+
+// formatOptionalDate formats a date for a table, leaving unknown dates blank.
+func formatOptionalDate(t *time.Time) string {
+    if t == nil {
+        return ""
+    }
+    return t.Format(time.DateOnly)
+}
+
+// formatOptionalMoney formats an amount for a table, leaving unknown amounts blank.
+func formatOptionalMoney(cents *int64) string {
+    if cents == nil {
+        return ""
+    }
+    return inventory.FormatMoney(*cents)
+}
@@ -0,0 +1,128 @@
+package cli
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// backupCommand writes schema-versioned snapshots.
+func backupCommand() *Command {
+    return &Command{
+        Name:    "backup",
+        Summary: "write a snapshot of the database",
+        Run:     runBackup,
+    }
+}
+
+// restoreCommand loads a snapshot written by backup.
+func restoreCommand() *Command {
+    return &Command{
+        Name:    "restore",
+        Summary: "restore a snapshot",
+        Run:     runRestore,
+    }
+}
+
+// runBackup writes a snapshot to a file or directory, optionally repeating on a schedule.
+func runBackup(env *Env, args []string) error {
+    usage := "backup [-out file | -dir dir] [-every interval -keep n] [-include-catalog]"
+    flags := env.newFlagSet("backup", usage)
+    out := flags.String("out", "", "write the snapshot to this file")
+    dir := flags.String("dir", "backups", "directory for timestamped snapshots")
+    every := flags.Duration("every", 0, "repeat the backup at this interval until interrupted (e.g. 24h)")
+    keep := flags.Int("keep", 0, "number of snapshots to keep in -dir (0 keeps all)")
+    includeCatalog := flags.Bool("include-catalog", false, "include the product catalog table")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+    opts := inventory.BackupOptions{IncludeCatalog: *includeCatalog}
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    if *every > 0 {
+        if *out != "" {
+            return fmt.Errorf("-out cannot be combined with -every; scheduled backups are written to -dir")
+        }
+        fmt.Fprintf(env.Stderr, "Backing up to %s every %s. Press Ctrl+C to stop.\n", *dir, *every)
+        return inventory.RunScheduledBackups(env.Context, db, inventory.BackupSchedule{
+            Dir:      *dir,
+            Interval: *every,
+            Keep:     *keep,
+            Options:  opts,
+        })
+    }
+
+    if *out == "" {
+        path, err := inventory.BackupToDir(env.Context, db, *dir, opts)
+        if err != nil {
+            return err
+        }
+        if *keep > 0 {
+            if _, err := inventory.PruneBackups(*dir, *keep); err != nil {
+                return err
+            }
+        }
+        return env.printMessage(map[string]string{"path": path}, "Backup written to %s", path)
+    }
+
+    f, err := os.Create(*out)
+    if err != nil {
+        return err
+    }
+    header, err := inventory.Backup(env.Context, db, f, opts)
+    if closeErr := f.Close(); err == nil {
+        err = closeErr
+    }
+    if err != nil {
+        os.Remove(*out)
+        return err
+    }
+    return env.printMessage(map[string]interface{}{"path": *out, "header": header},
+        "Backup of %d tables (schema version %d) written to %s", len(header.Tables), header.SchemaVersion, *out)
+}
+
+// runRestore loads a snapshot written by the backup command.
+// It skips the schema check because restoring creates the tables it needs.
+func runRestore(env *Env, args []string) error {
+    usage := "restore [-replace] [-json] <file>"
+    flags := env.newFlagSet("restore", usage)
+    replace := flags.Bool("replace", false, "delete existing data before restoring")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+
+    f, err := os.Open(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    db, err := env.connect()
+    if err != nil {
+        return err
+    }
+    header, err := inventory.Restore(env.Context, db, f, inventory.RestoreOptions{Replace: *replace})
+    if err != nil {
+        return err
+    }
+
+    if env.JSON {
+        return env.printMessage(header, "")
+    }
+    fmt.Fprintf(env.Stdout, "Restored backup from %s (schema version %d):\n", header.CreatedAt.Format(time.RFC3339), header.SchemaVersion)
+    rows := make([][]string, 0, len(header.Tables))
+    for _, table := range header.Tables {
+        rows = append(rows, []string{table.Name, strconv.Itoa(table.Rows)})
+    }
+    return env.print(header, []string{"TABLE", "ROWS"}, rows)
+}
@@ -0,0 +1,295 @@
+// Package cli implements the myhomeinventory command tree: the HTTP server plus
+// commands for managing the inventory from a shell or cron.
+package cli
+
+import (
+    "context"
+    "errors"
+    "encoding/json"
+    "flag"
+    "fmt"
+    "io"
+    "io/fs"
+    "os"
+    "os/signal"
+    "strings"
+    "syscall"
+    "text/tabwriter"
+
+    "myhomeinventory/internal/config"
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/logging"
+)
+
+// Command is a node in the command tree. Leaf commands have Run; group commands have Subcommands.
+type Command struct {
+    Name        string
+    Summary     string
+    Run         func(env *Env, args []string) error
+    Subcommands []*Command
+}
+
+// Env carries what commands share: the output writers, the output format, the configuration
+// and the database.
+type Env struct {
+    Stdout io.Writer
+    Stderr io.Writer
+    JSON   bool
+    Config *config.Loaded
+    // Assets holds the web templates and static files built into the binary.
+    Assets fs.FS
+    // Context is cancelled on Ctrl+C so a long-running command stops its database work.
+    Context context.Context
+
+    // configErr holds configuration problems; they only stop commands that need the settings.
+    configErr error
+    db        *inventory.Database
+}
+
+// commands returns the top-level command tree.
+func commands() []*Command {
+    return []*Command{
+        serveCommand(),
+        migrateCommand(),
+        itemCommand(),
+        typesCommand(),
+        substitutionsCommand(),
+        locationsCommand(),
+        tagsCommand(),
+        photosCommand(),
+        assetsCommand(),
+        maintenanceCommand(),
+        loansCommand(),
+        expiringCommand(),
+        tuiCommand(),
+        exportCommand(),
+        importCommand(),
+        backupCommand(),
+        restoreCommand(),
+        catalogCommand(),
+        configCommand(),
+    }
+}
+
+// Run executes the command named by args and returns the process exit code.
+// With no arguments it starts the HTTP server, as the application always has.
+// assets holds the templates/ and static/ directories served by the web UI.
+func Run(args []string, assets fs.FS) int {
+    env := &Env{Stdout: os.Stdout, Stderr: os.Stderr, Assets: assets, Context: context.Background()}
+    defer env.Close()
+
+    cfg, args, err := config.Load(args)
+    if err == flag.ErrHelp {
+        printUsage(env.Stdout, "", commands())
+        return exitOK
+    }
+    if cfg == nil {
+        fmt.Fprintln(env.Stderr, "Error:", err)
+        return exitUsage
+    }
+    env.Config, env.configErr = cfg, err
+
+    if len(args) == 0 {
+        args = []string{"serve"}
+    }
+
+    cmd, rest, path := find(commands(), args)
+    if cmd == nil {
+        if args[0] != "help" {
+            fmt.Fprintf(env.Stderr, "unknown command %q\n\n", strings.Join(args, " "))
+            printUsage(env.Stderr, "", commands())
+            return exitUsage
+        }
+        printUsage(env.Stdout, "", commands())
+        return exitOK
+    }
+    if cmd.Run == nil {
+        printUsage(env.Stderr, path, cmd.Subcommands)
+        return exitUsage
+    }
+
+    // Other commands log to standard error so their output stays clean for pipes and
+    // JSON. serve logs to standard output and handles its own signals so it can drain
+    // requests before exiting.
+    logOutput := env.Stdout
+    if cmd.Name != "serve" {
+        logOutput = env.Stderr
+        ctx, stop := signal.NotifyContext(env.Context, os.Interrupt, syscall.SIGTERM)
+        defer stop()
+        env.Context = ctx
+    }
+    logging.Setup(logOutput, env.Config.Config.Log)
+
+    if err := cmd.Run(env, rest); err != nil {
+        if err == flag.ErrHelp {
+            return exitOK
+        }
+        fmt.Fprintln(env.Stderr, "Error:", err)
+        var exitErr *exitError
+        if errors.As(err, &exitErr) {
+            return exitErr.code
+        }
+        return exitFailure
+    }
+    return exitOK
+}
+
+// Exit codes returned by Run.
+const (
+    exitOK              = 0
+    exitFailure         = 1
+    exitUsage           = 2
+    exitShutdownTimeout = 3
+)
+
+// exitError is an error that ends the process with a specific exit code.
+type exitError struct {
+    code int
+    err  error
+}
+
+// Error returns the message of the wrapped error.
+func (e *exitError) Error() string { return e.err.Error() }
+
+// Unwrap returns the wrapped error.
+func (e *exitError) Unwrap() error { return e.err }
+
+// find walks the command tree following args and returns the deepest matching command,
+// the remaining arguments and the command path.
+func find(cmds []*Command, args []string) (*Command, []string, string) {
+    var found *Command
+    path := ""
+    for len(args) > 0 {
+        var next *Command
+        for _, c := range cmds {
+            if c.Name == args[0] {
+                next = c
+                break
+            }
+        }
+        if next == nil {
+            break
+        }
+        found = next
+        path = strings.TrimSpace(path + " " + next.Name)
+        args = args[1:]
+        cmds = next.Subcommands
+        if next.Run != nil && len(next.Subcommands) == 0 {
+            break
+        }
+    }
+    return found, args, path
+}
+
+// printUsage lists commands with their summaries. Use "<command> -h" for a command's flags.
+func printUsage(w io.Writer, prefix string, cmds []*Command) {
+    fmt.Fprintln(w, "usage: myhomeinventory [global flags] <command> [flags] [args]")
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, "commands:")
+    tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+    for _, c := range cmds {
+        name := strings.TrimSpace(prefix + " " + c.Name)
+        if len(c.Subcommands) == 0 {
+            fmt.Fprintf(tw, "  %s\t%s\n", name, c.Summary)
+        }
+        for _, sub := range c.Subcommands {
+            fmt.Fprintf(tw, "  %s %s\t%s\n", name, sub.Name, sub.Summary)
+        }
+    }
+    tw.Flush()
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, "global flags (before the command):")
+    config.PrintFlags(w)
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, "Run 'myhomeinventory <command> -h' for a command's flags.")
+}
+
+// newFlagSet creates a flag set for a command with the shared -json output flag.
+func (e *Env) newFlagSet(name, usage string) *flag.FlagSet {
+    flags := flag.NewFlagSet(name, flag.ContinueOnError)
+    flags.SetOutput(e.Stderr)
+    flags.BoolVar(&e.JSON, "json", false, "print JSON instead of a table")
+    flags.Usage = func() {
+        fmt.Fprintf(e.Stderr, "usage: myhomeinventory %s\n", usage)
+        flags.PrintDefaults()
+    }
+    return flags
+}
+
+// Database boots the database connection on first use and checks the schema is current.
+func (e *Env) Database() (*inventory.Database, error) {
+    if e.db != nil {
+        return e.db, nil
+    }
+    db, err := e.connect()
+    if err != nil {
+        return nil, err
+    }
+
+    version, err := db.GetSchemaVersion(e.Context)
+    if err != nil {
+        return nil, err
+    }
+    if version != inventory.SchemaVersion {
+        return nil, fmt.Errorf("database schema is at version %d but version %d is required; run 'myhomeinventory migrate'",
+            version, inventory.SchemaVersion)
+    }
+    return db, nil
+}
+
+// connect boots the database connection without checking the schema.
+func (e *Env) connect() (*inventory.Database, error) {
+    if e.db != nil {
+        return e.db, nil
+    }
+    if e.configErr != nil {
+        return nil, fmt.Errorf("invalid configuration:\n%w", e.configErr)
+    }
+
+    db := inventory.NewDatabase(e.Config.Config.Database)
+    if err := db.Boot(e.Context); err != nil {
+        return nil, err
+    }
+    e.db = db
+    return db, nil
+}
+
+// Close shuts down the database connection if one was opened.
+func (e *Env) Close() {
+    if e.db != nil {
+        e.db.Shutdown()
+        e.db = nil
+    }
+}
+
+// print writes v as indented JSON when -json is set, or as a table otherwise.
+func (e *Env) print(v interface{}, header []string, rows [][]string) error {
+    if e.JSON {
+        enc := json.NewEncoder(e.Stdout)
+        enc.SetIndent("", "  ")
+        return enc.Encode(v)
+    }
+
+    tw := tabwriter.NewWriter(e.Stdout, 0, 4, 2, ' ', 0)
+    fmt.Fprintln(tw, strings.Join(header, "\t"))
+    for _, row := range rows {
+        fmt.Fprintln(tw, strings.Join(row, "\t"))
+    }
+    return tw.Flush()
+}
+
+// printMessage writes v as JSON when -json is set, or a plain line otherwise.
+func (e *Env) printMessage(v interface{}, format string, args ...interface{}) error {
+    if e.JSON {
+        enc := json.NewEncoder(e.Stdout)
+        enc.SetIndent("", "  ")
+        return enc.Encode(v)
+    }
+    _, err := fmt.Fprintf(e.Stdout, format+"\n", args...)
+    return err
+}
+
+// errUsage reports that a command was called with the wrong arguments.
+func errUsage(usage string) error {
+    return fmt.Errorf("usage: myhomeinventory %s", usage)
+}
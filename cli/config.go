@@ -0,0 +1,38 @@
+package cli
+
+import (
+    "fmt"
+)
+
+// configCommand groups the configuration commands.
+func configCommand() *Command {
+    return &Command{
+        Name:    "config",
+        Summary: "inspect the configuration",
+        Subcommands: []*Command{
+            {Name: "print", Summary: "print the effective configuration with secrets redacted", Run: runConfigPrint},
+        },
+    }
+}
+
+// runConfigPrint prints every setting, its value and where it came from, then any problems found.
+func runConfigPrint(env *Env, args []string) error {
+    usage := "config print [-json]"
+    if err := parseOnly(env.newFlagSet("config print", usage), args, usage); err != nil {
+        return err
+    }
+
+    entries := env.Config.Entries()
+    rows := make([][]string, 0, len(entries))
+    for _, e := range entries {
+        rows = append(rows, []string{e.Key, e.Value, e.Source})
+    }
+    if err := env.print(entries, []string{"KEY", "VALUE", "SOURCE"}, rows); err != nil {
+        return err
+    }
+
+    if env.configErr != nil {
+        return fmt.Errorf("invalid configuration:\n%w", env.configErr)
+    }
+    return nil
+}
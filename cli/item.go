@@ -0,0 +1,363 @@
+package cli
+
+import (
+    "database/sql"
+    "errors"
+    "flag"
+    "fmt"
+    "strconv"
+    "strings"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// itemCommand groups the commands that work on inventory items.
+func itemCommand() *Command {
+    return &Command{
+        Name:    "item",
+        Summary: "manage inventory items",
+        Subcommands: []*Command{
+            {
+                Name:    "list",
+                Summary: "list inventory items",
+                Run:     runItemList,
+            },
+            {
+                Name:    "search",
+                Summary: "search item names, aliases and barcodes",
+                Run:     runItemSearch,
+            },
+            {
+                Name:    "tag",
+                Summary: "put a tag on one or more items",
+                Run:     runItemTag,
+            },
+            {
+                Name:    "untag",
+                Summary: "remove a tag from one or more items",
+                Run:     runItemUntag,
+            },
+            {
+                Name:    "add",
+                Summary: "add an inventory item",
+                Run:     runItemAdd,
+            },
+            {
+                Name:    "adjust",
+                Summary: "add or use up units of an item",
+                Run:     runItemAdjust,
+            },
+            {
+                Name:    "dispose",
+                Summary: "throw out the oldest units of an item",
+                Run:     runItemDispose,
+            },
+        },
+    }
+}
+
+// runItemList prints inventory items as a table or JSON.
+func runItemList(env *Env, args []string) error {
+    flags := env.newFlagSet("item list", "item list [-name text] [-type name] [-substitution name] [-location name] [-tag name]... [-kind asset|consumable] [-under-minimum] [-expiring days] [-sort field] [-desc] [-limit n] [-json]")
+    name := flags.String("name", "", "only list items whose name contains this text")
+    itemType := flags.String("type", "", "only list items of this type")
+    substitution := flags.String("substitution", "", "only list items with this substitution")
+    location := flags.String("location", "", "only list items kept at this location")
+    var tags stringList
+    flags.Var(&tags, "tag", "only list items with this tag (repeat to require several)")
+    kind := flags.String("kind", "", "only list assets or consumables")
+    underMinimum := flags.Bool("under-minimum", false, "only list items below their minimum quantity")
+    expiring := flags.Int("expiring", -1, "only list items with units expired or expiring within this many days")
+    sortField := flags.String("sort", "id", "sort by "+strings.Join(inventory.ItemSortFields(), ", "))
+    desc := flags.Bool("desc", false, "sort in descending order")
+    limit := flags.Int("limit", 0, "maximum number of items to list (0 lists all)")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    query := inventory.ItemListQuery{
+        Name:         *name,
+        ItemType:     *itemType,
+        Substitution: *substitution,
+        Location:     *location,
+        Tags:         tags,
+        Kind:         *kind,
+        UnderMinimum: *underMinimum,
+        Sort:         *sortField,
+        Descending:   *desc,
+        Limit:        *limit,
+    }
+    if *expiring >= 0 {
+        query.ExpiringWithin = expiring
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    page, err := inventory.ListItems(env.Context, db, query)
+    if err != nil {
+        return err
+    }
+    items := page.Items
+
+    rows := make([][]string, 0, len(items))
+    for _, item := range items {
+        rows = append(rows, []string{
+            strconv.Itoa(item.ID),
+            item.ItemName,
+            strconv.Itoa(item.ItemQTY),
+            strconv.Itoa(item.LentQTY),
+            strconv.Itoa(item.MinimumQTY),
+            strconv.Itoa(item.ItemUsedToDate),
+            strconv.Itoa(item.ItemTotalTossed),
+            item.ItemTypeName,
+            item.ItemSubstitutionName,
+            item.LocationName,
+            strings.Join(item.Tags, ", "),
+        })
+    }
+    return env.print(items, []string{"ID", "NAME", "QTY", "LENT", "MIN", "USED", "TOSSED", "TYPE", "SUBSTITUTION", "LOCATION", "TAGS"}, rows)
+}
+
+// runItemSearch prints the items matching the search text, best match first.
+func runItemSearch(env *Env, args []string) error {
+    usage := "item search [-limit n] [-json] <text>"
+    flags := env.newFlagSet("item search", usage)
+    limit := flags.Int("limit", 20, "maximum number of results")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() == 0 {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    results, err := inventory.SearchItems(env.Context, db, strings.Join(flags.Args(), " "), *limit)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(results))
+    for _, result := range results {
+        match := "full-text"
+        if result.Fuzzy {
+            match = "fuzzy"
+        }
+        rows = append(rows, []string{
+            strconv.Itoa(result.Item.ID),
+            result.Item.ItemName,
+            strconv.Itoa(result.Item.ItemQTY),
+            strconv.FormatFloat(result.Score, 'f', 2, 64),
+            match,
+        })
+    }
+    return env.print(results, []string{"ID", "NAME", "QTY", "SCORE", "MATCH"}, rows)
+}
+
+// runItemTag puts a tag on the items with the given IDs, creating the tag if needed.
+func runItemTag(env *Env, args []string) error {
+    return tagItems(env, "item tag", args, true)
+}
+
+// runItemUntag removes a tag from the items with the given IDs.
+func runItemUntag(env *Env, args []string) error {
+    return tagItems(env, "item untag", args, false)
+}
+
+// tagItems parses "<tag> <id>..." and adds or removes the tag.
+func tagItems(env *Env, name string, args []string, tag bool) error {
+    usage := name + " [-json] <tag> <id>..."
+    flags := env.newFlagSet(name, usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() < 2 {
+        return errUsage(usage)
+    }
+    tagName := flags.Arg(0)
+    itemIDs := make([]int, 0, flags.NArg()-1)
+    for _, arg := range flags.Args()[1:] {
+        id, err := strconv.Atoi(arg)
+        if err != nil {
+            return fmt.Errorf("invalid item ID %q", arg)
+        }
+        itemIDs = append(itemIDs, id)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    update, verb := inventory.UntagItems, "Removed"
+    if tag {
+        update, verb = inventory.TagItems, "Added"
+    }
+    changed, err := update(env.Context, db, tagName, itemIDs)
+    if err != nil {
+        return err
+    }
+    result := map[string]interface{}{"tag": tagName, "changed": changed}
+    return env.printMessage(result, "%s tag %q on %d item(s).", verb, tagName, changed)
+}
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+    *s = append(*s, v)
+    return nil
+}
+
+// runItemAdd inserts a new item, resolving its type and substitution by name.
+func runItemAdd(env *Env, args []string) error {
+    usage := "item add -name name -qty n -min n -type name -substitution name -expires days [-barcode code] [-json]"
+    flags := env.newFlagSet("item add", usage)
+    name := flags.String("name", "", "item name")
+    qty := flags.Int("qty", 0, "quantity on hand")
+    minQty := flags.Int("min", 0, "minimum quantity to keep")
+    typeName := flags.String("type", "", "item type name")
+    substitutionName := flags.String("substitution", "", "item substitution name")
+    expires := flags.Int("expires", 0, "days until a unit expires")
+    var barcodes stringList
+    flags.Var(&barcodes, "barcode", "barcode to assign (may be repeated)")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if *name == "" || *typeName == "" || *substitutionName == "" {
+        return fmt.Errorf("-name, -type and -substitution are required\nusage: myhomeinventory %s", usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    types, err := inventory.GetItemTypes(env.Context, db)
+    if err != nil {
+        return err
+    }
+    typeID := 0
+    for _, t := range types {
+        if strings.EqualFold(t.Name, *typeName) {
+            typeID = t.ID
+        }
+    }
+    if typeID == 0 {
+        return fmt.Errorf("unknown item type %q; see 'myhomeinventory types list'", *typeName)
+    }
+
+    substitutions, err := inventory.GetItemSubstitutions(env.Context, db)
+    if err != nil {
+        return err
+    }
+    substitutionID := 0
+    for _, s := range substitutions {
+        if strings.EqualFold(s.Name, *substitutionName) {
+            substitutionID = s.ID
+        }
+    }
+    if substitutionID == 0 {
+        return fmt.Errorf("unknown item substitution %q; see 'myhomeinventory substitutions list'", *substitutionName)
+    }
+
+    id, err := inventory.InsertItem(env.Context, db, inventory.InventoryItem{
+        ItemName:             *name,
+        ItemQTY:              *qty,
+        MinimumQTY:           *minQty,
+        ItemTypeID:           typeID,
+        ItemSubstitutionID:   substitutionID,
+        ItemExpirationPeriod: *expires,
+        Barcodes:             barcodes,
+    })
+    if err != nil {
+        return err
+    }
+    return env.printMessage(map[string]interface{}{"id": id}, "Added item %q with ID %d.", *name, id)
+}
+
+// runItemAdjust adds or uses up units of an item, one unit at a time as the web UI does.
+func runItemAdjust(env *Env, args []string) error {
+    usage := "item adjust [-json] <name> <+n|-n>"
+    flags := env.newFlagSet("item adjust", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 2 {
+        return errUsage(usage)
+    }
+    name := flags.Arg(0)
+    delta, err := strconv.Atoi(flags.Arg(1))
+    if err != nil || delta == 0 {
+        return fmt.Errorf("invalid adjustment %q: use a signed count such as +2 or -1", flags.Arg(1))
+    }
+
+    action, count := "+", delta
+    if delta < 0 {
+        action, count = "-", -delta
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    var result map[string]interface{}
+    for i := 0; i < count; i++ {
+        result, err = inventory.UpdateItemQty(env.Context, db, name, action)
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("no item named %q", name)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return env.printMessage(result, "%s: %v available, used to date %v.", name, result["itemQTY"], result["itemUsedToDate"])
+}
+
+// runItemDispose throws out the oldest units of an item.
+func runItemDispose(env *Env, args []string) error {
+    usage := "item dispose [-count n] [-json] <name>"
+    flags := env.newFlagSet("item dispose", usage)
+    count := flags.Int("count", 1, "number of units to throw out")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 || *count < 1 {
+        return errUsage(usage)
+    }
+    name := flags.Arg(0)
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    var result map[string]interface{}
+    for i := 0; i < *count; i++ {
+        result, err = inventory.DisposeItem(env.Context, db, name)
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("no item named %q", name)
+        }
+        if err != nil {
+            return err
+        }
+    }
+    return env.printMessage(result, "%s: %v available, total tossed %v.", name, result["itemQTY"], result["itemTotalTossed"])
+}
+
+// parseOnly parses flags for commands that take no other arguments.
+func parseOnly(flags *flag.FlagSet, args []string, usage string) error {
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 0 {
+        return errUsage(usage)
+    }
+    return nil
+}
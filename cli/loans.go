@@ -0,0 +1,156 @@
+package cli
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// loansCommand lends items out and checks them back in.
+func loansCommand() *Command {
+    return &Command{
+        Name:    "loans",
+        Summary: "lend items to people and track their return",
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list loans still out", Run: runLoansList},
+            {Name: "lend", Summary: "check units of an item out to someone", Run: runLoansLend},
+            {Name: "return", Summary: "check a loan back in", Run: runLoansReturn},
+        },
+    }
+}
+
+// runLoansList prints loans still out, or an item's whole lending history.
+func runLoansList(env *Env, args []string) error {
+    usage := "loans list [-overdue] [-history] [-json] [item id]"
+    flags := env.newFlagSet("loans list", usage)
+    overdue := flags.Bool("overdue", false, "only list loans past their due date")
+    history := flags.Bool("history", false, "include returned loans, newest first")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() > 1 {
+        return errUsage(usage)
+    }
+    query := inventory.LoanQuery{Overdue: *overdue, IncludeReturned: *history}
+    if flags.NArg() == 1 {
+        var err error
+        if query.ItemID, err = strconv.Atoi(flags.Arg(0)); err != nil {
+            return errUsage(usage)
+        }
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    loans, err := inventory.GetLoans(env.Context, db, query)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(loans))
+    for _, loan := range loans {
+        overdue := ""
+        if loan.DaysOverdue > 0 {
+            overdue = strconv.Itoa(loan.DaysOverdue)
+        }
+        rows = append(rows, []string{
+            strconv.Itoa(loan.ID),
+            loan.ItemName,
+            strconv.Itoa(loan.Quantity),
+            loan.Borrower,
+            loan.LentDate.Format(time.DateOnly),
+            formatOptionalDate(loan.DueDate),
+            formatOptionalDate(loan.ReturnedDate),
+            overdue,
+        })
+    }
+    return env.print(loans, []string{"ID", "ITEM", "QTY", "BORROWER", "LENT", "DUE", "RETURNED", "DAYS OVERDUE"}, rows)
+}
+
+// runLoansLend checks units of an item out to a borrower.
+func runLoansLend(env *Env, args []string) error {
+    usage := "loans lend [-qty n] [-due YYYY-MM-DD] [-note text] [-json] <item id> <borrower>"
+    flags := env.newFlagSet("loans lend", usage)
+    qty := flags.Int("qty", 1, "units to lend")
+    due := flags.String("due", "", "date the units are expected back")
+    note := flags.String("note", "", "note about the loan")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() < 2 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+    loan := inventory.ItemLoan{
+        ItemID:   itemID,
+        Borrower: strings.Join(flags.Args()[1:], " "),
+        Quantity: *qty,
+        Note:     *note,
+    }
+    if *due != "" {
+        t, err := time.Parse(time.DateOnly, *due)
+        if err != nil {
+            return fmt.Errorf("invalid -due %q: use YYYY-MM-DD", *due)
+        }
+        loan.DueDate = &t
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.LendItem(env.Context, db, loan)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no item with ID %d", itemID)
+    }
+    if err != nil {
+        return err
+    }
+    saved, err := inventory.GetLoan(env.Context, db, int(id))
+    if err != nil {
+        return err
+    }
+    back := ""
+    if saved.DueDate != nil {
+        back = ", due back " + saved.DueDate.Format(time.DateOnly)
+    }
+    return env.printMessage(saved, "Loan %d: %d x %s lent to %s%s.", saved.ID, saved.Quantity, saved.ItemName, saved.Borrower, back)
+}
+
+// runLoansReturn checks a loan back in.
+func runLoansReturn(env *Env, args []string) error {
+    usage := "loans return [-json] <loan id>"
+    flags := env.newFlagSet("loans return", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    id, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    loan, err := inventory.ReturnLoan(env.Context, db, id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no loan with ID %d", id)
+    }
+    if err != nil {
+        return err
+    }
+    return env.printMessage(loan, "%s returned %d x %s.", loan.Borrower, loan.Quantity, loan.ItemName)
+}
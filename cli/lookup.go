@@ -0,0 +1,342 @@
+package cli
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// typesCommand lists and adds item types.
+func typesCommand() *Command {
+    return &Command{
+        Name:    "types",
+        Summary: "list item types",
+        Run:     runTypesList,
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list item types", Run: runTypesList},
+            {Name: "add", Summary: "add an item type", Run: runTypesAdd},
+        },
+    }
+}
+
+// substitutionsCommand lists and adds item substitutions.
+func substitutionsCommand() *Command {
+    return &Command{
+        Name:    "substitutions",
+        Summary: "list item substitutions",
+        Run:     runSubstitutionsList,
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list item substitutions", Run: runSubstitutionsList},
+            {Name: "add", Summary: "add an item substitution", Run: runSubstitutionsAdd},
+        },
+    }
+}
+
+// locationsCommand lists and adds item locations.
+func locationsCommand() *Command {
+    return &Command{
+        Name:    "locations",
+        Summary: "list item locations",
+        Run:     runLocationsList,
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list item locations", Run: runLocationsList},
+            {Name: "add", Summary: "add an item location", Run: runLocationsAdd},
+        },
+    }
+}
+
+// tagsCommand lists and manages item tags.
+func tagsCommand() *Command {
+    return &Command{
+        Name:    "tags",
+        Summary: "list item tags",
+        Run:     runTagsList,
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list item tags with their item counts", Run: runTagsList},
+            {Name: "add", Summary: "add an item tag", Run: runTagsAdd},
+            {Name: "rename", Summary: "rename an item tag", Run: runTagsRename},
+            {Name: "delete", Summary: "delete an item tag and remove it from its items", Run: runTagsDelete},
+        },
+    }
+}
+
+// runTypesList prints every item type.
+func runTypesList(env *Env, args []string) error {
+    usage := "types list [-json]"
+    if err := parseOnly(env.newFlagSet("types list", usage), args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    types, err := inventory.GetItemTypes(env.Context, db)
+    if err != nil {
+        return err
+    }
+    if types == nil {
+        types = []inventory.ItemType{}
+    }
+
+    rows := make([][]string, 0, len(types))
+    for _, t := range types {
+        rows = append(rows, []string{strconv.Itoa(t.ID), t.Name})
+    }
+    return env.print(types, []string{"ID", "NAME"}, rows)
+}
+
+// runTypesAdd adds an item type.
+func runTypesAdd(env *Env, args []string) error {
+    usage := "types add [-json] <name>"
+    name, err := parseName(env, "types add", usage, args)
+    if err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.AddItemType(env.Context, db, name)
+    if err != nil {
+        return err
+    }
+    return env.printMessage(inventory.ItemType{ID: int(id), Name: name}, "Added item type %q with ID %d.", name, id)
+}
+
+// runSubstitutionsList prints every item substitution.
+func runSubstitutionsList(env *Env, args []string) error {
+    usage := "substitutions list [-json]"
+    if err := parseOnly(env.newFlagSet("substitutions list", usage), args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    substitutions, err := inventory.GetItemSubstitutions(env.Context, db)
+    if err != nil {
+        return err
+    }
+    if substitutions == nil {
+        substitutions = []inventory.ItemSubstitution{}
+    }
+
+    rows := make([][]string, 0, len(substitutions))
+    for _, s := range substitutions {
+        rows = append(rows, []string{strconv.Itoa(s.ID), s.Name})
+    }
+    return env.print(substitutions, []string{"ID", "NAME"}, rows)
+}
+
+// runSubstitutionsAdd adds an item substitution.
+func runSubstitutionsAdd(env *Env, args []string) error {
+    usage := "substitutions add [-json] <name>"
+    name, err := parseName(env, "substitutions add", usage, args)
+    if err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.AddItemSubstitution(env.Context, db, name)
+    if err != nil {
+        return err
+    }
+    return env.printMessage(inventory.ItemSubstitution{ID: int(id), Name: name}, "Added item substitution %q with ID %d.", name, id)
+}
+
+// runLocationsList prints every item location.
+func runLocationsList(env *Env, args []string) error {
+    usage := "locations list [-json]"
+    if err := parseOnly(env.newFlagSet("locations list", usage), args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    locations, err := inventory.GetItemLocations(env.Context, db)
+    if err != nil {
+        return err
+    }
+    if locations == nil {
+        locations = []inventory.ItemLocation{}
+    }
+
+    rows := make([][]string, 0, len(locations))
+    for _, l := range locations {
+        rows = append(rows, []string{strconv.Itoa(l.ID), l.Name})
+    }
+    return env.print(locations, []string{"ID", "NAME"}, rows)
+}
+
+// runLocationsAdd adds an item location.
+func runLocationsAdd(env *Env, args []string) error {
+    usage := "locations add [-json] <name>"
+    name, err := parseName(env, "locations add", usage, args)
+    if err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.AddItemLocation(env.Context, db, name)
+    if err != nil {
+        return err
+    }
+    return env.printMessage(inventory.ItemLocation{ID: int(id), Name: name}, "Added item location %q with ID %d.", name, id)
+}
+
+// runTagsList prints every item tag and how many items carry it.
+func runTagsList(env *Env, args []string) error {
+    usage := "tags list [-json]"
+    if err := parseOnly(env.newFlagSet("tags list", usage), args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    tags, err := inventory.GetItemTags(env.Context, db)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(tags))
+    for _, t := range tags {
+        rows = append(rows, []string{strconv.Itoa(t.ID), t.Name, strconv.Itoa(t.Items)})
+    }
+    return env.print(tags, []string{"ID", "NAME", "ITEMS"}, rows)
+}
+
+// runTagsAdd adds an item tag.
+func runTagsAdd(env *Env, args []string) error {
+    usage := "tags add [-json] <name>"
+    name, err := parseName(env, "tags add", usage, args)
+    if err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.AddItemTag(env.Context, db, name)
+    if err != nil {
+        return err
+    }
+    return env.printMessage(inventory.ItemTag{ID: int(id), Name: name}, "Added item tag %q with ID %d.", name, id)
+}
+
+// runTagsRename renames an item tag.
+func runTagsRename(env *Env, args []string) error {
+    usage := "tags rename [-json] <id> <name>"
+    flags := env.newFlagSet("tags rename", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 2 {
+        return errUsage(usage)
+    }
+    id, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+    name := flags.Arg(1)
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    err = inventory.RenameItemTag(env.Context, db, id, name)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no tag with ID %d", id)
+    }
+    if err != nil {
+        return err
+    }
+    return env.printMessage(inventory.ItemTag{ID: id, Name: name}, "Renamed item tag %d to %q.", id, name)
+}
+
+// runTagsDelete deletes an item tag.
+func runTagsDelete(env *Env, args []string) error {
+    usage := "tags delete [-json] <id>"
+    flags := env.newFlagSet("tags delete", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    id, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    err = inventory.DeleteItemTag(env.Context, db, id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no tag with ID %d", id)
+    }
+    if err != nil {
+        return err
+    }
+    return env.printMessage(map[string]interface{}{"id": id}, "Deleted item tag %d.", id)
+}
+
+// parseName parses flags for commands that take a single name argument.
+func parseName(env *Env, name, usage string, args []string) (string, error) {
+    flags := env.newFlagSet(name, usage)
+    if err := flags.Parse(args); err != nil {
+        return "", err
+    }
+    if flags.NArg() != 1 {
+        return "", errUsage(usage)
+    }
+    return flags.Arg(0), nil
+}
+
+// expiringCommand lists stock units that expire soon.
+func expiringCommand() *Command {
+    return &Command{
+        Name:    "expiring",
+        Summary: "list units that have expired or expire within n days",
+        Run:     runExpiring,
+    }
+}
+
+// runExpiring prints units expiring within the requested number of days.
+func runExpiring(env *Env, args []string) error {
+    usage := "expiring [-days n] [-json]"
+    flags := env.newFlagSet("expiring", usage)
+    days := flags.Int("days", 7, "include units expiring within this many days")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    expiring, err := inventory.GetExpiringUnits(env.Context, db, *days)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(expiring))
+    for _, e := range expiring {
+        rows = append(rows, []string{
+            e.ItemName,
+            strconv.Itoa(e.Units),
+            e.ExpirationDate.Format("2006-01-02"),
+            strconv.Itoa(e.DaysLeft),
+        })
+    }
+    return env.print(expiring, []string{"ITEM", "UNITS", "EXPIRES", "DAYS LEFT"}, rows)
+}
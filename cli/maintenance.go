@@ -0,0 +1,288 @@
+package cli
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// maintenanceCommand manages maintenance schedules on items.
+func maintenanceCommand() *Command {
+    return &Command{
+        Name:    "maintenance",
+        Summary: "schedule maintenance tasks and see what is due",
+        Subcommands: []*Command{
+            {Name: "due", Summary: "list tasks and warranties due within n days", Run: runMaintenanceDue},
+            {Name: "list", Summary: "list scheduled tasks", Run: runMaintenanceList},
+            {Name: "add", Summary: "schedule a one-off or recurring task on an item", Run: runMaintenanceAdd},
+            {Name: "done", Summary: "mark a task done and schedule its next occurrence", Run: runMaintenanceDone},
+            {Name: "remove", Summary: "remove a task, keeping its log", Run: runMaintenanceRemove},
+            {Name: "log", Summary: "list completed tasks", Run: runMaintenanceLog},
+        },
+    }
+}
+
+// runMaintenanceDue prints tasks due within the requested number of days, overdue ones
+// included, and warranties ending in that time.
+func runMaintenanceDue(env *Env, args []string) error {
+    usage := "maintenance due [-days n] [-json]"
+    flags := env.newFlagSet("maintenance due", usage)
+    days := flags.Int("days", 14, "include tasks due within this many days")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    due, err := inventory.GetDueMaintenance(env.Context, db, *days)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(due))
+    for _, d := range due {
+        task := ""
+        if d.TaskID != 0 {
+            task = strconv.Itoa(d.TaskID)
+        }
+        rows = append(rows, []string{task, d.ItemName, d.Name, d.DueDate.Format(time.DateOnly), strconv.Itoa(d.DaysLeft)})
+    }
+    return env.print(due, []string{"TASK", "ITEM", "NAME", "DUE", "DAYS LEFT"}, rows)
+}
+
+// runMaintenanceList prints the tasks scheduled on one item, or on every item.
+func runMaintenanceList(env *Env, args []string) error {
+    usage := "maintenance list [-json] [item id]"
+    flags := env.newFlagSet("maintenance list", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() > 1 {
+        return errUsage(usage)
+    }
+    itemID := 0
+    if flags.NArg() == 1 {
+        var err error
+        if itemID, err = strconv.Atoi(flags.Arg(0)); err != nil {
+            return errUsage(usage)
+        }
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    tasks, err := inventory.GetMaintenanceTasks(env.Context, db, itemID)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(tasks))
+    for _, t := range tasks {
+        uses := ""
+        if t.ConsumeItemID != 0 {
+            uses = fmt.Sprintf("%d x %s", t.ConsumeUnits, t.ConsumeItemName)
+        }
+        rows = append(rows, []string{
+            strconv.Itoa(t.ID),
+            t.ItemName,
+            t.Name,
+            t.Every.String(),
+            formatOptionalDate(t.NextDue),
+            formatOptionalDate(t.LastDone),
+            uses,
+        })
+    }
+    return env.print(tasks, []string{"ID", "ITEM", "TASK", "REPEATS", "NEXT DUE", "LAST DONE", "USES"}, rows)
+}
+
+// runMaintenanceAdd schedules a task on an item.
+func runMaintenanceAdd(env *Env, args []string) error {
+    usage := "maintenance add [-every interval] [-due YYYY-MM-DD] [-consume item id [-units n]] [-json] <item id> <task name>"
+    flags := env.newFlagSet("maintenance add", usage)
+    every := flags.String("every", "", "repeat interval, e.g. 90d, 2w, 6m, 1y or monthly (omit for a one-off task)")
+    due := flags.String("due", "", "first due date (default one interval from today)")
+    consume := flags.Int("consume", 0, "ID of a consumable item to use up each time the task is done")
+    units := flags.Int("units", 1, "units of the consumable used each time")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() < 2 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    task := inventory.MaintenanceTask{
+        ItemID:        itemID,
+        Name:          strings.Join(flags.Args()[1:], " "),
+        ConsumeItemID: *consume,
+        ConsumeUnits:  *units,
+    }
+    if task.Every, err = inventory.ParseInterval(*every); err != nil {
+        return err
+    }
+    if *due != "" {
+        t, err := time.Parse(time.DateOnly, *due)
+        if err != nil {
+            return fmt.Errorf("invalid -due %q: use YYYY-MM-DD", *due)
+        }
+        task.NextDue = &t
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    id, err := inventory.AddMaintenanceTask(env.Context, db, task)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no item with ID %d", itemID)
+    }
+    if err != nil {
+        return err
+    }
+    saved, err := inventory.GetMaintenanceTask(env.Context, db, int(id))
+    if err != nil {
+        return err
+    }
+    return env.printMessage(saved, "Scheduled task %d, %q on %s, %s, next due %s.",
+        saved.ID, saved.Name, saved.ItemName, saved.Every, formatOptionalDate(saved.NextDue))
+}
+
+// runMaintenanceDone marks a task done.
+func runMaintenanceDone(env *Env, args []string) error {
+    usage := "maintenance done [-date YYYY-MM-DD] [-note text] [-skip-consume] [-json] <task id>"
+    flags := env.newFlagSet("maintenance done", usage)
+    date := flags.String("date", "", "when the task was done (default today)")
+    note := flags.String("note", "", "note for the maintenance log")
+    skipConsume := flags.Bool("skip-consume", false, "leave the task's consumable in stock")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    id, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+    done := inventory.MaintenanceCompletion{Note: *note, SkipConsume: *skipConsume}
+    if *date != "" {
+        if done.DoneDate, err = time.Parse(time.DateOnly, *date); err != nil {
+            return fmt.Errorf("invalid -date %q: use YYYY-MM-DD", *date)
+        }
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    entry, err := inventory.CompleteMaintenanceTask(env.Context, db, id, done)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no maintenance task with ID %d", id)
+    }
+    if err != nil {
+        return err
+    }
+    task, err := inventory.GetMaintenanceTask(env.Context, db, id)
+    if err != nil {
+        return err
+    }
+
+    next := "not due again"
+    if task.NextDue != nil {
+        next = "next due " + task.NextDue.Format(time.DateOnly)
+    }
+    used := ""
+    if entry.ConsumedUnits > 0 {
+        used = fmt.Sprintf(" Used %d x %s.", entry.ConsumedUnits, entry.ConsumedItemName)
+    }
+    if task.ConsumeItemID != 0 && !*skipConsume && entry.ConsumedUnits < task.ConsumeUnits {
+        used += fmt.Sprintf(" %s was short by %d.", task.ConsumeItemName, task.ConsumeUnits-entry.ConsumedUnits)
+    }
+    return env.printMessage(map[string]interface{}{"entry": entry, "task": task},
+        "Done: %s on %s, %s.%s", task.Name, task.ItemName, next, used)
+}
+
+// runMaintenanceRemove removes a task.
+func runMaintenanceRemove(env *Env, args []string) error {
+    usage := "maintenance remove [-json] <task id>"
+    flags := env.newFlagSet("maintenance remove", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    id, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    err = inventory.DeleteMaintenanceTask(env.Context, db, id)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no maintenance task with ID %d", id)
+    }
+    if err != nil {
+        return err
+    }
+    return env.printMessage(map[string]int{"id": id}, "Removed maintenance task %d.", id)
+}
+
+// runMaintenanceLog prints the most recent task completions of one item, or of every item.
+func runMaintenanceLog(env *Env, args []string) error {
+    usage := "maintenance log [-limit n] [-json] [item id]"
+    flags := env.newFlagSet("maintenance log", usage)
+    limit := flags.Int("limit", 50, "maximum number of entries to list")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() > 1 || *limit < 1 {
+        return errUsage(usage)
+    }
+    itemID := 0
+    if flags.NArg() == 1 {
+        var err error
+        if itemID, err = strconv.Atoi(flags.Arg(0)); err != nil {
+            return errUsage(usage)
+        }
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    entries, err := inventory.GetMaintenanceLog(env.Context, db, itemID, *limit)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(entries))
+    for _, e := range entries {
+        used := ""
+        if e.ConsumedUnits > 0 {
+            used = fmt.Sprintf("%d x %s", e.ConsumedUnits, e.ConsumedItemName)
+        }
+        rows = append(rows, []string{
+            e.DoneDate.Format(time.DateOnly),
+            e.ItemName,
+            e.TaskName,
+            formatOptionalDate(e.DueDate),
+            used,
+            e.Note,
+        })
+    }
+    return env.print(entries, []string{"DONE", "ITEM", "TASK", "WAS DUE", "USED", "NOTE"}, rows)
+}
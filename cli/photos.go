@@ -0,0 +1,141 @@
+package cli
+
+import (
+    "database/sql"
+    "errors"
+    "fmt"
+    "os"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/photo"
+)
+
+// photosCommand manages item photos and the directory they are stored in.
+func photosCommand() *Command {
+    return &Command{
+        Name:    "photos",
+        Summary: "manage item photos",
+        Subcommands: []*Command{
+            {Name: "list", Summary: "list an item's photos", Run: runPhotosList},
+            {Name: "add", Summary: "attach image files to an item", Run: runPhotosAdd},
+            {Name: "prune", Summary: "delete stored photos no item uses", Run: runPhotosPrune},
+        },
+    }
+}
+
+// runPhotosList prints the photos attached to an item.
+func runPhotosList(env *Env, args []string) error {
+    usage := "photos list [-json] <item id>"
+    flags := env.newFlagSet("photos list", usage)
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    photos, err := inventory.GetItemPhotos(env.Context, db, itemID)
+    if err != nil {
+        return err
+    }
+
+    rows := make([][]string, 0, len(photos))
+    for _, p := range photos {
+        rows = append(rows, []string{
+            strconv.Itoa(p.ID),
+            p.FileName,
+            fmt.Sprintf("%dx%d", p.Width, p.Height),
+            strconv.FormatInt(p.SizeBytes, 10),
+            p.Caption,
+        })
+    }
+    return env.print(photos, []string{"ID", "FILE", "SIZE", "BYTES", "CAPTION"}, rows)
+}
+
+// runPhotosAdd stores image files in the photo directory and attaches them to an item.
+func runPhotosAdd(env *Env, args []string) error {
+    usage := "photos add [-caption text] [-json] <item id> <file>..."
+    flags := env.newFlagSet("photos add", usage)
+    caption := flags.String("caption", "", "caption for the photos")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() < 2 {
+        return errUsage(usage)
+    }
+    itemID, err := strconv.Atoi(flags.Arg(0))
+    if err != nil {
+        return errUsage(usage)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    store := photo.NewStore(env.Config.Config.Photos.Dir)
+
+    added := []inventory.ItemPhoto{}
+    for _, path := range flags.Args()[1:] {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return err
+        }
+        stored, err := store.Save(data)
+        if err != nil {
+            return fmt.Errorf("%s: %w", path, err)
+        }
+        p := inventory.ItemPhoto{
+            ItemID:      itemID,
+            FileName:    stored.FileName,
+            ContentType: stored.ContentType,
+            Width:       stored.Width,
+            Height:      stored.Height,
+            SizeBytes:   stored.Size,
+            Caption:     *caption,
+        }
+        id, err := inventory.AddItemPhoto(env.Context, db, p)
+        if errors.Is(err, sql.ErrNoRows) {
+            return fmt.Errorf("no item with ID %d", itemID)
+        }
+        if err != nil {
+            return err
+        }
+        p.ID = int(id)
+        added = append(added, p)
+    }
+    return env.printMessage(added, "Attached %d photo(s) to item %d.", len(added), itemID)
+}
+
+// runPhotosPrune deletes photo files left behind by deleted items.
+func runPhotosPrune(env *Env, args []string) error {
+    usage := "photos prune [-json]"
+    if err := parseOnly(env.newFlagSet("photos prune", usage), args, usage); err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    inUse, err := inventory.PhotoFileNames(env.Context, db)
+    if err != nil {
+        return err
+    }
+    store := photo.NewStore(env.Config.Config.Photos.Dir)
+    removed, err := store.Prune(inUse)
+    if err != nil {
+        return err
+    }
+    if removed == nil {
+        removed = []string{}
+    }
+    return env.printMessage(removed, "Removed %d unused photo(s) from %s.", len(removed), store.Dir())
+}
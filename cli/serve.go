@@ -0,0 +1,164 @@
+package cli
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "os"
+    "os/signal"
+    "sync"
+    "syscall"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/photo"
+    "myhomeinventory/server"
+)
+
+// serveCommand starts the HTTP server.
+func serveCommand() *Command {
+    return &Command{
+        Name:    "serve",
+        Summary: "start the web server (default when no command is given)",
+        Run:     runServe,
+    }
+}
+
+// runServe ensures the required tables exist, sets up the router, and serves until
+// SIGINT or SIGTERM. On a signal it stops accepting connections, lets in-flight requests
+// and background jobs finish within the shutdown timeout, and then the database is closed.
+// A second signal during the drain stops the process immediately.
+func runServe(env *Env, args []string) error {
+    flags := env.newFlagSet("serve", "serve [-dev]")
+    dev := flags.Bool("dev", false, "serve templates and static files from the working directory, reloading templates on every request")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    assets := env.Assets
+    if *dev {
+        assets = os.DirFS(".")
+        slog.Info("dev mode: serving templates and static files from disk")
+    }
+
+    db, err := env.connect()
+    if err != nil {
+        return err
+    }
+
+    db.EnsureTables(env.Context)
+    if err := inventory.RebuildSearchIndex(env.Context, db); err != nil {
+        return fmt.Errorf("failed to rebuild the search index: %w", err)
+    }
+    slog.Info("database is ready")
+
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+    defer stop()
+
+    jobsCtx, cancelJobs := context.WithCancel(context.Background())
+    defer cancelJobs()
+    var jobs sync.WaitGroup
+    jobs.Add(1)
+    go func() {
+        defer jobs.Done()
+        db.MonitorHealth(jobsCtx)
+    }()
+
+    cfg := env.Config.Config.Server
+    photos := env.Config.Config.Photos
+    router, err := server.NewRouter(db, server.Options{
+        RequestTimeout: cfg.RequestTimeout,
+        PublicURL:      cfg.PublicURL,
+        Assets:         assets,
+        Dev:            *dev,
+        Photos:         photo.NewStore(photos.Dir),
+        MaxPhotoUpload: int64(photos.MaxUploadMB) << 20,
+    })
+    if err != nil {
+        return err
+    }
+    srv := &http.Server{
+        Addr:              cfg.Address(),
+        Handler:           router,
+        ReadTimeout:       cfg.ReadTimeout,
+        ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+        WriteTimeout:      cfg.WriteTimeout,
+        IdleTimeout:       cfg.IdleTimeout,
+    }
+
+    slog.Info("server listening", "url", "http://"+srv.Addr)
+
+    serveErr := make(chan error, 1)
+    go func() {
+        serveErr <- srv.ListenAndServe()
+    }()
+
+    select {
+    case err := <-serveErr:
+        return fmt.Errorf("server stopped: %w", err)
+    case <-ctx.Done():
+    }
+    stop()
+
+    slog.Info("shutting down: draining requests and background jobs", "timeout", cfg.ShutdownTimeout)
+    drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+    defer cancel()
+
+    shutdownErr := srv.Shutdown(drainCtx)
+    cancelJobs()
+    jobsDone := make(chan struct{})
+    go func() {
+        jobs.Wait()
+        close(jobsDone)
+    }()
+    select {
+    case <-jobsDone:
+    case <-drainCtx.Done():
+    }
+
+    if shutdownErr != nil || drainCtx.Err() != nil {
+        srv.Close()
+        return &exitError{code: exitShutdownTimeout, err: fmt.Errorf("shutdown did not finish within %s; remaining requests were cut off", cfg.ShutdownTimeout)}
+    }
+    slog.Info("server stopped")
+    return nil
+}
+
+// migrateCommand creates missing tables and records the schema version.
+func migrateCommand() *Command {
+    return &Command{
+        Name:    "migrate",
+        Summary: "create missing tables and record the schema version",
+        Run:     runMigrate,
+    }
+}
+
+// runMigrate brings the database schema up to date without prompting.
+func runMigrate(env *Env, args []string) error {
+    flags := env.newFlagSet("migrate", "migrate")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+
+    db, err := env.connect()
+    if err != nil {
+        return err
+    }
+
+    before, err := db.GetSchemaVersion(env.Context)
+    if err != nil {
+        return err
+    }
+    if err := db.CreateMissingTables(env.Context); err != nil {
+        return err
+    }
+    if err := inventory.RebuildSearchIndex(env.Context, db); err != nil {
+        return err
+    }
+
+    result := map[string]interface{}{
+        "previousVersion": before,
+        "schemaVersion":   inventory.SchemaVersion,
+    }
+    return env.printMessage(result, "Schema migrated from version %d to %d.", before, inventory.SchemaVersion)
+}
@@ -0,0 +1,180 @@
+package cli
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// exportCommand writes the inventory as JSON or CSV.
+func exportCommand() *Command {
+    return &Command{
+        Name:    "export",
+        Summary: "export the inventory",
+        Run:     runExport,
+    }
+}
+
+// importCommand imports a JSON, CSV or zip export.
+func importCommand() *Command {
+    return &Command{
+        Name:    "import",
+        Summary: "import an export file",
+        Run:     runImport,
+    }
+}
+
+// catalogCommand groups the product catalog commands.
+func catalogCommand() *Command {
+    return &Command{
+        Name:    "catalog",
+        Summary: "manage the product catalog",
+        Subcommands: []*Command{
+            {
+                Name:    "load",
+                Summary: "bulk-load an Open Food Facts dump",
+                Run:     runCatalogLoad,
+            },
+        },
+    }
+}
+
+// runCatalogLoad bulk-loads the product catalog from an Open Food Facts dump file.
+func runCatalogLoad(env *Env, args []string) error {
+    usage := "catalog load [-json] <dump-file>"
+    path, err := parseName(env, "catalog load", usage, args)
+    if err != nil {
+        return err
+    }
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    f, err := os.Open(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+
+    fmt.Fprintln(env.Stderr, "Loading product catalog from", path)
+    stats, err := inventory.LoadProductCatalog(env.Context, db, f)
+    if err != nil {
+        return err
+    }
+    return env.printMessage(stats, "Catalog load complete: %d read, %d loaded, %d skipped.", stats.Read, stats.Loaded, stats.Skipped)
+}
+
+// runExport writes the inventory as JSON or CSV to a file or standard output.
+func runExport(env *Env, args []string) error {
+    usage := "export [-format json|csv] [-table items|types|substitutions|expirations] [-out file]"
+    flags := env.newFlagSet("export", usage)
+    format := flags.String("format", "json", "output format: json or csv")
+    table := flags.String("table", "", "table to export as CSV (all tables as a zip when omitted)")
+    out := flags.String("out", "", "output file (standard output when omitted)")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+    if env.JSON {
+        *format = "json"
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    doc, err := inventory.ExportInventory(env.Context, db)
+    if err != nil {
+        return err
+    }
+
+    w := env.Stdout
+    if *out != "" {
+        f, err := os.Create(*out)
+        if err != nil {
+            return err
+        }
+        defer f.Close()
+        w = f
+    }
+
+    switch {
+    case *format == "json":
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(doc)
+    case *format == "csv" && *table == "":
+        if *out == "" {
+            return fmt.Errorf("exporting every table as CSV writes a zip archive; use -out or -table")
+        }
+        return inventory.WriteExportZip(w, doc)
+    case *format == "csv":
+        return inventory.WriteExportCSV(w, doc, *table)
+    }
+    return fmt.Errorf("invalid format %q: must be json or csv", *format)
+}
+
+// runImport imports a JSON, CSV or zip file and prints the report.
+func runImport(env *Env, args []string) error {
+    usage := "import [-format json|csv|zip] [-table name] [-dry-run] [-create-missing] [-json] <file>"
+    flags := env.newFlagSet("import", usage)
+    format := flags.String("format", "", "input format: json, csv or zip (inferred when omitted)")
+    table := flags.String("table", "", "table a CSV file holds (inferred from the header when omitted)")
+    dryRun := flags.Bool("dry-run", false, "validate without writing anything")
+    createMissing := flags.Bool("create-missing", false, "create item types and substitutions that do not exist")
+    if err := flags.Parse(args); err != nil {
+        return err
+    }
+    if flags.NArg() != 1 {
+        return errUsage(usage)
+    }
+
+    data, err := os.ReadFile(flags.Arg(0))
+    if err != nil {
+        return err
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+
+    opts := inventory.ImportOptions{DryRun: *dryRun, CreateMissing: *createMissing}
+    report, err := inventory.ImportData(env.Context, db, data, *format, *table, flags.Arg(0), opts)
+    if err != nil {
+        return err
+    }
+
+    if env.JSON {
+        if err := env.printMessage(report, ""); err != nil {
+            return err
+        }
+    } else {
+        printImportReport(env.Stdout, report)
+    }
+
+    if len(report.Errors) > 0 {
+        return fmt.Errorf("import rejected: %d invalid row(s), nothing was written", len(report.Errors))
+    }
+    return nil
+}
+
+// printImportReport writes a human-readable import report.
+func printImportReport(w io.Writer, report inventory.ImportReport) {
+    for _, rowErr := range report.Errors {
+        fmt.Fprintln(w, " -", rowErr.Error())
+    }
+    fmt.Fprintf(w, "Types created: %d, substitutions created: %d, items created: %d, items updated: %d, expirations imported: %d\n",
+        report.TypesCreated, report.SubstitutionsCreated, report.ItemsCreated, report.ItemsUpdated, report.ExpirationsImported)
+
+    switch {
+    case len(report.Errors) > 0:
+    case report.DryRun:
+        fmt.Fprintln(w, "Dry run: no changes were written.")
+    default:
+        fmt.Fprintln(w, "Import applied.")
+    }
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+    "os"
+
+    "myhomeinventory/internal/tui"
+)
+
+// tuiCommand starts the terminal UI.
+func tuiCommand() *Command {
+    return &Command{
+        Name:    "tui",
+        Summary: "browse and update the inventory in a full-screen terminal UI",
+        Run:     runTUI,
+    }
+}
+
+// runTUI starts the terminal UI against the database, or against a running server with -server.
+func runTUI(env *Env, args []string) error {
+    usage := "tui [-server url] [-days n]"
+    flags := env.newFlagSet("tui", usage)
+    serverURL := flags.String("server", os.Getenv("INVENTORY_SERVER"), "URL of a running server to use instead of the database (e.g. http://localhost:8080)")
+    days := flags.Int("days", 7, "show units expiring within this many days")
+    if err := parseOnly(flags, args, usage); err != nil {
+        return err
+    }
+
+    opts := tui.Options{ExpiringDays: *days, Output: env.Stdout}
+    if *serverURL != "" {
+        return tui.Run(tui.NewAPIBackend(*serverURL), opts)
+    }
+
+    db, err := env.Database()
+    if err != nil {
+        return err
+    }
+    return tui.Run(tui.DatabaseBackend{DB: db}, opts)
+}
@@ -3,7 +3,8 @@ module myhomeinventory
 go 1.22
 
 require (
-	filippo.io/edwards25519 v1.1.0 // indirect
-	github.com/go-sql-driver/mysql v1.9.2 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
+	github.com/go-sql-driver/mysql v1.9.2
+	github.com/joho/godotenv v1.5.1
 )
+
+require filippo.io/edwards25519 v1.1.0 // indirect
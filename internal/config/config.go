@@ -0,0 +1,443 @@
+// Package config loads the application configuration from defaults, an optional
+// config file, environment variables and command-line flags, in increasing order
+// of precedence. A .env file in the working directory is read into the environment
+// when present but is never required.
+package config
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "slices"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/joho/godotenv"
+)
+
+// Config is the complete application configuration.
+type Config struct {
+    Database Database
+    Server   Server
+    Log      Log
+    Photos   Photos
+}
+
+// Database holds the MySQL connection and pool settings.
+type Database struct {
+    // DSN, when set, replaces the connection settings below except TLS.
+    DSN      string
+    User     string
+    Password string
+    Host     string
+    Port     int
+    // Socket connects through a unix socket instead of Host and Port.
+    Socket    string
+    Name      string
+    Collation string
+
+    // TLS is "", "false", "true", "skip-verify" or "preferred".
+    TLS     string
+    TLSCA   string
+    TLSCert string
+    TLSKey  string
+
+    ConnectTimeout time.Duration
+    ReadTimeout    time.Duration
+    WriteTimeout   time.Duration
+
+    MaxOpenConns    int
+    MaxIdleConns    int
+    ConnMaxLifetime time.Duration
+    ConnMaxIdleTime time.Duration
+
+    // BootTimeout is how long startup keeps retrying an unreachable database.
+    BootTimeout time.Duration
+    // HealthInterval is how often a running server checks the connection.
+    HealthInterval time.Duration
+}
+
+// TLSModes lists the accepted values of Database.TLS.
+var TLSModes = []string{"", "false", "true", "skip-verify", "preferred"}
+
+// Server holds the HTTP listener settings.
+type Server struct {
+    Host string
+    Port int
+    // PublicURL is the address clients reach the server at, e.g. behind a reverse proxy.
+    // Printed labels link there; when empty the request's own host is used.
+    PublicURL string
+
+    ReadTimeout       time.Duration
+    ReadHeaderTimeout time.Duration
+    WriteTimeout      time.Duration
+    IdleTimeout       time.Duration
+    // RequestTimeout bounds the database work done for a single request.
+    RequestTimeout time.Duration
+    // ShutdownTimeout is how long in-flight requests and background jobs get to finish.
+    ShutdownTimeout time.Duration
+}
+
+// Address returns the host:port the server listens on.
+func (s Server) Address() string {
+    return fmt.Sprintf("%s:%d", s.Host, s.Port)
+}
+
+// Log holds the logging settings.
+type Log struct {
+    // Format is "text" or "json".
+    Format string
+    // Level is "debug", "info", "warn" or "error".
+    Level string
+}
+
+// Photos holds the item photo storage settings.
+type Photos struct {
+    // Dir is where uploaded photos and their thumbnails are stored.
+    Dir string
+    // MaxUploadMB is the largest upload request accepted, in megabytes.
+    MaxUploadMB int
+}
+
+// LogFormats lists the accepted values of Log.Format.
+var LogFormats = []string{"text", "json"}
+
+// LogLevels lists the accepted values of Log.Level.
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// Sources a setting's value can come from, lowest precedence first.
+const (
+    SourceDefault = "default"
+    SourceFile    = "file"
+    SourceEnv     = "env"
+    SourceFlag    = "flag"
+)
+
+// setting describes one configuration value and every place it can be set.
+type setting struct {
+    key      string // dotted name used in config files, e.g. database.user
+    env      string // environment variable
+    flag     string // command-line flag
+    usage    string
+    def      string // default value; empty means none
+    required bool
+    secret   bool
+    value    func(c *Config) interface{} // pointer to the *string, *int or *time.Duration field
+}
+
+// settings lists every configuration value.
+var settings = []setting{
+    {key: "database.dsn", env: "DB_DSN", flag: "db-dsn", usage: "full MySQL DSN, overriding the other connection settings", secret: true,
+        value: func(c *Config) interface{} { return &c.Database.DSN }},
+    {key: "database.user", env: "DB_USER", flag: "db-user", usage: "database user", required: true,
+        value: func(c *Config) interface{} { return &c.Database.User }},
+    {key: "database.password", env: "DB_PASSWORD", flag: "db-password", usage: "database password", secret: true,
+        value: func(c *Config) interface{} { return &c.Database.Password }},
+    {key: "database.host", env: "DB_HOST", flag: "db-host", usage: "database host", def: "localhost",
+        value: func(c *Config) interface{} { return &c.Database.Host }},
+    {key: "database.port", env: "DB_PORT", flag: "db-port", usage: "database port", def: "3306",
+        value: func(c *Config) interface{} { return &c.Database.Port }},
+    {key: "database.socket", env: "DB_SOCKET", flag: "db-socket", usage: "unix socket path, used instead of host and port",
+        value: func(c *Config) interface{} { return &c.Database.Socket }},
+    {key: "database.name", env: "DB_NAME", flag: "db-name", usage: "database (schema) name", required: true,
+        value: func(c *Config) interface{} { return &c.Database.Name }},
+    {key: "database.collation", env: "DB_COLLATION", flag: "db-collation", usage: "connection collation, e.g. utf8mb4_unicode_ci",
+        value: func(c *Config) interface{} { return &c.Database.Collation }},
+    {key: "database.tls", env: "DB_TLS", flag: "db-tls", usage: "TLS mode: false, true, skip-verify or preferred",
+        value: func(c *Config) interface{} { return &c.Database.TLS }},
+    {key: "database.tls_ca", env: "DB_TLS_CA", flag: "db-tls-ca", usage: "PEM file of the CA that signed the server certificate",
+        value: func(c *Config) interface{} { return &c.Database.TLSCA }},
+    {key: "database.tls_cert", env: "DB_TLS_CERT", flag: "db-tls-cert", usage: "PEM client certificate",
+        value: func(c *Config) interface{} { return &c.Database.TLSCert }},
+    {key: "database.tls_key", env: "DB_TLS_KEY", flag: "db-tls-key", usage: "PEM client certificate key",
+        value: func(c *Config) interface{} { return &c.Database.TLSKey }},
+    {key: "database.connect_timeout", env: "DB_CONNECT_TIMEOUT", flag: "db-connect-timeout", usage: "timeout for establishing a connection", def: "10s",
+        value: func(c *Config) interface{} { return &c.Database.ConnectTimeout }},
+    {key: "database.read_timeout", env: "DB_READ_TIMEOUT", flag: "db-read-timeout", usage: "I/O read timeout (0 disables)", def: "30s",
+        value: func(c *Config) interface{} { return &c.Database.ReadTimeout }},
+    {key: "database.write_timeout", env: "DB_WRITE_TIMEOUT", flag: "db-write-timeout", usage: "I/O write timeout (0 disables)", def: "30s",
+        value: func(c *Config) interface{} { return &c.Database.WriteTimeout }},
+    {key: "database.max_open_conns", env: "DB_MAX_OPEN_CONNS", flag: "db-max-open-conns", usage: "maximum open connections (0 is unlimited)", def: "10",
+        value: func(c *Config) interface{} { return &c.Database.MaxOpenConns }},
+    {key: "database.max_idle_conns", env: "DB_MAX_IDLE_CONNS", flag: "db-max-idle-conns", usage: "maximum idle connections", def: "5",
+        value: func(c *Config) interface{} { return &c.Database.MaxIdleConns }},
+    {key: "database.conn_max_lifetime", env: "DB_CONN_MAX_LIFETIME", flag: "db-conn-max-lifetime", usage: "maximum time a connection is reused (0 is forever)", def: "30m",
+        value: func(c *Config) interface{} { return &c.Database.ConnMaxLifetime }},
+    {key: "database.conn_max_idle_time", env: "DB_CONN_MAX_IDLE_TIME", flag: "db-conn-max-idle-time", usage: "maximum time a connection stays idle (0 is forever)", def: "5m",
+        value: func(c *Config) interface{} { return &c.Database.ConnMaxIdleTime }},
+    {key: "database.boot_timeout", env: "DB_BOOT_TIMEOUT", flag: "db-boot-timeout", usage: "how long to keep retrying the database at startup (0 tries once)", def: "60s",
+        value: func(c *Config) interface{} { return &c.Database.BootTimeout }},
+    {key: "database.health_interval", env: "DB_HEALTH_INTERVAL", flag: "db-health-interval", usage: "how often the server checks the database connection (0 disables)", def: "15s",
+        value: func(c *Config) interface{} { return &c.Database.HealthInterval }},
+    {key: "server.host", env: "APP_HOST", flag: "host", usage: "address the web server listens on", def: "localhost",
+        value: func(c *Config) interface{} { return &c.Server.Host }},
+    {key: "server.port", env: "APP_PORT", flag: "port", usage: "port the web server listens on", def: "8080",
+        value: func(c *Config) interface{} { return &c.Server.Port }},
+    {key: "server.public_url", env: "APP_PUBLIC_URL", flag: "public-url", usage: "public base URL printed labels link to, e.g. https://inventory.example.com",
+        value: func(c *Config) interface{} { return &c.Server.PublicURL }},
+    {key: "server.read_timeout", env: "APP_READ_TIMEOUT", flag: "read-timeout", usage: "maximum time to read a request including its body", def: "60s",
+        value: func(c *Config) interface{} { return &c.Server.ReadTimeout }},
+    {key: "server.read_header_timeout", env: "APP_READ_HEADER_TIMEOUT", flag: "read-header-timeout", usage: "maximum time to read request headers", def: "10s",
+        value: func(c *Config) interface{} { return &c.Server.ReadHeaderTimeout }},
+    {key: "server.write_timeout", env: "APP_WRITE_TIMEOUT", flag: "write-timeout", usage: "maximum time to write a response", def: "120s",
+        value: func(c *Config) interface{} { return &c.Server.WriteTimeout }},
+    {key: "server.idle_timeout", env: "APP_IDLE_TIMEOUT", flag: "idle-timeout", usage: "how long idle keep-alive connections stay open", def: "120s",
+        value: func(c *Config) interface{} { return &c.Server.IdleTimeout }},
+    {key: "server.request_timeout", env: "APP_REQUEST_TIMEOUT", flag: "request-timeout", usage: "maximum time a request may spend on the database (0 disables)", def: "30s",
+        value: func(c *Config) interface{} { return &c.Server.RequestTimeout }},
+    {key: "server.shutdown_timeout", env: "APP_SHUTDOWN_TIMEOUT", flag: "shutdown-timeout", usage: "how long to drain requests and jobs on shutdown", def: "30s",
+        value: func(c *Config) interface{} { return &c.Server.ShutdownTimeout }},
+    {key: "log.format", env: "LOG_FORMAT", flag: "log-format", usage: "log output format: text or json", def: "text",
+        value: func(c *Config) interface{} { return &c.Log.Format }},
+    {key: "log.level", env: "LOG_LEVEL", flag: "log-level", usage: "minimum log level: debug, info, warn or error", def: "info",
+        value: func(c *Config) interface{} { return &c.Log.Level }},
+    {key: "photos.dir", env: "APP_PHOTO_DIR", flag: "photo-dir", usage: "directory item photos are stored in", def: "photos",
+        value: func(c *Config) interface{} { return &c.Photos.Dir }},
+    {key: "photos.max_upload_mb", env: "APP_PHOTO_MAX_UPLOAD_MB", flag: "photo-max-upload-mb", usage: "largest photo upload accepted, in megabytes", def: "25",
+        value: func(c *Config) interface{} { return &c.Photos.MaxUploadMB }},
+}
+
+// configFileEnv names the environment variable that points at a config file.
+const configFileEnv = "INVENTORY_CONFIG"
+
+// Loaded is a configuration together with where each value came from.
+type Loaded struct {
+    Config  Config
+    File    string
+    sources map[string]string
+    set     map[string]bool
+}
+
+// Load builds the configuration. Global flags are parsed from the front of args and the
+// remaining arguments are returned. The configuration is returned even when validation
+// fails so it can be printed; the error then lists every problem found.
+func Load(args []string) (*Loaded, []string, error) {
+    flagValues := map[string]string{}
+    var configFile string
+
+    fs := newFlagSet(&configFile, flagValues)
+    if err := fs.Parse(args); err != nil {
+        return nil, nil, err
+    }
+    rest := fs.Args()
+
+    l := &Loaded{sources: map[string]string{}, set: map[string]bool{}}
+    var errs []error
+
+    // A .env file only seeds the environment; variables that are already set win.
+    if _, err := os.Stat(".env"); err == nil {
+        if err := godotenv.Load(); err != nil {
+            errs = append(errs, fmt.Errorf(".env: %w", err))
+        }
+    }
+
+    if configFile == "" {
+        configFile = os.Getenv(configFileEnv)
+    }
+    fileValues := map[string]string{}
+    if configFile != "" {
+        values, err := readFile(configFile)
+        if err != nil {
+            errs = append(errs, err)
+        }
+        fileValues = values
+        l.File = configFile
+    }
+
+    for _, s := range settings {
+        raw, source := s.def, SourceDefault
+        if v, ok := fileValues[s.key]; ok {
+            raw, source = v, SourceFile
+        }
+        if v, ok := os.LookupEnv(s.env); ok && v != "" {
+            raw, source = v, SourceEnv
+        }
+        if v, ok := flagValues[s.flag]; ok {
+            raw, source = v, SourceFlag
+        }
+        l.sources[s.key] = source
+        l.set[s.key] = raw != ""
+
+        if err := assign(s.value(&l.Config), raw); err != nil {
+            errs = append(errs, fmt.Errorf("%s (from %s): %w", s.key, l.describeSource(s), err))
+            l.set[s.key] = false
+        }
+    }
+
+    errs = append(errs, l.validate()...)
+    return l, rest, errors.Join(errs...)
+}
+
+// newFlagSet defines the global flags, recording explicitly set values in values.
+func newFlagSet(configFile *string, values map[string]string) *flag.FlagSet {
+    fs := flag.NewFlagSet("myhomeinventory", flag.ContinueOnError)
+    fs.SetOutput(io.Discard)
+    fs.StringVar(configFile, "config", "", "config file (.toml, .yaml or .yml); also "+configFileEnv)
+    for _, s := range settings {
+        s := s
+        usage := fmt.Sprintf("%s (env %s", s.usage, s.env)
+        if s.def != "" {
+            usage += ", default " + s.def
+        }
+        usage += ")"
+        fs.Func(s.flag, usage, func(v string) error {
+            values[s.flag] = v
+            return nil
+        })
+    }
+    return fs
+}
+
+// PrintFlags writes the global flags and their descriptions to w.
+func PrintFlags(w io.Writer) {
+    var configFile string
+    fs := newFlagSet(&configFile, map[string]string{})
+    fs.SetOutput(w)
+    fs.PrintDefaults()
+}
+
+// assign parses raw into the field ptr points at.
+func assign(ptr interface{}, raw string) error {
+    switch p := ptr.(type) {
+    case *string:
+        *p = raw
+    case *int:
+        if raw == "" {
+            *p = 0
+            return nil
+        }
+        n, err := strconv.Atoi(raw)
+        if err != nil {
+            return fmt.Errorf("invalid number %q", raw)
+        }
+        *p = n
+    case *time.Duration:
+        if raw == "" {
+            *p = 0
+            return nil
+        }
+        d, err := time.ParseDuration(raw)
+        if err != nil {
+            // A bare number is taken as seconds.
+            n, nerr := strconv.Atoi(raw)
+            if nerr != nil {
+                return fmt.Errorf("invalid duration %q (use e.g. 30s or 5m)", raw)
+            }
+            d = time.Duration(n) * time.Second
+        }
+        if d < 0 {
+            return fmt.Errorf("must not be negative, got %s", raw)
+        }
+        *p = d
+    }
+    return nil
+}
+
+// validate checks required settings and value ranges.
+func (l *Loaded) validate() []error {
+    var errs []error
+    db := l.Config.Database
+    for _, s := range settings {
+        // A DSN carries the user and database name itself.
+        if s.required && !l.set[s.key] && !(db.DSN != "" && strings.HasPrefix(s.key, "database.")) {
+            errs = append(errs, fmt.Errorf("%s is required: set %s, -%s or %s in the config file", s.key, s.env, s.flag, s.key))
+        }
+    }
+    for key, port := range map[string]int{"database.port": l.Config.Database.Port, "server.port": l.Config.Server.Port} {
+        if l.set[key] && (port < 1 || port > 65535) {
+            errs = append(errs, fmt.Errorf("%s must be between 1 and 65535, got %d", key, port))
+        }
+    }
+    if l.set["server.public_url"] {
+        if u, err := url.Parse(l.Config.Server.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            errs = append(errs, fmt.Errorf("server.public_url must be an absolute http or https URL, got %q", l.Config.Server.PublicURL))
+        }
+    }
+    if !slices.Contains(TLSModes, db.TLS) {
+        errs = append(errs, fmt.Errorf("database.tls must be one of false, true, skip-verify or preferred, got %q", db.TLS))
+    }
+    if db.TLS == "false" && (db.TLSCA != "" || db.TLSCert != "") {
+        errs = append(errs, errors.New("database.tls_ca and database.tls_cert cannot be used with database.tls=false"))
+    }
+    if (db.TLSCert == "") != (db.TLSKey == "") {
+        errs = append(errs, errors.New("database.tls_cert and database.tls_key must be set together"))
+    }
+    for key, path := range map[string]string{"database.tls_ca": db.TLSCA, "database.tls_cert": db.TLSCert, "database.tls_key": db.TLSKey} {
+        if path == "" {
+            continue
+        }
+        if _, err := os.Stat(path); err != nil {
+            errs = append(errs, fmt.Errorf("%s: %w", key, err))
+        }
+    }
+    for key, n := range map[string]int{"database.max_open_conns": db.MaxOpenConns, "database.max_idle_conns": db.MaxIdleConns} {
+        if n < 0 {
+            errs = append(errs, fmt.Errorf("%s must not be negative, got %d", key, n))
+        }
+    }
+    if !slices.Contains(LogFormats, l.Config.Log.Format) {
+        errs = append(errs, fmt.Errorf("log.format must be text or json, got %q", l.Config.Log.Format))
+    }
+    if !slices.Contains(LogLevels, l.Config.Log.Level) {
+        errs = append(errs, fmt.Errorf("log.level must be one of debug, info, warn or error, got %q", l.Config.Log.Level))
+    }
+    if l.Config.Photos.Dir == "" {
+        errs = append(errs, errors.New("photos.dir must not be empty"))
+    }
+    if l.Config.Photos.MaxUploadMB < 1 {
+        errs = append(errs, fmt.Errorf("photos.max_upload_mb must be at least 1, got %d", l.Config.Photos.MaxUploadMB))
+    }
+    sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+    return errs
+}
+
+// describeSource names where a setting's value came from, e.g. "env DB_USER".
+func (l *Loaded) describeSource(s setting) string {
+    switch l.sources[s.key] {
+    case SourceFile:
+        return "file " + l.File
+    case SourceEnv:
+        return "env " + s.env
+    case SourceFlag:
+        return "flag -" + s.flag
+    }
+    return SourceDefault
+}
+
+// Entry is one setting as shown by config print.
+type Entry struct {
+    Key    string `json:"key"`
+    Value  string `json:"value"`
+    Source string `json:"source"`
+}
+
+// Entries lists every setting with its effective value and source. Secrets are redacted.
+func (l *Loaded) Entries() []Entry {
+    entries := make([]Entry, 0, len(settings))
+    for _, s := range settings {
+        value := fmt.Sprint(reflectValue(s.value(&l.Config)))
+        if s.secret && value != "" {
+            value = "********"
+        }
+        entries = append(entries, Entry{Key: s.key, Value: value, Source: l.describeSource(s)})
+    }
+    return entries
+}
+
+// reflectValue dereferences a setting's field pointer.
+func reflectValue(ptr interface{}) interface{} {
+    switch p := ptr.(type) {
+    case *string:
+        return *p
+    case *int:
+        return *p
+    case *time.Duration:
+        return *p
+    }
+    return nil
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+    "bufio"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// readFile reads a config file into dotted keys. It understands the subset of TOML
+// and YAML needed for flat sections of scalar values:
+//
+//  TOML                  YAML
+//  [database]            database:
+//  user = "inventory"      user: inventory
+//  port = 3306             port: 3306
+func readFile(path string) (map[string]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, fmt.Errorf("config file: %w", err)
+    }
+    defer f.Close()
+
+    var yaml bool
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".toml":
+    case ".yaml", ".yml":
+        yaml = true
+    default:
+        return nil, fmt.Errorf("config file %s: unsupported format; use .toml, .yaml or .yml", path)
+    }
+
+    known := map[string]bool{}
+    for _, s := range settings {
+        known[s.key] = true
+    }
+
+    values := map[string]string{}
+    var errs []error
+    section := ""
+    scanner := bufio.NewScanner(f)
+    for lineNo := 1; scanner.Scan(); lineNo++ {
+        raw := scanner.Text()
+        line := strings.TrimSpace(stripComment(raw))
+        if line == "" {
+            continue
+        }
+        fail := func(format string, args ...interface{}) {
+            errs = append(errs, fmt.Errorf("%s:%d: %s", path, lineNo, fmt.Sprintf(format, args...)))
+        }
+
+        var key, value string
+        switch {
+        case !yaml && strings.HasPrefix(line, "["):
+            if !strings.HasSuffix(line, "]") {
+                fail("malformed section header %q", line)
+                continue
+            }
+            section = strings.TrimSpace(line[1 : len(line)-1])
+            continue
+        case !yaml:
+            k, v, ok := strings.Cut(line, "=")
+            if !ok {
+                fail("expected key = value")
+                continue
+            }
+            key, value = strings.TrimSpace(k), strings.TrimSpace(v)
+        default:
+            k, v, ok := strings.Cut(line, ":")
+            if !ok {
+                fail("expected key: value")
+                continue
+            }
+            key, value = strings.TrimSpace(k), strings.TrimSpace(v)
+            indented := raw[0] == ' ' || raw[0] == '\t'
+            if !indented {
+                section = ""
+                if value == "" {
+                    section = key
+                    continue
+                }
+            }
+        }
+
+        if section != "" {
+            key = section + "." + key
+        }
+        if !known[key] {
+            fail("unknown setting %q", key)
+            continue
+        }
+        values[key] = unquote(value)
+    }
+    if err := scanner.Err(); err != nil {
+        errs = append(errs, fmt.Errorf("config file %s: %w", path, err))
+    }
+    return values, errors.Join(errs...)
+}
+
+// stripComment removes a # comment that is not inside quotes.
+func stripComment(line string) string {
+    var quote rune
+    for i, r := range line {
+        switch {
+        case quote != 0:
+            if r == quote {
+                quote = 0
+            }
+        case r == '"' || r == '\'':
+            quote = r
+        case r == '#':
+            return line[:i]
+        }
+    }
+    return line
+}
+
+// unquote removes matching single or double quotes around a value.
+func unquote(value string) string {
+    if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+        return value[1 : len(value)-1]
+    }
+    return value
+}
@@ -0,0 +1,289 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "encoding/csv"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// Item kinds accepted by ItemListQuery.Kind.
+const (
+    ItemKindAsset      = "asset"
+    ItemKindConsumable = "consumable"
+)
+
+// unassignedRoom is the insurance report heading for assets with no location.
+const unassignedRoom = "Unassigned"
+
+// maxMoneyCents is the largest amount a DECIMAL(12,2) column holds.
+const maxMoneyCents = 999_999_999_999
+
+// ParseMoney parses an amount such as "1234.56", "$1,234.56" or "80" into cents.
+func ParseMoney(s string) (int64, error) {
+    clean := strings.NewReplacer("$", "", ",", "", " ", "").Replace(strings.TrimSpace(s))
+    whole, frac, hasFrac := strings.Cut(clean, ".")
+    if whole == "" && hasFrac {
+        whole = "0"
+    }
+    if len(frac) > 2 || (hasFrac && frac == "") {
+        return 0, fmt.Errorf("invalid amount %q", s)
+    }
+    for len(frac) < 2 {
+        frac += "0"
+    }
+    units, err := strconv.ParseInt(whole, 10, 64)
+    if err != nil || units < 0 || strings.HasPrefix(whole, "+") {
+        return 0, fmt.Errorf("invalid amount %q", s)
+    }
+    cents, err := strconv.ParseInt(frac, 10, 64)
+    if err != nil || strings.HasPrefix(frac, "-") || strings.HasPrefix(frac, "+") {
+        return 0, fmt.Errorf("invalid amount %q", s)
+    }
+    if units > maxMoneyCents/100 {
+        return 0, fmt.Errorf("amount %q is too large", s)
+    }
+    return units*100 + cents, nil
+}
+
+// FormatMoney formats cents as an amount with thousands separators, e.g. "1,234.56".
+func FormatMoney(cents int64) string {
+    sign := ""
+    if cents < 0 {
+        sign, cents = "-", -cents
+    }
+    units := strconv.FormatInt(cents/100, 10)
+    var grouped strings.Builder
+    for i, digit := range units {
+        if i > 0 && (len(units)-i)%3 == 0 {
+            grouped.WriteByte(',')
+        }
+        grouped.WriteRune(digit)
+    }
+    return fmt.Sprintf("%s%s.%02d", sign, grouped.String(), cents%100)
+}
+
+// assetColumns selects an item_asset row for scanAsset, with amounts in cents.
+const assetColumns = `
+    d.item_id, d.manufacturer, d.model, d.serial_number, d.purchase_date,
+    CAST(ROUND(d.purchase_price * 100) AS SIGNED), d.warranty_expiry, CAST(ROUND(d.current_value * 100) AS SIGNED)`
+
+// scanAsset reads the columns selected by assetColumns, followed by any extra targets.
+func scanAsset(row interface{ Scan(...interface{}) error }, extra ...interface{}) (ItemAsset, error) {
+    var a ItemAsset
+    var purchaseDate, warrantyExpiry sql.NullTime
+    var purchasePrice, currentValue sql.NullInt64
+    targets := append([]interface{}{
+        &a.ItemID, &a.Manufacturer, &a.Model, &a.SerialNumber, &purchaseDate,
+        &purchasePrice, &warrantyExpiry, &currentValue,
+    }, extra...)
+    if err := row.Scan(targets...); err != nil {
+        return ItemAsset{}, err
+    }
+    if purchaseDate.Valid {
+        a.PurchaseDate = &purchaseDate.Time
+    }
+    if warrantyExpiry.Valid {
+        a.WarrantyExpiry = &warrantyExpiry.Time
+    }
+    if purchasePrice.Valid {
+        a.PurchasePrice = &purchasePrice.Int64
+    }
+    if currentValue.Valid {
+        a.CurrentValue = &currentValue.Int64
+    }
+    return a, nil
+}
+
+// GetItemAsset retrieves an item's durable-goods details. It returns sql.ErrNoRows when
+// the item is not an asset.
+func GetItemAsset(ctx context.Context, db *Database, itemID int) (ItemAsset, error) {
+    defer observe("GetItemAsset", time.Now())
+    return scanAsset(db.conn.QueryRowContext(ctx, `SELECT`+assetColumns+` FROM item_asset d WHERE d.item_id = ?`, itemID))
+}
+
+// SetItemAsset records an item's durable-goods details, making it an asset. It returns
+// sql.ErrNoRows when the item does not exist.
+func SetItemAsset(ctx context.Context, db *Database, a ItemAsset) error {
+    defer observe("SetItemAsset", time.Now())
+    a.Manufacturer = strings.TrimSpace(a.Manufacturer)
+    a.Model = strings.TrimSpace(a.Model)
+    a.SerialNumber = strings.TrimSpace(a.SerialNumber)
+    for field, value := range map[string]string{"manufacturer": a.Manufacturer, "model": a.Model, "serial number": a.SerialNumber} {
+        if utf8.RuneCountInString(value) > 128 {
+            return fmt.Errorf("%s must be at most 128 characters", field)
+        }
+    }
+    for field, value := range map[string]*int64{"purchase price": a.PurchasePrice, "current value": a.CurrentValue} {
+        if value != nil && (*value < 0 || *value > maxMoneyCents) {
+            return fmt.Errorf("%s must be between 0 and %s", field, FormatMoney(maxMoneyCents))
+        }
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var id int
+    if err := tx.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE id = ?`, a.ItemID).Scan(&id); err != nil {
+        return err
+    }
+    _, err = tx.ExecContext(ctx, `
+        INSERT INTO item_asset (item_id, manufacturer, model, serial_number, purchase_date, purchase_price, warranty_expiry, current_value)
+        VALUES (?, ?, ?, ?, ?, ? / 100, ?, ? / 100)
+        ON DUPLICATE KEY UPDATE
+            manufacturer = VALUES(manufacturer),
+            model = VALUES(model),
+            serial_number = VALUES(serial_number),
+            purchase_date = VALUES(purchase_date),
+            purchase_price = VALUES(purchase_price),
+            warranty_expiry = VALUES(warranty_expiry),
+            current_value = VALUES(current_value)
+    `, a.ItemID, a.Manufacturer, a.Model, a.SerialNumber, nullDate(a.PurchaseDate), nullCents(a.PurchasePrice),
+        nullDate(a.WarrantyExpiry), nullCents(a.CurrentValue))
+    if err != nil {
+        return err
+    }
+    if err := refreshItemSearch(ctx, tx, int64(a.ItemID)); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// RemoveItemAsset deletes an item's durable-goods details, making it a consumable again.
+func RemoveItemAsset(ctx context.Context, db *Database, itemID int) error {
+    defer observe("RemoveItemAsset", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM item_asset WHERE item_id = ?`, itemID); err != nil {
+        return err
+    }
+    if err := refreshItemSearch(ctx, tx, int64(itemID)); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// nullDate converts an optional date to a DATE argument.
+func nullDate(t *time.Time) interface{} {
+    if t == nil {
+        return nil
+    }
+    return t.Format(time.DateOnly)
+}
+
+// nullCents converts an optional amount to an argument; SQL divides it by 100.
+func nullCents(cents *int64) interface{} {
+    if cents == nil {
+        return nil
+    }
+    return *cents
+}
+
+// GetInsuranceReport lists every asset still owned (quantity above zero) grouped by
+// location, rooms and items in name order, with assets that have no location last.
+func GetInsuranceReport(ctx context.Context, db *Database) (InsuranceReport, error) {
+    defer observe("GetInsuranceReport", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT`+assetColumns+`, i.item_name, i.itemQTY, COALESCE(l.location_name, '')
+        FROM item_asset d
+        JOIN inventory_item i ON i.id = d.item_id
+        LEFT JOIN item_location_xref lx ON lx.item_id = i.id
+        LEFT JOIN item_location l ON l.id = lx.location_id
+        WHERE i.itemQTY > 0
+        ORDER BY l.location_name IS NULL, l.location_name ASC, i.item_name ASC, i.id ASC
+    `)
+    if err != nil {
+        return InsuranceReport{}, err
+    }
+    defer rows.Close()
+
+    report := InsuranceReport{Generated: time.Now(), Rooms: []InsuranceRoom{}}
+    for rows.Next() {
+        var line InsuranceLine
+        var room string
+        line.ItemAsset, err = scanAsset(rows, &line.ItemName, &line.Quantity, &room)
+        if err != nil {
+            return InsuranceReport{}, err
+        }
+        if room == "" {
+            room = unassignedRoom
+        }
+        if line.PurchasePrice != nil {
+            line.PurchaseTotal = *line.PurchasePrice * int64(line.Quantity)
+        }
+        if line.CurrentValue != nil {
+            line.ValueTotal = *line.CurrentValue * int64(line.Quantity)
+        } else {
+            report.Unvalued++
+        }
+
+        if n := len(report.Rooms); n == 0 || report.Rooms[n-1].Room != room {
+            report.Rooms = append(report.Rooms, InsuranceRoom{Room: room})
+        }
+        r := &report.Rooms[len(report.Rooms)-1]
+        r.Lines = append(r.Lines, line)
+        r.PurchaseTotal += line.PurchaseTotal
+        r.ValueTotal += line.ValueTotal
+        report.Items++
+        report.PurchaseTotal += line.PurchaseTotal
+        report.ValueTotal += line.ValueTotal
+    }
+    return report, rows.Err()
+}
+
+// WriteInsuranceCSV writes the report as one CSV row per asset followed by a total row
+// per room and a grand total. Amounts are plain decimals so spreadsheets can sum them;
+// text cells are passed through csvText so a spreadsheet does not run them as formulas.
+func WriteInsuranceCSV(w io.Writer, report InsuranceReport) error {
+    cw := csv.NewWriter(w)
+    cw.Write([]string{"room", "item", "quantity", "manufacturer", "model", "serial_number", "purchase_date",
+        "purchase_price", "warranty_expiry", "current_value", "purchase_total", "value_total"})
+
+    money := func(cents *int64) string {
+        if cents == nil {
+            return ""
+        }
+        return fmt.Sprintf("%d.%02d", *cents/100, *cents%100)
+    }
+    date := func(t *time.Time) string {
+        if t == nil {
+            return ""
+        }
+        return t.Format(time.DateOnly)
+    }
+    for _, room := range report.Rooms {
+        for _, line := range room.Lines {
+            cw.Write([]string{
+                csvText(room.Room), csvText(line.ItemName), strconv.Itoa(line.Quantity),
+                csvText(line.Manufacturer), csvText(line.Model), csvText(line.SerialNumber),
+                date(line.PurchaseDate), money(line.PurchasePrice), date(line.WarrantyExpiry), money(line.CurrentValue),
+                money(&line.PurchaseTotal), money(&line.ValueTotal),
+            })
+        }
+        cw.Write([]string{csvText(room.Room), "Room total", "", "", "", "", "", "", "", "", money(&room.PurchaseTotal), money(&room.ValueTotal)})
+    }
+    cw.Write([]string{"", "Grand total", "", "", "", "", "", "", "", "", money(&report.PurchaseTotal), money(&report.ValueTotal)})
+    cw.Flush()
+    return cw.Error()
+}
+
+// csvText prefixes text starting with =, +, - or @ with an apostrophe, which spreadsheets
+// would otherwise read as the start of a formula.
+func csvText(s string) string {
+    if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+        return "'" + s
+    }
+    return s
+}
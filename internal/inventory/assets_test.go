@@ -0,0 +1,102 @@
+package inventory
+
+import (
+    "bytes"
+    "encoding/csv"
+    "slices"
+    "testing"
+)
+
+func TestParseMoney(t *testing.T) {
+    tests := []struct {
+        in      string
+        want    int64
+        wantErr bool
+    }{
+        {"80", 8000, false},
+        {"1234.56", 123456, false},
+        {"$1,234.56", 123456, false},
+        {" 1234.5 ", 123450, false},
+        {".5", 50, false},
+        {"0.05", 5, false},
+        {"9999999999.99", maxMoneyCents, false},
+        {"10000000000", 0, true},
+        {"1.", 0, true},
+        {"-5", 0, true},
+        {"+5", 0, true},
+        {"1.-5", 0, true},
+        {"1.+5", 0, true},
+        {"1.234", 0, true},
+        {"", 0, true},
+        {"abc", 0, true},
+    }
+    for _, tt := range tests {
+        got, err := ParseMoney(tt.in)
+        if (err != nil) != tt.wantErr {
+            t.Errorf("ParseMoney(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestFormatMoney(t *testing.T) {
+    tests := []struct {
+        cents int64
+        want  string
+    }{
+        {0, "0.00"},
+        {5, "0.05"},
+        {8000, "80.00"},
+        {123456, "1,234.56"},
+        {100000000, "1,000,000.00"},
+        {-123456, "-1,234.56"},
+        {maxMoneyCents, "9,999,999,999.99"},
+    }
+    for _, tt := range tests {
+        if got := FormatMoney(tt.cents); got != tt.want {
+            t.Errorf("FormatMoney(%d) = %q, want %q", tt.cents, got, tt.want)
+        }
+    }
+}
+
+func TestWriteInsuranceCSVEscapesFormulas(t *testing.T) {
+    price := int64(123456)
+    report := InsuranceReport{
+        Rooms: []InsuranceRoom{{
+            Room: "@Garage",
+            Lines: []InsuranceLine{{
+                ItemAsset: ItemAsset{
+                    Manufacturer:  "=HYPERLINK(\"http://example.com\")",
+                    Model:         "+X100",
+                    SerialNumber:  "-42",
+                    PurchasePrice: &price,
+                },
+                ItemName:      "=1+1",
+                Quantity:      1,
+                PurchaseTotal: price,
+            }},
+            PurchaseTotal: price,
+        }},
+        PurchaseTotal: price,
+    }
+
+    var buf bytes.Buffer
+    if err := WriteInsuranceCSV(&buf, report); err != nil {
+        t.Fatal(err)
+    }
+    rows, err := csv.NewReader(&buf).ReadAll()
+    if err != nil {
+        t.Fatal(err)
+    }
+    want := []string{"'@Garage", "'=1+1", "1", "'=HYPERLINK(\"http://example.com\")", "'+X100", "'-42", "",
+        "1234.56", "", "", "1234.56", "0.00"}
+    if !slices.Equal(rows[1], want) {
+        t.Errorf("asset row = %q, want %q", rows[1], want)
+    }
+    if rows[2][0] != "'@Garage" || rows[2][1] != "Room total" {
+        t.Errorf("room total row = %q", rows[2])
+    }
+}
@@ -0,0 +1,605 @@
+package inventory
+
+import (
+    "bufio"
+    "compress/gzip"
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "encoding/base64"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "time"
+)
+
+// BackupFormat identifies snapshot files written by Backup.
+const BackupFormat = "myhomeinventory-backup"
+
+// backupFilePrefix and backupFileSuffix name the files written by BackupToDir.
+const (
+    backupFilePrefix = "inventory-backup-"
+    backupFileSuffix = ".jsonl.gz"
+)
+
+// restoreBatchSize is the number of rows written per INSERT statement during a restore.
+const restoreBatchSize = 200
+
+// catalogTable is the product catalog, which can be reloaded from its dump and is
+// only included in backups on request.
+const catalogTable = "product_catalog"
+
+// BackupHeader is the first line of a snapshot and describes its contents.
+type BackupHeader struct {
+    Format        string        `json:"format"`
+    SchemaVersion int           `json:"schemaVersion"`
+    CreatedAt     time.Time     `json:"createdAt"`
+    Tables        []BackupTable `json:"tables"`
+}
+
+// BackupTable describes one table in a snapshot.
+type BackupTable struct {
+    Name    string         `json:"name"`
+    Columns []BackupColumn `json:"columns"`
+    Rows    int            `json:"rows"`
+}
+
+// BackupColumn is a column name and its MySQL type.
+type BackupColumn struct {
+    Name string `json:"name"`
+    Type string `json:"type"`
+}
+
+// backupRow is one row line of a snapshot.
+type backupRow struct {
+    Table  string            `json:"t"`
+    Values []json.RawMessage `json:"v"`
+}
+
+// BackupOptions controls what Backup writes.
+type BackupOptions struct {
+    // IncludeCatalog adds the product_catalog table, which can be very large.
+    IncludeCatalog bool
+}
+
+// RestoreOptions controls how Restore loads a snapshot.
+type RestoreOptions struct {
+    // Replace deletes existing rows first. Without it the target tables must be empty.
+    Replace bool
+}
+
+// Backup writes a gzip-compressed snapshot of every inventory table to w. All tables are
+// read inside one read-only repeatable-read transaction so the snapshot is consistent.
+// The file holds a JSON header line followed by one JSON line per row.
+func Backup(ctx context.Context, db *Database, w io.Writer, opts BackupOptions) (BackupHeader, error) {
+    defer observe("Backup", time.Now())
+    header := BackupHeader{
+        Format:        BackupFormat,
+        SchemaVersion: SchemaVersion,
+        CreatedAt:     time.Now().UTC(),
+    }
+
+    tx, err := db.conn.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+    if err != nil {
+        return header, err
+    }
+    defer tx.Rollback()
+
+    // Count rows first so the header can be verified on restore. The transaction's
+    // snapshot guarantees the counts match the rows read afterwards.
+    for _, name := range backupTableNames(opts) {
+        columns, err := tableColumns(ctx, tx, name)
+        if err != nil {
+            return header, err
+        }
+        var count int
+        if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM " + name).Scan(&count); err != nil {
+            return header, err
+        }
+        header.Tables = append(header.Tables, BackupTable{Name: name, Columns: columns, Rows: count})
+    }
+
+    gz := gzip.NewWriter(w)
+    enc := json.NewEncoder(gz)
+    if err := enc.Encode(header); err != nil {
+        return header, err
+    }
+
+    for _, table := range header.Tables {
+        if err := backupTableRows(ctx, tx, enc, table); err != nil {
+            return header, fmt.Errorf("failed to back up table '%s': %w", table.Name, err)
+        }
+    }
+
+    return header, gz.Close()
+}
+
+// backupTableNames returns the tables included in a backup in creation order.
+func backupTableNames(opts BackupOptions) []string {
+    names := []string{}
+    for _, name := range TableNames() {
+        if name == catalogTable && !opts.IncludeCatalog {
+            continue
+        }
+        names = append(names, name)
+    }
+    return names
+}
+
+// tableColumns returns a table's columns in ordinal order.
+func tableColumns(ctx context.Context, q interface {
+    QueryContext(context.Context, string, ...interface{}) (*sql.Rows, error)
+}, table string) ([]BackupColumn, error) {
+    rows, err := q.QueryContext(ctx, `
+        SELECT column_name, data_type
+        FROM information_schema.columns
+        WHERE table_schema = DATABASE() AND table_name = ?
+        ORDER BY ordinal_position
+    `, table)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    columns := []BackupColumn{}
+    for rows.Next() {
+        var c BackupColumn
+        if err := rows.Scan(&c.Name, &c.Type); err != nil {
+            return nil, err
+        }
+        c.Type = strings.ToUpper(c.Type)
+        columns = append(columns, c)
+    }
+    return columns, rows.Err()
+}
+
+// backupTableRows writes every row of a table as JSON lines.
+func backupTableRows(ctx context.Context, tx *sql.Tx, enc *json.Encoder, table BackupTable) error {
+    names := make([]string, len(table.Columns))
+    for i, c := range table.Columns {
+        names[i] = c.Name
+    }
+    rows, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT %s FROM %s ORDER BY 1", strings.Join(names, ", "), table.Name))
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        dest := make([]interface{}, len(table.Columns))
+        for i, c := range table.Columns {
+            dest[i] = scanTarget(c.Type)
+        }
+        if err := rows.Scan(dest...); err != nil {
+            return err
+        }
+
+        row := backupRow{Table: table.Name, Values: make([]json.RawMessage, len(dest))}
+        for i, d := range dest {
+            v, err := encodeBackupValue(d)
+            if err != nil {
+                return err
+            }
+            row.Values[i] = v
+        }
+        if err := enc.Encode(row); err != nil {
+            return err
+        }
+    }
+    return rows.Err()
+}
+
+// columnKind groups MySQL column types by how they are stored in a snapshot.
+func columnKind(mysqlType string) string {
+    switch mysqlType {
+    case "TINYINT", "SMALLINT", "MEDIUMINT", "INT", "INTEGER", "BIGINT", "YEAR":
+        return "int"
+    case "DATETIME", "TIMESTAMP", "DATE":
+        return "time"
+    case "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY":
+        return "bytes"
+    }
+    return "string"
+}
+
+// scanTarget returns a nullable scan destination for a column type.
+func scanTarget(mysqlType string) interface{} {
+    switch columnKind(mysqlType) {
+    case "int":
+        return &sql.NullInt64{}
+    case "time":
+        return &sql.NullTime{}
+    case "bytes":
+        return &[]byte{}
+    }
+    return &sql.NullString{}
+}
+
+// encodeBackupValue converts a scanned value to its JSON form. Times are RFC 3339 strings
+// and binary values are base64 strings.
+func encodeBackupValue(v interface{}) (json.RawMessage, error) {
+    var out interface{}
+    switch v := v.(type) {
+    case *sql.NullInt64:
+        if v.Valid {
+            out = v.Int64
+        }
+    case *sql.NullTime:
+        if v.Valid {
+            out = v.Time.UTC().Format(time.RFC3339Nano)
+        }
+    case *sql.NullString:
+        if v.Valid {
+            out = v.String
+        }
+    case *[]byte:
+        if *v != nil {
+            out = base64.StdEncoding.EncodeToString(*v)
+        }
+    }
+    return json.Marshal(out)
+}
+
+// decodeBackupValue converts a JSON value back to a value for an INSERT statement.
+func decodeBackupValue(raw json.RawMessage, mysqlType string) (interface{}, error) {
+    if string(raw) == "null" {
+        return nil, nil
+    }
+    switch columnKind(mysqlType) {
+    case "int":
+        var n int64
+        err := json.Unmarshal(raw, &n)
+        return n, err
+    case "time":
+        var s string
+        if err := json.Unmarshal(raw, &s); err != nil {
+            return nil, err
+        }
+        return time.Parse(time.RFC3339Nano, s)
+    case "bytes":
+        var s string
+        if err := json.Unmarshal(raw, &s); err != nil {
+            return nil, err
+        }
+        return base64.StdEncoding.DecodeString(s)
+    }
+    var s string
+    err := json.Unmarshal(raw, &s)
+    return s, err
+}
+
+// ReadBackupHeader reads and validates the header of a snapshot without loading it.
+func ReadBackupHeader(r io.Reader) (BackupHeader, error) {
+    gz, err := gzip.NewReader(r)
+    if err != nil {
+        return BackupHeader{}, fmt.Errorf("not a backup file: %w", err)
+    }
+    defer gz.Close()
+    header, _, err := readBackupHeader(bufio.NewReader(gz))
+    return header, err
+}
+
+// readBackupHeader decodes the header line and checks it can be restored by this build.
+func readBackupHeader(r io.Reader) (BackupHeader, *json.Decoder, error) {
+    dec := json.NewDecoder(r)
+    var header BackupHeader
+    if err := dec.Decode(&header); err != nil {
+        return header, nil, fmt.Errorf("invalid backup header: %w", err)
+    }
+    if header.Format != BackupFormat {
+        return header, nil, fmt.Errorf("not a backup file: format is %q", header.Format)
+    }
+    if header.SchemaVersion > SchemaVersion {
+        return header, nil, fmt.Errorf("backup schema version %d is newer than this build's version %d; upgrade before restoring",
+            header.SchemaVersion, SchemaVersion)
+    }
+    if header.SchemaVersion < 1 {
+        return header, nil, fmt.Errorf("backup has invalid schema version %d", header.SchemaVersion)
+    }
+    return header, dec, nil
+}
+
+// Restore loads a snapshot written by Backup. Missing tables are created first, so a fresh
+// empty database can be restored into. The snapshot's schema version and each table's
+// columns are verified before any row is written, and all rows are loaded in a single
+// transaction that is rolled back on any error.
+func Restore(ctx context.Context, db *Database, r io.Reader, opts RestoreOptions) (BackupHeader, error) {
+    defer observe("Restore", time.Now())
+    gz, err := gzip.NewReader(r)
+    if err != nil {
+        return BackupHeader{}, fmt.Errorf("not a backup file: %w", err)
+    }
+    defer gz.Close()
+
+    header, dec, err := readBackupHeader(bufio.NewReader(gz))
+    if err != nil {
+        return header, err
+    }
+
+    if err := db.CreateMissingTables(ctx); err != nil {
+        return header, err
+    }
+
+    known := map[string]bool{}
+    for _, name := range TableNames() {
+        known[name] = true
+    }
+    tables := map[string]BackupTable{}
+    for _, table := range header.Tables {
+        if !known[table.Name] {
+            return header, fmt.Errorf("backup contains unknown table '%s'", table.Name)
+        }
+        current, err := tableColumns(ctx, db.conn, table.Name)
+        if err != nil {
+            return header, err
+        }
+        if !sameColumns(current, table.Columns) {
+            return header, fmt.Errorf("table '%s' in the backup does not match the database structure", table.Name)
+        }
+        tables[table.Name] = table
+    }
+
+    conn, err := db.conn.Conn(ctx)
+    if err != nil {
+        return header, err
+    }
+    defer conn.Close()
+
+    // Rows are inserted table by table in creation order, but self-references and
+    // ordering within a table are easiest to handle with checks disabled for this session.
+    if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+        return header, err
+    }
+    defer restoreForeignKeyChecks(ctx, conn)
+
+    tx, err := conn.BeginTx(ctx, nil)
+    if err != nil {
+        return header, err
+    }
+    defer tx.Rollback()
+
+    for _, table := range header.Tables {
+        var count int
+        if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM " + table.Name).Scan(&count); err != nil {
+            return header, err
+        }
+        if count == 0 || table.Name == "schema_info" {
+            continue
+        }
+        if !opts.Replace {
+            return header, fmt.Errorf("table '%s' is not empty; restore into an empty database or replace existing data", table.Name)
+        }
+    }
+    if opts.Replace {
+        names := TableNames()
+        for i := len(names) - 1; i >= 0; i-- {
+            // Tables newer than the backup are cleared too so no rows point at replaced items.
+            if _, inBackup := tables[names[i]]; !inBackup && names[i] == catalogTable {
+                continue
+            }
+            if _, err := tx.ExecContext(ctx, "DELETE FROM " + names[i]); err != nil {
+                return header, err
+            }
+        }
+    } else if _, err := tx.ExecContext(ctx, "DELETE FROM schema_info"); err != nil {
+        return header, err
+    }
+
+    loaded := map[string]int{}
+    pending := map[string][][]interface{}{}
+    flush := func(name string) error {
+        if len(pending[name]) == 0 {
+            return nil
+        }
+        if err := insertBackupRows(ctx, tx, tables[name], pending[name]); err != nil {
+            return fmt.Errorf("failed to restore table '%s': %w", name, err)
+        }
+        loaded[name] += len(pending[name])
+        pending[name] = pending[name][:0]
+        return nil
+    }
+
+    for {
+        var row backupRow
+        if err := dec.Decode(&row); err == io.EOF {
+            break
+        } else if err != nil {
+            return header, fmt.Errorf("corrupt backup: %w", err)
+        }
+
+        table, ok := tables[row.Table]
+        if !ok {
+            return header, fmt.Errorf("corrupt backup: row for unexpected table '%s'", row.Table)
+        }
+        if len(row.Values) != len(table.Columns) {
+            return header, fmt.Errorf("corrupt backup: row in '%s' has %d values, expected %d", row.Table, len(row.Values), len(table.Columns))
+        }
+
+        values := make([]interface{}, len(row.Values))
+        for i, raw := range row.Values {
+            v, err := decodeBackupValue(raw, table.Columns[i].Type)
+            if err != nil {
+                return header, fmt.Errorf("corrupt backup: table '%s' column '%s': %w", row.Table, table.Columns[i].Name, err)
+            }
+            values[i] = v
+        }
+
+        pending[row.Table] = append(pending[row.Table], values)
+        if len(pending[row.Table]) == restoreBatchSize {
+            if err := flush(row.Table); err != nil {
+                return header, err
+            }
+        }
+    }
+
+    for _, table := range header.Tables {
+        if err := flush(table.Name); err != nil {
+            return header, err
+        }
+        if loaded[table.Name] != table.Rows {
+            return header, fmt.Errorf("backup is incomplete: table '%s' has %d rows, expected %d", table.Name, loaded[table.Name], table.Rows)
+        }
+    }
+
+    // Snapshots from before the search index existed carry no item_search rows.
+    if err := rebuildItemSearch(ctx, tx); err != nil {
+        return header, err
+    }
+
+    // The restored data now matches this build's layout, whatever version it was taken at.
+    if _, err := tx.ExecContext(ctx, `
+        INSERT INTO schema_info (id, schema_version) VALUES (1, ?)
+        ON DUPLICATE KEY UPDATE schema_version = VALUES(schema_version)
+    `, SchemaVersion); err != nil {
+        return header, err
+    }
+
+    return header, tx.Commit()
+}
+
+// sameColumns reports whether two column lists have the same names and types in the same order.
+func sameColumns(a, b []BackupColumn) bool {
+    if len(a) != len(b) {
+        return false
+    }
+    for i := range a {
+        if a[i].Name != b[i].Name || a[i].Type != b[i].Type {
+            return false
+        }
+    }
+    return true
+}
+
+// insertBackupRows writes rows with a single multi-row INSERT.
+func insertBackupRows(ctx context.Context, tx *sql.Tx, table BackupTable, rows [][]interface{}) error {
+    names := make([]string, len(table.Columns))
+    for i, c := range table.Columns {
+        names[i] = c.Name
+    }
+    placeholder := "(" + strings.TrimSuffix(strings.Repeat("?, ", len(names)), ", ") + ")"
+
+    placeholders := make([]string, len(rows))
+    args := make([]interface{}, 0, len(rows)*len(names))
+    for i, row := range rows {
+        placeholders[i] = placeholder
+        args = append(args, row...)
+    }
+
+    query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table.Name, strings.Join(names, ", "), strings.Join(placeholders, ", "))
+    _, err := tx.ExecContext(ctx, query, args...)
+    return err
+}
+
+// BackupToDir writes a timestamped snapshot into dir and returns its path. The file is
+// written under a temporary name and renamed once complete.
+func BackupToDir(ctx context.Context, db *Database, dir string, opts BackupOptions) (string, error) {
+    defer observe("BackupToDir", time.Now())
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        return "", err
+    }
+
+    name := backupFilePrefix + time.Now().UTC().Format("20060102-150405") + backupFileSuffix
+    path := filepath.Join(dir, name)
+    tmp, err := os.CreateTemp(dir, ".tmp-"+name)
+    if err != nil {
+        return "", err
+    }
+    defer os.Remove(tmp.Name())
+
+    if _, err := Backup(ctx, db, tmp, opts); err != nil {
+        tmp.Close()
+        return "", err
+    }
+    if err := tmp.Close(); err != nil {
+        return "", err
+    }
+    return path, os.Rename(tmp.Name(), path)
+}
+
+// PruneBackups deletes the oldest snapshots in dir so at most keep remain, returning the removed paths.
+func PruneBackups(dir string, keep int) ([]string, error) {
+    entries, err := os.ReadDir(dir)
+    if err != nil {
+        return nil, err
+    }
+
+    names := []string{}
+    for _, e := range entries {
+        if !e.IsDir() && strings.HasPrefix(e.Name(), backupFilePrefix) && strings.HasSuffix(e.Name(), backupFileSuffix) {
+            names = append(names, e.Name())
+        }
+    }
+    // Timestamped names sort chronologically.
+    sort.Strings(names)
+
+    removed := []string{}
+    for len(names) > keep {
+        path := filepath.Join(dir, names[0])
+        if err := os.Remove(path); err != nil {
+            return removed, err
+        }
+        removed = append(removed, path)
+        names = names[1:]
+    }
+    return removed, nil
+}
+
+// BackupSchedule configures RunScheduledBackups.
+type BackupSchedule struct {
+    Dir      string
+    Interval time.Duration
+    Keep     int
+    Options  BackupOptions
+}
+
+// RunScheduledBackups takes a snapshot immediately and then every Interval until ctx is
+// cancelled, keeping the newest Keep snapshots. Failures are logged and retried at the
+// next interval rather than stopping the schedule.
+func RunScheduledBackups(ctx context.Context, db *Database, schedule BackupSchedule) error {
+    if schedule.Interval <= 0 {
+        return fmt.Errorf("backup interval must be positive")
+    }
+
+    ticker := time.NewTicker(schedule.Interval)
+    defer ticker.Stop()
+
+    for {
+        path, err := BackupToDir(ctx, db, schedule.Dir, schedule.Options)
+        if err != nil {
+            slog.ErrorContext(ctx, "scheduled backup failed", "error", err)
+        } else {
+            slog.InfoContext(ctx, "backup written", "path", path)
+            if schedule.Keep > 0 {
+                removed, err := PruneBackups(schedule.Dir, schedule.Keep)
+                if err != nil {
+                    slog.ErrorContext(ctx, "failed to prune old backups", "error", err)
+                }
+                for _, old := range removed {
+                    slog.InfoContext(ctx, "removed old backup", "path", old)
+                }
+            }
+        }
+
+        select {
+        case <-ctx.Done():
+            return nil
+        case <-ticker.C:
+        }
+    }
+}
+
+// restoreForeignKeyChecks turns foreign key checks back on for a connection Restore
+// borrowed from the pool. It runs even when ctx was cancelled mid-restore; if it still
+// fails, the connection is discarded so no later query runs with checks disabled.
+func restoreForeignKeyChecks(ctx context.Context, conn *sql.Conn) {
+    ctx = context.WithoutCancel(ctx)
+    if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1"); err != nil {
+        slog.ErrorContext(ctx, "failed to re-enable foreign key checks; discarding connection", "error", err)
+        conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+    }
+}
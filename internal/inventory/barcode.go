@@ -0,0 +1,63 @@
+package inventory
+
+import (
+    "fmt"
+    "strings"
+)
+
+// NormalizeBarcode cleans up a scanned or typed UPC/EAN code and validates its check digit.
+// UPC-A (12 digits) and zero-padded GTIN-14 codes are returned in their EAN-13 form so the
+// same product always maps to the same stored value. EAN-8 codes are returned unchanged.
+func NormalizeBarcode(code string) (string, error) {
+    cleaned := strings.Map(func(r rune) rune {
+        if r == ' ' || r == '-' {
+            return -1
+        }
+        return r
+    }, strings.TrimSpace(code))
+
+    if cleaned == "" {
+        return "", fmt.Errorf("barcode is required")
+    }
+
+    for _, r := range cleaned {
+        if r < '0' || r > '9' {
+            return "", fmt.Errorf("invalid barcode %q: must contain only digits", code)
+        }
+    }
+
+    switch len(cleaned) {
+    case 8, 13:
+    case 12:
+        cleaned = "0" + cleaned
+    case 14:
+        if cleaned[0] != '0' {
+            return "", fmt.Errorf("invalid barcode %q: GTIN-14 codes are not supported", code)
+        }
+        cleaned = cleaned[1:]
+    default:
+        return "", fmt.Errorf("invalid barcode %q: must be 8, 12, 13 or 14 digits", code)
+    }
+
+    if !validCheckDigit(cleaned) {
+        return "", fmt.Errorf("invalid barcode %q: check digit mismatch", code)
+    }
+
+    return cleaned, nil
+}
+
+// validCheckDigit reports whether the last digit of a GTIN matches the checksum of the others.
+func validCheckDigit(code string) bool {
+    sum := 0
+    weight := 3
+    for i := len(code) - 2; i >= 0; i-- {
+        sum += int(code[i]-'0') * weight
+        if weight == 3 {
+            weight = 1
+        } else {
+            weight = 3
+        }
+    }
+    check := (10 - sum%10) % 10
+    return int(code[len(code)-1]-'0') == check
+}
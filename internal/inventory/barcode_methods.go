@@ -0,0 +1,168 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "time"
+)
+
+// AddItemBarcode attaches a barcode to an existing inventory item and returns the normalized code.
+// The barcode is removed from the unknown scan queue once it is assigned.
+func AddItemBarcode(ctx context.Context, db *Database, itemID int64, code string) (string, error) {
+    defer observe("AddItemBarcode", time.Now())
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return "", err
+    }
+
+    var ownerID int64
+    err = db.conn.QueryRowContext(ctx, `SELECT item_id FROM item_barcode WHERE barcode = ?`, barcode).Scan(&ownerID)
+    switch {
+    case err == nil && ownerID == itemID:
+        return barcode, nil
+    case err == nil:
+        return "", fmt.Errorf("barcode %s is already assigned to item %d", barcode, ownerID)
+    case !errors.Is(err, sql.ErrNoRows):
+        return "", err
+    }
+
+    if _, err := db.conn.ExecContext(ctx, `INSERT INTO item_barcode (item_id, barcode) VALUES (?, ?)`, itemID, barcode); err != nil {
+        return "", err
+    }
+    if err := RemoveQueuedScan(ctx, db, barcode); err != nil {
+        return "", err
+    }
+    if err := refreshItemSearch(ctx, db.conn, itemID); err != nil {
+        return "", err
+    }
+    return barcode, nil
+}
+
+// RemoveItemBarcode detaches a barcode from whichever item it belongs to.
+func RemoveItemBarcode(ctx context.Context, db *Database, code string) error {
+    defer observe("RemoveItemBarcode", time.Now())
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return err
+    }
+
+    var itemID int64
+    err = db.conn.QueryRowContext(ctx, `SELECT item_id FROM item_barcode WHERE barcode = ?`, barcode).Scan(&itemID)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("barcode %s is not assigned to any item", barcode)
+    }
+    if err != nil {
+        return err
+    }
+
+    if _, err := db.conn.ExecContext(ctx, `DELETE FROM item_barcode WHERE barcode = ?`, barcode); err != nil {
+        return err
+    }
+    return refreshItemSearch(ctx, db.conn, itemID)
+}
+
+// GetItemBarcodes retrieves the barcodes assigned to the given items, or to every item when
+// itemIDs is nil, grouped by item ID.
+func GetItemBarcodes(ctx context.Context, db *Database, itemIDs []int) (map[int][]string, error) {
+    defer observe("GetItemBarcodes", time.Now())
+    where, args := itemIDCondition("item_id", itemIDs)
+    rows, err := db.conn.QueryContext(ctx, `SELECT item_id, barcode FROM item_barcode`+where+` ORDER BY item_id ASC, id ASC`, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    barcodes := map[int][]string{}
+    for rows.Next() {
+        var itemID int
+        var barcode string
+        if err := rows.Scan(&itemID, &barcode); err != nil {
+            return nil, err
+        }
+        barcodes[itemID] = append(barcodes[itemID], barcode)
+    }
+    return barcodes, rows.Err()
+}
+
+// FindItemByBarcode returns the ID and name of the item a barcode is assigned to.
+// It returns sql.ErrNoRows when the barcode is not assigned.
+func FindItemByBarcode(ctx context.Context, db *Database, code string) (int, string, error) {
+    defer observe("FindItemByBarcode", time.Now())
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return 0, "", err
+    }
+
+    var id int
+    var name string
+    err = db.conn.QueryRowContext(ctx, `
+        SELECT i.id, i.item_name
+        FROM item_barcode b
+        JOIN inventory_item i ON b.item_id = i.id
+        WHERE b.barcode = ?
+    `, barcode).Scan(&id, &name)
+    if err != nil {
+        return 0, "", err
+    }
+    return id, name, nil
+}
+
+// GetCatalogProduct retrieves a product from the local catalog by barcode.
+// It returns sql.ErrNoRows when the catalog has no entry for the code.
+func GetCatalogProduct(ctx context.Context, db *Database, code string) (*CatalogProduct, error) {
+    defer observe("GetCatalogProduct", time.Now())
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return nil, err
+    }
+
+    var p CatalogProduct
+    var brand, category, quantityLabel sql.NullString
+    err = db.conn.QueryRowContext(ctx, `
+        SELECT id, barcode, product_name, brand, category, quantity_label
+        FROM product_catalog
+        WHERE barcode = ?
+    `, barcode).Scan(&p.ID, &p.Barcode, &p.ProductName, &brand, &category, &quantityLabel)
+    if err != nil {
+        return nil, err
+    }
+    p.Brand = brand.String
+    p.Category = category.String
+    p.QuantityLabel = quantityLabel.String
+    return &p, nil
+}
+
+// LookupBarcode resolves a scanned barcode. Codes assigned to an existing item
+// increment that item's quantity; codes found in the product catalog are returned
+// so the add form can be pre-filled; anything else is reported as unknown.
+func LookupBarcode(ctx context.Context, db *Database, code string) (BarcodeLookupResult, error) {
+    defer observe("LookupBarcode", time.Now())
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return BarcodeLookupResult{}, err
+    }
+
+    itemID, _, err := FindItemByBarcode(ctx, db, barcode)
+    if err == nil {
+        item, err := AdjustItemQty(ctx, db, itemID, "+")
+        if err != nil {
+            return BarcodeLookupResult{}, err
+        }
+        return BarcodeLookupResult{Status: "item", Barcode: barcode, Item: item}, nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return BarcodeLookupResult{}, err
+    }
+
+    product, err := GetCatalogProduct(ctx, db, barcode)
+    if err == nil {
+        return BarcodeLookupResult{Status: "catalog", Barcode: barcode, Product: product}, nil
+    }
+    if !errors.Is(err, sql.ErrNoRows) {
+        return BarcodeLookupResult{}, err
+    }
+
+    return BarcodeLookupResult{Status: "unknown", Barcode: barcode}, nil
+}
@@ -0,0 +1,183 @@
+package inventory
+
+import (
+    "bufio"
+    "compress/gzip"
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+    "time"
+)
+
+// catalogBatchSize is the number of products written per INSERT statement while loading a dump.
+const catalogBatchSize = 500
+
+// CatalogLoadStats summarizes a product catalog load.
+type CatalogLoadStats struct {
+    Read    int `json:"read"`
+    Loaded  int `json:"loaded"`
+    Skipped int `json:"skipped"`
+}
+
+// offProduct holds the Open Food Facts fields the catalog keeps.
+type offProduct struct {
+    Code          string `json:"code"`
+    ProductName   string `json:"product_name"`
+    ProductNameEn string `json:"product_name_en"`
+    GenericName   string `json:"generic_name"`
+    Brands        string `json:"brands"`
+    Categories    string `json:"categories"`
+    Quantity      string `json:"quantity"`
+}
+
+// LoadProductCatalog bulk-loads the product_catalog table from an Open Food Facts dump.
+// Both the tab-separated "CSV" export and the JSONL export are accepted, optionally gzip
+// compressed. Existing catalog rows with the same barcode are updated in place.
+func LoadProductCatalog(ctx context.Context, db *Database, r io.Reader) (CatalogLoadStats, error) {
+    defer observe("LoadProductCatalog", time.Now())
+    stats := CatalogLoadStats{}
+
+    br := bufio.NewReader(r)
+    if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+        gz, err := gzip.NewReader(br)
+        if err != nil {
+            return stats, fmt.Errorf("failed to open gzip stream: %w", err)
+        }
+        defer gz.Close()
+        br = bufio.NewReader(gz)
+    }
+
+    scanner := bufio.NewScanner(br)
+    scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024)
+
+    var header map[string]int
+    batch := make([]CatalogProduct, 0, catalogBatchSize)
+
+    flush := func() error {
+        if len(batch) == 0 {
+            return nil
+        }
+        if err := upsertCatalogProducts(ctx, db, batch); err != nil {
+            return err
+        }
+        stats.Loaded += len(batch)
+        batch = batch[:0]
+        return nil
+    }
+
+    for scanner.Scan() {
+        line := scanner.Text()
+        if strings.TrimSpace(line) == "" {
+            continue
+        }
+
+        var raw offProduct
+        switch {
+        case strings.HasPrefix(line, "{"):
+            if err := json.Unmarshal([]byte(line), &raw); err != nil {
+                stats.Read++
+                stats.Skipped++
+                continue
+            }
+        case header == nil:
+            header = map[string]int{}
+            for i, name := range strings.Split(line, "\t") {
+                header[name] = i
+            }
+            if _, ok := header["code"]; !ok {
+                return stats, fmt.Errorf("unrecognized catalog dump: header has no 'code' column")
+            }
+            continue
+        default:
+            raw = parseOFFRow(header, strings.Split(line, "\t"))
+        }
+
+        stats.Read++
+        product, ok := catalogProductFromOFF(raw)
+        if !ok {
+            stats.Skipped++
+            continue
+        }
+
+        batch = append(batch, product)
+        if len(batch) == catalogBatchSize {
+            if err := flush(); err != nil {
+                return stats, err
+            }
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return stats, err
+    }
+    if err := flush(); err != nil {
+        return stats, err
+    }
+
+    return stats, nil
+}
+
+// parseOFFRow maps a tab-separated Open Food Facts row onto the fields the catalog uses.
+func parseOFFRow(header map[string]int, fields []string) offProduct {
+    get := func(name string) string {
+        if i, ok := header[name]; ok && i < len(fields) {
+            return fields[i]
+        }
+        return ""
+    }
+    return offProduct{
+        Code:          get("code"),
+        ProductName:   get("product_name"),
+        ProductNameEn: get("product_name_en"),
+        GenericName:   get("generic_name"),
+        Brands:        get("brands"),
+        Categories:    get("categories"),
+        Quantity:      get("quantity"),
+    }
+}
+
+// catalogProductFromOFF validates a raw dump entry and converts it to a CatalogProduct.
+func catalogProductFromOFF(raw offProduct) (CatalogProduct, bool) {
+    barcode, err := NormalizeBarcode(raw.Code)
+    if err != nil {
+        return CatalogProduct{}, false
+    }
+
+    name := firstNonEmpty(raw.ProductName, raw.ProductNameEn, raw.GenericName)
+    if name == "" {
+        return CatalogProduct{}, false
+    }
+
+    brand, _, _ := strings.Cut(raw.Brands, ",")
+
+    return CatalogProduct{
+        Barcode:       barcode,
+        ProductName:   truncateRunes(name, 255),
+        Brand:         truncateRunes(strings.TrimSpace(brand), 255),
+        Category:      truncateRunes(strings.TrimSpace(raw.Categories), 255),
+        QuantityLabel: truncateRunes(strings.TrimSpace(raw.Quantity), 64),
+    }, true
+}
+
+// upsertCatalogProducts writes a batch of products with a single multi-row INSERT.
+func upsertCatalogProducts(ctx context.Context, db *Database, products []CatalogProduct) error {
+    placeholders := make([]string, 0, len(products))
+    args := make([]interface{}, 0, len(products)*5)
+    for _, p := range products {
+        placeholders = append(placeholders, "(?, ?, ?, ?, ?)")
+        args = append(args, p.Barcode, p.ProductName, p.Brand, p.Category, p.QuantityLabel)
+    }
+
+    query := `
+        INSERT INTO product_catalog (barcode, product_name, brand, category, quantity_label)
+        VALUES ` + strings.Join(placeholders, ", ") + `
+        ON DUPLICATE KEY UPDATE
+            product_name = VALUES(product_name),
+            brand = VALUES(brand),
+            category = VALUES(category),
+            quantity_label = VALUES(quantity_label)
+    `
+    _, err := db.conn.ExecContext(ctx, query, args...)
+    return err
+}
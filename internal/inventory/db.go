@@ -1,55 +1,172 @@
 ﻿package inventory
 
 import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
     "database/sql"
     "fmt"
+    "log/slog"
+    "net"
+    "os"
+    "strconv"
+    "time"
 
-    _ "github.com/go-sql-driver/mysql"
+    "github.com/go-sql-driver/mysql"
+
+    "myhomeinventory/internal/config"
+    "myhomeinventory/internal/metrics"
 )
 
 // Database wraps the sql.DB connection.
 type Database struct {
-    conn *sql.DB
+    conn   *sql.DB
+    cfg    config.Database
+    health healthState
+}
+
+// NewDatabase creates a new instance of Database for the given connection settings.
+func NewDatabase(cfg config.Database) *Database {
+    return &Database{cfg: cfg}
 }
 
-// NewDatabase creates a new instance of Database.
-func NewDatabase() *Database {
-    return &Database{}
+// Name returns the name of the database schema.
+func (d *Database) Name() string {
+    return d.cfg.Name
 }
 
-// Boot initializes the database connection using environment variables.
-func (d *Database) Boot() {
-    if d.IsRunning() {
-        fmt.Println("Database is already running.")
-        return
+// Boot opens the database connection using the configured settings. When the server
+// is not reachable yet, as when MySQL and the application start together, it retries
+// with exponential backoff for up to BootTimeout. Errors that retrying cannot fix,
+// such as bad credentials or an unknown database, are returned at once.
+func (d *Database) Boot(ctx context.Context) error {
+    if d.conn != nil {
+        slog.WarnContext(ctx, "database is already running")
+        return nil
+    }
+
+    driverCfg, err := d.driverConfig()
+    if err != nil {
+        return fmt.Errorf("configuring database: %w", err)
+    }
+    connector, err := mysql.NewConnector(driverCfg)
+    if err != nil {
+        return fmt.Errorf("opening database: %w", err)
+    }
+    conn := sql.OpenDB(connector)
+    conn.SetMaxOpenConns(d.cfg.MaxOpenConns)
+    conn.SetMaxIdleConns(d.cfg.MaxIdleConns)
+    conn.SetConnMaxLifetime(d.cfg.ConnMaxLifetime)
+    conn.SetConnMaxIdleTime(d.cfg.ConnMaxIdleTime)
+
+    deadline := time.Now().Add(d.cfg.BootTimeout)
+    backoff := bootInitialBackoff
+    for attempt := 1; ; attempt++ {
+        err = pingWithTimeout(ctx, conn, d.cfg.ConnectTimeout)
+        if err == nil {
+            break
+        }
+        wait := jitter(backoff)
+        if !retryable(err) || time.Now().Add(wait).After(deadline) {
+            conn.Close()
+            return fmt.Errorf("connecting to database after %d attempt(s): %w", attempt, err)
+        }
+        slog.WarnContext(ctx, "database not reachable; retrying", "attempt", attempt, "error", err, "wait", wait.Round(time.Millisecond))
+        select {
+        case <-ctx.Done():
+            conn.Close()
+            return ctx.Err()
+        case <-time.After(wait):
+        }
+        backoff = min(backoff*2, bootMaxBackoff)
     }
 
-    dbUser := GetEnv("DB_USER")
-    dbPassword := GetEnv("DB_PASSWORD")
-    dbHost := GetEnv("DB_HOST")
-    dbPort := GetEnv("DB_PORT")
-    dbName := GetEnv("DB_NAME")
+    var currentSchema sql.NullString
+    if err := conn.QueryRowContext(ctx, "SELECT DATABASE()").Scan(&currentSchema); err != nil {
+        conn.Close()
+        return fmt.Errorf("getting current database: %w", err)
+    }
 
-    dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true", dbUser, dbPassword, dbHost, dbPort, dbName)
+    d.conn = conn
+    d.markHealth(nil)
+    slog.InfoContext(ctx, "database connected", "schema", currentSchema.String)
+    return nil
+}
 
-    var err error
-    d.conn, err = sql.Open("mysql", dsn)
-    if err != nil {
-        panic(fmt.Sprintf("Error opening database: %v", err))
+// driverConfig builds the MySQL driver configuration from the settings, starting
+// from DB_DSN when one is given. Building it field by field keeps passwords with
+// '@' or ':' intact, which a hand-formatted DSN string cannot.
+func (d *Database) driverConfig() (*mysql.Config, error) {
+    var cfg *mysql.Config
+    if d.cfg.DSN != "" {
+        parsed, err := mysql.ParseDSN(d.cfg.DSN)
+        if err != nil {
+            return nil, fmt.Errorf("invalid DSN: %w", err)
+        }
+        cfg = parsed
+    } else {
+        cfg = mysql.NewConfig()
+        cfg.User = d.cfg.User
+        cfg.Passwd = d.cfg.Password
+        cfg.DBName = d.cfg.Name
+        cfg.Collation = firstNonEmpty(d.cfg.Collation, cfg.Collation)
+        cfg.Timeout = d.cfg.ConnectTimeout
+        cfg.ReadTimeout = d.cfg.ReadTimeout
+        cfg.WriteTimeout = d.cfg.WriteTimeout
+        if d.cfg.Socket != "" {
+            cfg.Net = "unix"
+            cfg.Addr = d.cfg.Socket
+        } else {
+            cfg.Net = "tcp"
+            cfg.Addr = net.JoinHostPort(d.cfg.Host, strconv.Itoa(d.cfg.Port))
+        }
     }
 
-    if err := d.conn.Ping(); err != nil {
-        panic(fmt.Sprintf("Error pinging database: %v", err))
+    // Queries scan DATETIME columns straight into time.Time.
+    cfg.ParseTime = true
+
+    if err := applyTLS(cfg, d.cfg); err != nil {
+        return nil, err
+    }
+    if cfg.DBName != "" {
+        d.cfg.Name = cfg.DBName
     }
+    return cfg, nil
+}
 
-    var currentSchema string
-    err = d.conn.QueryRow("SELECT DATABASE()").Scan(&currentSchema)
-    if err != nil {
-        panic(fmt.Sprintf("Error getting current database: %v", err))
+// applyTLS sets up TLS on cfg. A custom CA or client certificate needs a tls.Config;
+// the plain modes are passed through to the driver by name.
+func applyTLS(cfg *mysql.Config, settings config.Database) error {
+    if settings.TLSCA == "" && settings.TLSCert == "" {
+        if settings.TLS != "" {
+            cfg.TLSConfig = settings.TLS
+            cfg.TLS = nil
+        }
+        return nil
     }
 
-    fmt.Println("Database connected successfully.")
-    fmt.Printf("Connected to schema: %s\n", currentSchema)
+    tlsCfg := &tls.Config{InsecureSkipVerify: settings.TLS == "skip-verify"}
+    if settings.TLSCA != "" {
+        pem, err := os.ReadFile(settings.TLSCA)
+        if err != nil {
+            return fmt.Errorf("reading TLS CA: %w", err)
+        }
+        pool := x509.NewCertPool()
+        if !pool.AppendCertsFromPEM(pem) {
+            return fmt.Errorf("no certificates found in TLS CA file %s", settings.TLSCA)
+        }
+        tlsCfg.RootCAs = pool
+    }
+    if settings.TLSCert != "" {
+        cert, err := tls.LoadX509KeyPair(settings.TLSCert, settings.TLSKey)
+        if err != nil {
+            return fmt.Errorf("loading TLS client certificate: %w", err)
+        }
+        tlsCfg.Certificates = []tls.Certificate{cert}
+    }
+    cfg.TLS = tlsCfg
+    cfg.AllowFallbackToPlaintext = settings.TLS == "preferred"
+    return nil
 }
 
 // Shutdown cleanly closes the database connection.
@@ -57,35 +174,43 @@ func (d *Database) Shutdown() {
     if d.conn != nil {
         d.conn.Close()
         d.conn = nil
-        fmt.Println("Database connection closed.")
+        slog.Info("database connection closed")
+    }
+}
+
+// Stats returns the connection pool statistics, or zero values before Boot.
+func (d *Database) Stats() sql.DBStats {
+    if d.conn == nil {
+        return sql.DBStats{}
     }
+    return d.conn.Stats()
 }
 
-// IsRunning checks if the database connection is active.
-func (d *Database) IsRunning() bool {
-    return d.conn != nil
+// observe records how long a database operation took, for the /metrics endpoint.
+func observe(operation string, start time.Time) {
+    metrics.DBQueryDuration.Observe(time.Since(start).Seconds(), operation)
 }
 
 // QueryRow executes a query that is expected to return at most one row.
-func (d *Database) QueryRow(query string, args ...interface{}) *sql.Row {
-    return d.conn.QueryRow(query, args...)
+func (d *Database) QueryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+    return d.conn.QueryRowContext(ctx, query, args...)
 }
 
 // Query executes a query that returns rows.
-func (d *Database) Query(query string, args ...interface{}) (*sql.Rows, error) {
-    return d.conn.Query(query, args...)
+func (d *Database) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+    return d.conn.QueryContext(ctx, query, args...)
 }
 
 // Exec executes a query without returning any rows.
-func (d *Database) Exec(query string, args ...interface{}) (sql.Result, error) {
-    return d.conn.Exec(query, args...)
+func (d *Database) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+    return d.conn.ExecContext(ctx, query, args...)
 }
 
 // ValidateTableStructure checks if the table columns match the expected structure.
-func (d *Database) ValidateTableStructure(tableName string, expectedCols []string) bool {
-    rows, err := d.conn.Query(fmt.Sprintf("DESCRIBE %s", tableName))
+func (d *Database) ValidateTableStructure(ctx context.Context, tableName string, expectedCols []string) bool {
+    rows, err := d.conn.QueryContext(ctx, fmt.Sprintf("DESCRIBE %s", tableName))
     if err != nil {
-        fmt.Printf("Failed to describe table '%s': %v\n", tableName, err)
+        slog.ErrorContext(ctx, "failed to describe table", "table", tableName, "error", err)
         return false
     }
     defer rows.Close()
@@ -96,7 +221,7 @@ func (d *Database) ValidateTableStructure(tableName string, expectedCols []strin
         var defaultVal sql.NullString
         var extra string
         if err := rows.Scan(&field, &colType, &null, &key, &defaultVal, &extra); err != nil {
-            fmt.Printf("Failed to scan table description: %v\n", err)
+            slog.ErrorContext(ctx, "failed to scan table description", "table", tableName, "error", err)
             return false
         }
         actualCols = append(actualCols, field)
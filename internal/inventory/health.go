@@ -0,0 +1,123 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "log/slog"
+    "math/rand"
+    "sync"
+    "time"
+
+    "github.com/go-sql-driver/mysql"
+)
+
+// Backoff bounds for retrying the first connection.
+const (
+    bootInitialBackoff = 500 * time.Millisecond
+    bootMaxBackoff     = 10 * time.Second
+)
+
+// defaultHealthTimeout bounds a health check when the caller's context has no deadline.
+const defaultHealthTimeout = 2 * time.Second
+
+// healthState records the outcome of the most recent health check.
+type healthState struct {
+    mu        sync.Mutex
+    err       error
+    checkedAt time.Time
+}
+
+// HealthStatus describes the database connection as of the last check.
+type HealthStatus struct {
+    Healthy   bool      `json:"healthy"`
+    Error     string    `json:"error,omitempty"`
+    CheckedAt time.Time `json:"checkedAt"`
+}
+
+// Health pings the database and reports whether it answered in time. It replaces the
+// old IsRunning check, which only reported whether a connection pool had been created.
+func (d *Database) Health(ctx context.Context) error {
+    if d.conn == nil {
+        err := errors.New("database is not connected")
+        d.markHealth(err)
+        return err
+    }
+    err := pingWithTimeout(ctx, d.conn, defaultHealthTimeout)
+    d.markHealth(err)
+    return err
+}
+
+// LastHealth returns the result of the most recent health check without querying the database.
+func (d *Database) LastHealth() HealthStatus {
+    d.health.mu.Lock()
+    defer d.health.mu.Unlock()
+    status := HealthStatus{Healthy: d.health.err == nil && !d.health.checkedAt.IsZero(), CheckedAt: d.health.checkedAt}
+    if d.health.err != nil {
+        status.Error = d.health.err.Error()
+    }
+    return status
+}
+
+// markHealth records a health check result and reports when the connection is lost or restored.
+func (d *Database) markHealth(err error) {
+    d.health.mu.Lock()
+    defer d.health.mu.Unlock()
+    wasHealthy := d.health.err == nil && !d.health.checkedAt.IsZero()
+    switch {
+    case wasHealthy && err != nil:
+        slog.Error("database connection lost", "error", err)
+    case !wasHealthy && err == nil && !d.health.checkedAt.IsZero():
+        slog.Info("database connection restored")
+    }
+    d.health.err = err
+    d.health.checkedAt = time.Now()
+}
+
+// MonitorHealth checks the connection every HealthInterval until ctx is cancelled.
+// database/sql discards broken connections and dials new ones on demand, so when MySQL
+// restarts, requests fail only until it is back; the monitor makes the outage visible
+// in the log and keeps LastHealth current for status endpoints.
+func (d *Database) MonitorHealth(ctx context.Context) {
+    if d.cfg.HealthInterval <= 0 {
+        return
+    }
+    ticker := time.NewTicker(d.cfg.HealthInterval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            d.Health(ctx)
+        }
+    }
+}
+
+// pingWithTimeout pings conn, giving up after timeout unless ctx already has a deadline.
+func pingWithTimeout(ctx context.Context, conn *sql.DB, timeout time.Duration) error {
+    if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, timeout)
+        defer cancel()
+    }
+    return conn.PingContext(ctx)
+}
+
+// retryable reports whether a connection error may go away by waiting, as opposed to
+// configuration mistakes like wrong credentials or a missing database.
+func retryable(err error) bool {
+    var mysqlErr *mysql.MySQLError
+    if errors.As(err, &mysqlErr) {
+        switch mysqlErr.Number {
+        case 1044, 1045, 1049, 1698: // access denied, unknown database
+            return false
+        }
+    }
+    return true
+}
+
+// jitter spreads retries of several instances apart by up to 20% of d.
+func jitter(d time.Duration) time.Duration {
+    return d + time.Duration(rand.Int63n(int64(d)/5+1))
+}
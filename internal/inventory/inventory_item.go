@@ -13,6 +13,7 @@ type InventoryItem struct {
     ItemSubstitutionID  int       `json:"itemSubstitutionID"`
     ItemExpirationPeriod int      `json:"itemExpirationPeriod"`
     ItemTotalTossed     int       `json:"itemTotalTossed"`
+    Barcodes            []string  `json:"barcodes,omitempty"`
     CreateDate          time.Time `json:"createDate"`
     LastModifiedDate    time.Time `json:"lastModifiedDate"`
 }
@@ -21,16 +22,81 @@ type InventoryItem struct {
 type InventoryItemWithDetails struct {
     ID                   int       `json:"id"`
     ItemName             string    `json:"itemName"`
-    ItemQTY              int       `json:"itemQTY"`
+    ItemQTY              int       `json:"itemQTY"` // available: owned units minus LentQTY
+    LentQTY              int       `json:"lentQTY"`
     MinimumQTY           int       `json:"minimumQTY"`
     ItemUsedToDate       int       `json:"itemUsedToDate"`
     ItemTotalTossed      int       `json:"itemTotalTossed"`
+    ItemTypeID           int       `json:"itemTypeID"`
     ItemTypeName         string    `json:"itemTypeName"`
+    ItemSubstitutionID   int       `json:"itemSubstitutionID"`
     ItemSubstitutionName string    `json:"itemSubstitutionName"`
+    ItemExpirationPeriod int       `json:"itemExpirationPeriod"`
+    LocationID           int       `json:"locationID"`
+    LocationName         string    `json:"locationName"`
+    Barcodes             []string  `json:"barcodes"`
+    Tags                 []string  `json:"tags"`
     CreateDate           time.Time `json:"createDate"`
     LastModifiedDate     time.Time `json:"lastModifiedDate"`
 }
 
+// ItemUnit represents a record in the item_expiration_xref table: one unit of stock.
+type ItemUnit struct {
+    ID             int       `json:"id"`
+    ItemID         int       `json:"itemID"`
+    CreationDate   time.Time `json:"creationDate"`
+    ExpirationDate time.Time `json:"expirationDate"`
+}
+
+// Item history events.
+const (
+    HistoryAdded     = "added"
+    HistoryRestocked = "restocked"
+    HistoryUsed      = "used"
+    HistoryDisposed  = "disposed"
+    HistoryImported  = "imported"
+    HistoryEdited    = "edited"
+    HistoryDeleted   = "deleted"
+    HistoryLent      = "lent"
+    HistoryReturned  = "returned"
+)
+
+// ItemHistoryEntry represents a record in the item_history table: one change to an
+// item's stock.
+type ItemHistoryEntry struct {
+    ID            int       `json:"id"`
+    ItemID        int       `json:"itemID"`
+    ItemName      string    `json:"itemName"`
+    Event         string    `json:"event"`
+    Units         int       `json:"units"`
+    QuantityAfter int       `json:"quantityAfter"`
+    CreateDate    time.Time `json:"createDate"`
+}
+
+// ItemUpdate holds the editable fields of an inventory item. Quantities change only
+// through stock operations so the history stays accurate.
+type ItemUpdate struct {
+    ItemName             string `json:"itemName"`
+    MinimumQTY           int    `json:"minimumQTY"`
+    ItemTypeID           int    `json:"itemTypeID"`
+    ItemSubstitutionID   int    `json:"itemSubstitutionID"`
+    ItemExpirationPeriod int    `json:"itemExpirationPeriod"`
+    // LocationID is where the item is kept; 0 clears the location.
+    LocationID int `json:"locationID"`
+    // RecomputeExpirations moves the expiration date of units that have not expired
+    // yet to their creation date plus the new expiration period.
+    RecomputeExpirations bool `json:"recomputeExpirations"`
+}
+
+// ExpiringUnits counts an item's stock units that expire on the same day.
+type ExpiringUnits struct {
+    ItemID         int       `json:"itemID"`
+    ItemName       string    `json:"itemName"`
+    ExpirationDate time.Time `json:"expirationDate"`
+    DaysLeft       int       `json:"daysLeft"`
+    Units          int       `json:"units"`
+}
+
 // ItemType represents a record in the item_type table.
 type ItemType struct {
     ID   int    `json:"id"`
@@ -42,3 +108,260 @@ type ItemSubstitution struct {
     ID   int    `json:"id"`
     Name string `json:"name"`
 }
+
+// ItemLocation represents a record in the item_location table: a room or storage spot.
+type ItemLocation struct {
+    ID   int    `json:"id"`
+    Name string `json:"name"`
+}
+
+// ItemRef identifies an item in pickers, such as the consumables a maintenance task can use.
+type ItemRef struct {
+    ID       int    `json:"id"`
+    ItemName string `json:"itemName"`
+}
+
+// ItemTag represents a record in the item_tag table: a label such as "gluten-free" that
+// any number of items can carry.
+type ItemTag struct {
+    ID   int    `json:"id"`
+    Name string `json:"name"`
+    // Items counts the items carrying the tag.
+    Items int `json:"items"`
+}
+
+// ItemPhoto is a photo attached to an item. FileName is the content-addressed name of the
+// stored image; the same image attached to two items is stored once.
+type ItemPhoto struct {
+    ID          int       `json:"id"`
+    ItemID      int       `json:"itemID"`
+    FileName    string    `json:"fileName"`
+    ContentType string    `json:"contentType"`
+    Width       int       `json:"width"`
+    Height      int       `json:"height"`
+    SizeBytes   int64     `json:"sizeBytes"`
+    Caption     string    `json:"caption"`
+    CreateDate  time.Time `json:"createDate"`
+}
+
+// ItemAsset holds the details kept for a durable good, such as an appliance or a bike,
+// rather than a consumable; an item is an asset when it has them. Amounts are in cents
+// and nil fields are unknown.
+type ItemAsset struct {
+    ItemID         int        `json:"itemID"`
+    Manufacturer   string     `json:"manufacturer"`
+    Model          string     `json:"model"`
+    SerialNumber   string     `json:"serialNumber"`
+    PurchaseDate   *time.Time `json:"purchaseDate"`
+    PurchasePrice  *int64     `json:"purchasePriceCents"`
+    WarrantyExpiry *time.Time `json:"warrantyExpiry"`
+    CurrentValue   *int64     `json:"currentValueCents"`
+}
+
+// InsuranceLine is one asset in an insurance report. Totals multiply the per-unit
+// amounts by the quantity owned; unknown amounts count as zero.
+type InsuranceLine struct {
+    ItemAsset
+    ItemName      string `json:"itemName"`
+    Quantity      int    `json:"quantity"`
+    PurchaseTotal int64  `json:"purchaseTotalCents"`
+    ValueTotal    int64  `json:"valueTotalCents"`
+}
+
+// InsuranceRoom groups the assets kept in one location.
+type InsuranceRoom struct {
+    Room          string          `json:"room"`
+    Lines         []InsuranceLine `json:"lines"`
+    PurchaseTotal int64           `json:"purchaseTotalCents"`
+    ValueTotal    int64           `json:"valueTotalCents"`
+}
+
+// InsuranceReport lists every asset owned, room by room, with totals. Unvalued counts
+// the lines with no current value, whose totals may understate what they are worth.
+type InsuranceReport struct {
+    Generated     time.Time       `json:"generated"`
+    Rooms         []InsuranceRoom `json:"rooms"`
+    Items         int             `json:"items"`
+    Unvalued      int             `json:"unvalued"`
+    PurchaseTotal int64           `json:"purchaseTotalCents"`
+    ValueTotal    int64           `json:"valueTotalCents"`
+}
+
+// Interval is how often a maintenance task recurs: Count days, weeks, months or years.
+// The zero Interval means the task is done once.
+type Interval struct {
+    Count int    `json:"count"`
+    Unit  string `json:"unit"`
+}
+
+// MaintenanceTask represents a record in the maintenance_task table: a job to do on an
+// item, such as replacing the furnace filter. NextDue is nil once a one-off task is done.
+// When ConsumeItemID is set, completing the task uses ConsumeUnits of that item.
+type MaintenanceTask struct {
+    ID              int        `json:"id"`
+    ItemID          int        `json:"itemID"`
+    ItemName        string     `json:"itemName"`
+    Name            string     `json:"name"`
+    Every           Interval   `json:"every"`
+    NextDue         *time.Time `json:"nextDue"`
+    LastDone        *time.Time `json:"lastDone"`
+    ConsumeItemID   int        `json:"consumeItemID,omitempty"`
+    ConsumeItemName string     `json:"consumeItemName,omitempty"`
+    ConsumeUnits    int        `json:"consumeUnits,omitempty"`
+}
+
+// MaintenanceLogEntry represents a record in the maintenance_log table: one completion of
+// a task. ConsumedUnits may be less than the task asks for when the stock ran out.
+type MaintenanceLogEntry struct {
+    ID               int        `json:"id"`
+    TaskID           int        `json:"taskID,omitempty"`
+    ItemID           int        `json:"itemID"`
+    ItemName         string     `json:"itemName"`
+    TaskName         string     `json:"taskName"`
+    DueDate          *time.Time `json:"dueDate"`
+    DoneDate         time.Time  `json:"doneDate"`
+    Note             string     `json:"note"`
+    ConsumedItemName string     `json:"consumedItemName,omitempty"`
+    ConsumedUnits    int        `json:"consumedUnits"`
+}
+
+// MaintenanceCompletion describes how a task was done. A zero DoneDate means today.
+type MaintenanceCompletion struct {
+    DoneDate    time.Time
+    Note        string
+    SkipConsume bool
+}
+
+// MaintenanceDue is a task or warranty coming due. Kind is "task" or "warranty"; TaskID
+// is zero for warranties. DaysLeft is negative when overdue.
+type MaintenanceDue struct {
+    Kind     string    `json:"kind"`
+    TaskID   int       `json:"taskID,omitempty"`
+    ItemID   int       `json:"itemID"`
+    ItemName string    `json:"itemName"`
+    Name     string    `json:"name"`
+    DueDate  time.Time `json:"dueDate"`
+    DaysLeft int       `json:"daysLeft"`
+    Overdue  bool      `json:"overdue"`
+}
+
+// ItemLoan represents a record in the item_loan table: units of an item lent to someone.
+// ReturnedDate is nil while the units are out. DaysOverdue is positive for loans still out
+// after their due date.
+type ItemLoan struct {
+    ID           int        `json:"id"`
+    ItemID       int        `json:"itemID"`
+    ItemName     string     `json:"itemName"`
+    Borrower     string     `json:"borrower"`
+    Quantity     int        `json:"quantity"`
+    LentDate     time.Time  `json:"lentDate"`
+    DueDate      *time.Time `json:"dueDate"`
+    ReturnedDate *time.Time `json:"returnedDate"`
+    Note         string     `json:"note"`
+    DaysOverdue  int        `json:"daysOverdue"`
+}
+
+// LoanQuery selects the loans returned by GetLoans. Zero values select every loan still
+// out.
+type LoanQuery struct {
+    ItemID int
+    // Overdue keeps only loans still out after their due date.
+    Overdue bool
+    // IncludeReturned adds loans already returned, for an item's lending history.
+    IncludeReturned bool
+    Limit           int
+}
+
+// SearchResult is an item found by SearchItems with its relevance score; results are
+// ordered best first.
+type SearchResult struct {
+    Item  InventoryItemWithDetails `json:"item"`
+    Score float64                  `json:"score"`
+    // Fuzzy is set when the result came from typo-tolerant matching instead of the
+    // FULLTEXT index.
+    Fuzzy bool `json:"fuzzy"`
+}
+
+// ItemListQuery selects, orders and pages the items returned by ListItems. Zero
+// values leave a filter off.
+type ItemListQuery struct {
+    // Name matches items whose name contains it, ignoring case.
+    Name         string
+    ItemType     string
+    Substitution string
+    Location     string
+    // Kind is "asset" for durable goods, "consumable" for everything else, or "" for both.
+    Kind         string
+    UnderMinimum bool
+    // Tags keeps items carrying every one of these tags.
+    Tags []string
+    // ExpiringWithin, when set, keeps items with a unit that has expired or expires
+    // within that many days.
+    ExpiringWithin *int
+    // Sort is one of ItemSortFields; it defaults to "id".
+    Sort       string
+    Descending bool
+    // Limit is the page size; 0 returns every matching item.
+    Limit int
+    // Cursor is the NextCursor of the previous page.
+    Cursor string
+}
+
+// ItemListPage is one page of ListItems results.
+type ItemListPage struct {
+    Items []InventoryItemWithDetails `json:"items"`
+    // Total counts every item matching the filters, not just this page.
+    Total int `json:"total"`
+    // NextCursor fetches the following page; it is empty on the last page.
+    NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// CatalogProduct represents a record in the product_catalog table.
+type CatalogProduct struct {
+    ID            int    `json:"id"`
+    Barcode       string `json:"barcode"`
+    ProductName   string `json:"productName"`
+    Brand         string `json:"brand"`
+    Category      string `json:"category"`
+    QuantityLabel string `json:"quantityLabel"`
+}
+
+// BarcodeLookupResult describes what a scanned barcode resolved to.
+// Status is "item" when an existing item was incremented, "catalog" when the
+// code matched the local product catalog, or "unknown" otherwise.
+type BarcodeLookupResult struct {
+    Status  string                 `json:"status"`
+    Barcode string                 `json:"barcode"`
+    Item    map[string]interface{} `json:"item,omitempty"`
+    Product *CatalogProduct        `json:"product,omitempty"`
+}
+
+// QueuedScan represents a record in the scan_queue table: a barcode that was scanned
+// but not assigned to any item yet.
+type QueuedScan struct {
+    ID           int             `json:"id"`
+    Barcode      string          `json:"barcode"`
+    ScanMode     string          `json:"scanMode"`
+    ScanCount    int             `json:"scanCount"`
+    FirstScanned time.Time       `json:"firstScanned"`
+    LastScanned  time.Time       `json:"lastScanned"`
+    Product      *CatalogProduct `json:"product,omitempty"`
+}
+
+// ScanResult describes the outcome of a single scan in rapid scan mode.
+type ScanResult struct {
+    Status  string                 `json:"status"`
+    Mode    string                 `json:"mode"`
+    Barcode string                 `json:"barcode,omitempty"`
+    Message string                 `json:"message"`
+    Item    map[string]interface{} `json:"item,omitempty"`
+}
+
+// InventoryStats summarizes the inventory for monitoring.
+type InventoryStats struct {
+    Items         int `json:"items"`
+    UnderMinimum  int `json:"underMinimum"`
+    ExpiringUnits int `json:"expiringUnits"`
+    UnitsUsed     int `json:"unitsUsed"`
+    UnitsTossed   int `json:"unitsTossed"`
+}
@@ -0,0 +1,276 @@
+package inventory
+
+import (
+    "context"
+    "encoding/base64"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ErrInvalidItemQuery is wrapped by ListItems errors caused by a bad filter, sort or cursor
+// rather than by the database.
+var ErrInvalidItemQuery = errors.New("invalid item query")
+
+// itemSortField is a column ListItems can order by, and how to read it back from an item
+// to build a cursor.
+type itemSortField struct {
+    column string
+    value  func(item InventoryItemWithDetails) string
+}
+
+// itemSortFields maps sort names to columns. Only these names reach the SQL, so callers
+// cannot inject an ORDER BY expression.
+var itemSortFields = map[string]itemSortField{
+    "id":           {"i.id", func(item InventoryItemWithDetails) string { return strconv.Itoa(item.ID) }},
+    "name":         {"i.item_name", func(item InventoryItemWithDetails) string { return item.ItemName }},
+    "qty":          {availableQTY, func(item InventoryItemWithDetails) string { return strconv.Itoa(item.ItemQTY) }},
+    "minimum":      {"i.minimumQTY", func(item InventoryItemWithDetails) string { return strconv.Itoa(item.MinimumQTY) }},
+    "used":         {"i.itemUsedToDate", func(item InventoryItemWithDetails) string { return strconv.Itoa(item.ItemUsedToDate) }},
+    "tossed":       {"COALESCE(i.item_total_tossed, 0)", func(item InventoryItemWithDetails) string { return strconv.Itoa(item.ItemTotalTossed) }},
+    "type":         {"COALESCE(t.type_name, '')", func(item InventoryItemWithDetails) string { return item.ItemTypeName }},
+    "substitution": {"COALESCE(s.substitution_name, '')", func(item InventoryItemWithDetails) string { return item.ItemSubstitutionName }},
+    "location":     {"COALESCE(l.location_name, '')", func(item InventoryItemWithDetails) string { return item.LocationName }},
+    "created":      {"i.createDate", func(item InventoryItemWithDetails) string { return item.CreateDate.Format(time.DateTime) }},
+    "modified":     {"i.lastModifiedDate", func(item InventoryItemWithDetails) string { return item.LastModifiedDate.Format(time.DateTime) }},
+}
+
+// ItemSortFields returns the names ListItems accepts as a sort field.
+func ItemSortFields() []string {
+    names := make([]string, 0, len(itemSortFields))
+    for name := range itemSortFields {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// itemCursor is the position after the last item of a page: its sort value and ID.
+// The sort it was made for is kept so a cursor cannot be reused with another order.
+type itemCursor struct {
+    Sort  string `json:"s"`
+    Desc  bool   `json:"d,omitempty"`
+    Value string `json:"v"`
+    ID    int    `json:"id"`
+}
+
+func (c itemCursor) encode() string {
+    data, _ := json.Marshal(c)
+    return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeItemCursor(s string) (itemCursor, error) {
+    var c itemCursor
+    data, err := base64.RawURLEncoding.DecodeString(s)
+    if err == nil {
+        err = json.Unmarshal(data, &c)
+    }
+    if err != nil {
+        return itemCursor{}, fmt.Errorf("%w: malformed cursor", ErrInvalidItemQuery)
+    }
+    return c, nil
+}
+
+// ListItems returns the items matching q, in q's order, one page at a time, with the
+// number of items matching the filters. Pages are keyed on the sort value and ID of the
+// last item rather than an offset, so items added or removed between requests do not
+// shift later pages.
+func ListItems(ctx context.Context, db *Database, q ItemListQuery) (ItemListPage, error) {
+    defer observe("ListItems", time.Now())
+    if q.Sort == "" {
+        q.Sort = "id"
+    }
+    field, ok := itemSortFields[q.Sort]
+    if !ok {
+        return ItemListPage{}, fmt.Errorf("%w: unknown sort field %q (use one of %s)",
+            ErrInvalidItemQuery, q.Sort, strings.Join(ItemSortFields(), ", "))
+    }
+    if q.Limit < 0 {
+        return ItemListPage{}, fmt.Errorf("%w: limit must not be negative", ErrInvalidItemQuery)
+    }
+    if q.Kind != "" && q.Kind != ItemKindAsset && q.Kind != ItemKindConsumable {
+        return ItemListPage{}, fmt.Errorf("%w: kind must be %s or %s", ErrInvalidItemQuery, ItemKindAsset, ItemKindConsumable)
+    }
+    if q.ExpiringWithin != nil && *q.ExpiringWithin < 0 {
+        return ItemListPage{}, fmt.Errorf("%w: expiring days must not be negative", ErrInvalidItemQuery)
+    }
+
+    where, args := itemListFilters(q)
+
+    page := ItemListPage{Items: []InventoryItemWithDetails{}}
+    if err := db.conn.QueryRowContext(ctx, "SELECT COUNT(*)"+itemDetailsFrom+where, args...).Scan(&page.Total); err != nil {
+        return ItemListPage{}, err
+    }
+
+    direction, compare := "ASC", ">"
+    if q.Descending {
+        direction, compare = "DESC", "<"
+    }
+    query := itemDetailsQuery + where
+    if q.Cursor != "" {
+        cursor, err := decodeItemCursor(q.Cursor)
+        if err != nil {
+            return ItemListPage{}, err
+        }
+        if cursor.Sort != q.Sort || cursor.Desc != q.Descending {
+            return ItemListPage{}, fmt.Errorf("%w: cursor was made for a different sort order", ErrInvalidItemQuery)
+        }
+        query += fmt.Sprintf(" AND (%s %s ? OR (%s = ? AND i.id %s ?))", field.column, compare, field.column, compare)
+        args = append(args, cursor.Value, cursor.Value, cursor.ID)
+    }
+    query += fmt.Sprintf(" ORDER BY %s %s, i.id %s", field.column, direction, direction)
+    if q.Limit > 0 {
+        // One extra row tells whether there is another page.
+        query += " LIMIT ?"
+        args = append(args, q.Limit+1)
+    }
+
+    rows, err := db.conn.QueryContext(ctx, query, args...)
+    if err != nil {
+        return ItemListPage{}, err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        item, err := scanItemDetails(rows)
+        if err != nil {
+            return ItemListPage{}, err
+        }
+        page.Items = append(page.Items, item)
+    }
+    if err := rows.Err(); err != nil {
+        return ItemListPage{}, err
+    }
+
+    if q.Limit > 0 && len(page.Items) > q.Limit {
+        page.Items = page.Items[:q.Limit]
+        last := page.Items[len(page.Items)-1]
+        page.NextCursor = itemCursor{Sort: q.Sort, Desc: q.Descending, Value: field.value(last), ID: last.ID}.encode()
+    }
+
+    barcodes, err := GetItemBarcodes(ctx, db, itemIDs(page.Items))
+    if err != nil {
+        return ItemListPage{}, err
+    }
+    for i := range page.Items {
+        page.Items[i].Barcodes = barcodes[page.Items[i].ID]
+        if page.Items[i].Barcodes == nil {
+            page.Items[i].Barcodes = []string{}
+        }
+    }
+    if err := attachTags(ctx, db, page.Items); err != nil {
+        return ItemListPage{}, err
+    }
+
+    return page, nil
+}
+
+// itemListFilters builds the WHERE conditions for q's filters, to append to
+// itemDetailsFrom, with their arguments.
+func itemListFilters(q ItemListQuery) (string, []interface{}) {
+    var where strings.Builder
+    args := []interface{}{}
+
+    if q.Name != "" {
+        where.WriteString(" AND i.item_name LIKE ?")
+        args = append(args, "%"+escapeLike(q.Name)+"%")
+    }
+    if q.ItemType != "" {
+        where.WriteString(" AND t.type_name = ?")
+        args = append(args, q.ItemType)
+    }
+    if q.Substitution != "" {
+        where.WriteString(" AND s.substitution_name = ?")
+        args = append(args, q.Substitution)
+    }
+    if q.Location != "" {
+        where.WriteString(" AND l.location_name = ?")
+        args = append(args, q.Location)
+    }
+    switch q.Kind {
+    case ItemKindAsset:
+        where.WriteString(" AND EXISTS (SELECT 1 FROM item_asset d WHERE d.item_id = i.id)")
+    case ItemKindConsumable:
+        where.WriteString(" AND NOT EXISTS (SELECT 1 FROM item_asset d WHERE d.item_id = i.id)")
+    }
+    if q.UnderMinimum {
+        where.WriteString(" AND " + availableQTY + " < i.minimumQTY")
+    }
+    if len(q.Tags) > 0 {
+        q.Tags = uniqueTagNames(q.Tags)
+        placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(q.Tags)), ", ")
+        fmt.Fprintf(&where, ` AND i.id IN (
+            SELECT x.item_id FROM item_tag_xref x JOIN item_tag g ON g.id = x.tag_id
+            WHERE g.tag_name IN (%s)
+            GROUP BY x.item_id
+            HAVING COUNT(DISTINCT g.id) = ?)`, placeholders)
+        for _, tag := range q.Tags {
+            args = append(args, tag)
+        }
+        args = append(args, len(q.Tags))
+    }
+    if q.ExpiringWithin != nil {
+        // Same window as GetExpiringUnits: expired units count too.
+        where.WriteString(` AND EXISTS (
+            SELECT 1 FROM item_expiration_xref x
+            WHERE x.item_id = i.id AND x.item_expiration_date < DATE_ADD(?, INTERVAL ? + 1 DAY))`)
+        args = append(args, Today().Format(time.DateOnly), *q.ExpiringWithin)
+    }
+    return where.String(), args
+}
+
+// itemIDs returns the IDs of items.
+func itemIDs(items []InventoryItemWithDetails) []int {
+    ids := make([]int, len(items))
+    for i, item := range items {
+        ids[i] = item.ID
+    }
+    return ids
+}
+
+// itemIDCondition returns a WHERE clause limiting column to ids, with its arguments, or
+// no clause when ids is nil so every item matches.
+func itemIDCondition(column string, ids []int) (string, []interface{}) {
+    switch {
+    case ids == nil:
+        return "", nil
+    case len(ids) == 0:
+        return " WHERE FALSE", nil
+    }
+    placeholders, args := idList(ids)
+    return " WHERE " + column + " IN (" + placeholders + ")", args
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally.
+func escapeLike(s string) string {
+    return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetConsumableRefs retrieves the ID and name of every consumable item, by name.
+func GetConsumableRefs(ctx context.Context, db *Database) ([]ItemRef, error) {
+    defer observe("GetConsumableRefs", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT i.id, i.item_name
+        FROM inventory_item i
+        WHERE NOT EXISTS (SELECT 1 FROM item_asset d WHERE d.item_id = i.id)
+        ORDER BY i.item_name ASC
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    refs := []ItemRef{}
+    for rows.Next() {
+        var ref ItemRef
+        if err := rows.Scan(&ref.ID, &ref.ItemName); err != nil {
+            return nil, err
+        }
+        refs = append(refs, ref)
+    }
+    return refs, rows.Err()
+}
@@ -0,0 +1,241 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "log/slog"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// maxBorrowers is how many recent borrower names GetBorrowers returns.
+const maxBorrowers = 100
+
+// ErrItemLent is wrapped by errors refusing to delete an item or use up units of it
+// while they are lent out.
+var ErrItemLent = errors.New("item is lent out")
+
+// loanColumns selects an item_loan row for scanLoan. The item's current name is used
+// while it exists, and the name stored with the loan once it is deleted.
+const loanColumns = `
+    n.id, n.item_id, COALESCE(i.item_name, n.item_name), n.borrower, n.quantity, n.lent_date, n.due_date,
+    n.returned_date, n.note
+    FROM item_loan n
+    LEFT JOIN inventory_item i ON i.id = n.item_id`
+
+// scanLoan reads the columns selected by loanColumns.
+func scanLoan(row interface{ Scan(...interface{}) error }, today time.Time) (ItemLoan, error) {
+    var loan ItemLoan
+    var due, returned sql.NullTime
+    err := row.Scan(&loan.ID, &loan.ItemID, &loan.ItemName, &loan.Borrower, &loan.Quantity, &loan.LentDate,
+        &due, &returned, &loan.Note)
+    if err != nil {
+        return ItemLoan{}, err
+    }
+    if due.Valid {
+        loan.DueDate = &due.Time
+    }
+    if returned.Valid {
+        loan.ReturnedDate = &returned.Time
+    }
+    if loan.ReturnedDate == nil && loan.DueDate != nil {
+        loan.DaysOverdue = max(DaysBetween(*loan.DueDate, today), 0)
+    }
+    return loan, nil
+}
+
+// GetLoans retrieves the loans selected by q. Loans still out are listed soonest due
+// first; with IncludeReturned the newest loans come first.
+func GetLoans(ctx context.Context, db *Database, q LoanQuery) ([]ItemLoan, error) {
+    defer observe("GetLoans", time.Now())
+    var where strings.Builder
+    var args []interface{}
+    where.WriteString(" WHERE 1=1")
+    if q.ItemID != 0 {
+        where.WriteString(" AND n.item_id = ?")
+        args = append(args, q.ItemID)
+    }
+    if !q.IncludeReturned {
+        where.WriteString(" AND n.returned_date IS NULL")
+    }
+    if q.Overdue {
+        where.WriteString(" AND n.returned_date IS NULL AND n.due_date < ?")
+        args = append(args, Today().Format(time.DateOnly))
+    }
+    order := " ORDER BY n.due_date IS NULL, n.due_date ASC, n.lent_date ASC, n.id ASC"
+    if q.IncludeReturned {
+        order = " ORDER BY n.lent_date DESC, n.id DESC"
+    }
+    limit := ""
+    if q.Limit > 0 {
+        limit = " LIMIT ?"
+        args = append(args, q.Limit)
+    }
+
+    rows, err := db.conn.QueryContext(ctx, "SELECT"+loanColumns+where.String()+order+limit, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    today := Today()
+    loans := []ItemLoan{}
+    for rows.Next() {
+        loan, err := scanLoan(rows, today)
+        if err != nil {
+            return nil, err
+        }
+        loans = append(loans, loan)
+    }
+    return loans, rows.Err()
+}
+
+// GetLoan retrieves one loan.
+func GetLoan(ctx context.Context, db *Database, id int) (ItemLoan, error) {
+    defer observe("GetLoan", time.Now())
+    return scanLoan(db.conn.QueryRowContext(ctx, "SELECT"+loanColumns+" WHERE n.id = ?", id), Today())
+}
+
+// lentQuantity returns the units of an item lent out and not yet returned.
+func lentQuantity(ctx context.Context, tx *sql.Tx, itemID int) (int, error) {
+    var lent int
+    err := tx.QueryRowContext(ctx, `
+        SELECT COALESCE(SUM(quantity), 0) FROM item_loan WHERE item_id = ? AND returned_date IS NULL
+    `, itemID).Scan(&lent)
+    return lent, err
+}
+
+// LendItem checks units of an item out to a borrower. The units stay owned but no longer
+// count as available until they are returned. It returns sql.ErrNoRows when the item does
+// not exist, and an error when fewer units are available than asked for.
+func LendItem(ctx context.Context, db *Database, loan ItemLoan) (int64, error) {
+    defer observe("LendItem", time.Now())
+    loan.Borrower = strings.Join(strings.Fields(loan.Borrower), " ")
+    loan.Note = strings.TrimSpace(loan.Note)
+    if loan.Borrower == "" || utf8.RuneCountInString(loan.Borrower) > 128 {
+        return 0, fmt.Errorf("borrower must be 1 to 128 characters")
+    }
+    if loan.Quantity < 1 {
+        return 0, fmt.Errorf("quantity must be at least 1")
+    }
+    if utf8.RuneCountInString(loan.Note) > 255 {
+        return 0, fmt.Errorf("note must be at most 255 characters")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    var name string
+    var qty int
+    err = tx.QueryRowContext(ctx, `SELECT item_name, itemQTY FROM inventory_item WHERE id = ? FOR UPDATE`, loan.ItemID).Scan(&name, &qty)
+    if err != nil {
+        return 0, err
+    }
+    lent, err := lentQuantity(ctx, tx, loan.ItemID)
+    if err != nil {
+        return 0, err
+    }
+    available := qty - lent
+    if loan.Quantity > available {
+        return 0, fmt.Errorf("only %d of %s available to lend", max(available, 0), name)
+    }
+
+    res, err := tx.ExecContext(ctx, `
+        INSERT INTO item_loan (item_id, item_name, borrower, quantity, due_date, note)
+        VALUES (?, ?, ?, ?, ?, ?)
+    `, loan.ItemID, name, loan.Borrower, loan.Quantity, nullDate(loan.DueDate), loan.Note)
+    if err != nil {
+        return 0, err
+    }
+    id, err := res.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+    if err := recordItemHistory(ctx, tx, int64(loan.ItemID), HistoryLent, loan.Quantity, available-loan.Quantity); err != nil {
+        return 0, err
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    slog.InfoContext(ctx, "item lent", "item", name, "borrower", loan.Borrower, "qty", loan.Quantity)
+    return id, nil
+}
+
+// ReturnLoan checks a loan back in, making its units available again. It returns
+// sql.ErrNoRows when the loan does not exist, and an error when it was already returned.
+func ReturnLoan(ctx context.Context, db *Database, id int) (ItemLoan, error) {
+    defer observe("ReturnLoan", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return ItemLoan{}, err
+    }
+    defer tx.Rollback()
+
+    // Lock the item before the loan, in the same order as LendItem.
+    var itemID int
+    if err := tx.QueryRowContext(ctx, `SELECT item_id FROM item_loan WHERE id = ?`, id).Scan(&itemID); err != nil {
+        return ItemLoan{}, err
+    }
+    var qty int
+    if err := tx.QueryRowContext(ctx, `SELECT itemQTY FROM inventory_item WHERE id = ? FOR UPDATE`, itemID).Scan(&qty); err != nil {
+        return ItemLoan{}, err
+    }
+    var units int
+    var returned sql.NullTime
+    err = tx.QueryRowContext(ctx, `SELECT quantity, returned_date FROM item_loan WHERE id = ? FOR UPDATE`, id).Scan(&units, &returned)
+    if err != nil {
+        return ItemLoan{}, err
+    }
+    if returned.Valid {
+        return ItemLoan{}, fmt.Errorf("loan %d was already returned on %s", id, returned.Time.Format(time.DateOnly))
+    }
+
+    if _, err := tx.ExecContext(ctx, `UPDATE item_loan SET returned_date = NOW() WHERE id = ?`, id); err != nil {
+        return ItemLoan{}, err
+    }
+    lent, err := lentQuantity(ctx, tx, itemID)
+    if err != nil {
+        return ItemLoan{}, err
+    }
+    if err := recordItemHistory(ctx, tx, int64(itemID), HistoryReturned, units, qty-lent); err != nil {
+        return ItemLoan{}, err
+    }
+    if err := tx.Commit(); err != nil {
+        return ItemLoan{}, err
+    }
+
+    loan, err := GetLoan(ctx, db, id)
+    if err != nil {
+        return ItemLoan{}, err
+    }
+    slog.InfoContext(ctx, "item returned", "item", loan.ItemName, "borrower", loan.Borrower, "qty", loan.Quantity)
+    return loan, nil
+}
+
+// GetBorrowers returns the names of recent borrowers, most recent first, for suggestions.
+func GetBorrowers(ctx context.Context, db *Database) ([]string, error) {
+    defer observe("GetBorrowers", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT borrower FROM item_loan GROUP BY borrower ORDER BY MAX(lent_date) DESC LIMIT ?
+    `, maxBorrowers)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    borrowers := []string{}
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        borrowers = append(borrowers, name)
+    }
+    return borrowers, rows.Err()
+}
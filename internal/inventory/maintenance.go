@@ -0,0 +1,466 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// Interval units, as stored in maintenance_task.every_unit.
+const (
+    IntervalDay   = "day"
+    IntervalWeek  = "week"
+    IntervalMonth = "month"
+    IntervalYear  = "year"
+)
+
+// Kinds of MaintenanceDue entries.
+const (
+    MaintenanceDueTask     = "task"
+    MaintenanceDueWarranty = "warranty"
+)
+
+// intervalUnits maps the unit spellings ParseInterval accepts to the stored unit.
+var intervalUnits = map[string]string{
+    "d": IntervalDay, "day": IntervalDay, "days": IntervalDay,
+    "w": IntervalWeek, "week": IntervalWeek, "weeks": IntervalWeek,
+    "m": IntervalMonth, "month": IntervalMonth, "months": IntervalMonth,
+    "y": IntervalYear, "year": IntervalYear, "years": IntervalYear,
+}
+
+// intervalWords are the single-word intervals ParseInterval accepts.
+var intervalWords = map[string]Interval{
+    "daily":    {1, IntervalDay},
+    "weekly":   {1, IntervalWeek},
+    "monthly":  {1, IntervalMonth},
+    "yearly":   {1, IntervalYear},
+    "annually": {1, IntervalYear},
+}
+
+// maxIntervalCount bounds Interval.Count so next due dates stay within the DATE range.
+const maxIntervalCount = 1000
+
+// ParseInterval parses an interval such as "90d", "2 weeks", "6m", "1y", "every month"
+// or "monthly".
+// An empty string or "once" is the zero Interval: the task does not recur.
+func ParseInterval(s string) (Interval, error) {
+    s = strings.ToLower(strings.TrimSpace(s))
+    if s == "" || s == "once" {
+        return Interval{}, nil
+    }
+    if every, ok := intervalWords[s]; ok {
+        return every, nil
+    }
+    s = strings.TrimPrefix(s, "every ")
+    digits := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+    count := 1 // "every month"
+    var err error
+    if digits != "" {
+        count, err = strconv.Atoi(digits)
+    }
+    unit, ok := intervalUnits[strings.TrimSpace(s[len(digits):])]
+    if err != nil || !ok {
+        return Interval{}, fmt.Errorf("invalid interval %q: use e.g. 90d, 2w, 6m, 1y or monthly", s)
+    }
+    every := Interval{Count: count, Unit: unit}
+    return every, every.validate()
+}
+
+// validate checks a recurring interval; the zero Interval is valid.
+func (every Interval) validate() error {
+    if every == (Interval{}) {
+        return nil
+    }
+    switch every.Unit {
+    case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+    default:
+        return fmt.Errorf("invalid interval unit %q", every.Unit)
+    }
+    if every.Count < 1 || every.Count > maxIntervalCount {
+        return fmt.Errorf("interval must be between 1 and %d %ss", maxIntervalCount, every.Unit)
+    }
+    return nil
+}
+
+// String formats the interval as "every 90 days", "every month" or "once".
+func (every Interval) String() string {
+    switch {
+    case every.Count == 0:
+        return "once"
+    case every.Count == 1:
+        return "every " + every.Unit
+    default:
+        return fmt.Sprintf("every %d %ss", every.Count, every.Unit)
+    }
+}
+
+// After returns the date one interval after day. Adding months or years keeps the day
+// of the month where it exists and otherwise uses the month's last day, so a task due on
+// January 31 is next due on February 28 (or 29), not in March.
+func (every Interval) After(day time.Time) time.Time {
+    switch every.Unit {
+    case IntervalDay:
+        return day.AddDate(0, 0, every.Count)
+    case IntervalWeek:
+        return day.AddDate(0, 0, 7*every.Count)
+    case IntervalMonth, IntervalYear:
+        months := every.Count
+        if every.Unit == IntervalYear {
+            months *= 12
+        }
+        first := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, day.Location()).AddDate(0, months, 0)
+        lastDay := first.AddDate(0, 1, -1).Day()
+        return time.Date(first.Year(), first.Month(), min(day.Day(), lastDay), 0, 0, 0, 0, day.Location())
+    }
+    return day
+}
+
+// maintenanceColumns selects a maintenance_task row for scanMaintenanceTask.
+const maintenanceColumns = `
+    t.id, t.item_id, i.item_name, t.name, t.every_count, t.every_unit, t.next_due,
+    (SELECT MAX(g.done_date) FROM maintenance_log g WHERE g.task_id = t.id),
+    COALESCE(t.consume_item_id, 0), COALESCE(c.item_name, ''), t.consume_units`
+
+// maintenanceFrom joins the tables maintenanceColumns reads.
+const maintenanceFrom = `
+    FROM maintenance_task t
+    JOIN inventory_item i ON i.id = t.item_id
+    LEFT JOIN inventory_item c ON c.id = t.consume_item_id`
+
+// scanMaintenanceTask reads the columns selected by maintenanceColumns.
+func scanMaintenanceTask(row interface{ Scan(...interface{}) error }) (MaintenanceTask, error) {
+    var t MaintenanceTask
+    var nextDue, lastDone sql.NullTime
+    err := row.Scan(&t.ID, &t.ItemID, &t.ItemName, &t.Name, &t.Every.Count, &t.Every.Unit, &nextDue,
+        &lastDone, &t.ConsumeItemID, &t.ConsumeItemName, &t.ConsumeUnits)
+    if err != nil {
+        return MaintenanceTask{}, err
+    }
+    if nextDue.Valid {
+        t.NextDue = &nextDue.Time
+    }
+    if lastDone.Valid {
+        t.LastDone = &lastDone.Time
+    }
+    return t, nil
+}
+
+// GetMaintenanceTasks retrieves an item's maintenance tasks, or every task when itemID is
+// 0, soonest due first and finished one-off tasks last.
+func GetMaintenanceTasks(ctx context.Context, db *Database, itemID int) ([]MaintenanceTask, error) {
+    defer observe("GetMaintenanceTasks", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT`+maintenanceColumns+maintenanceFrom+`
+        WHERE ? = 0 OR t.item_id = ?
+        ORDER BY t.next_due IS NULL, t.next_due ASC, i.item_name ASC, t.name ASC
+    `, itemID, itemID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    tasks := []MaintenanceTask{}
+    for rows.Next() {
+        t, err := scanMaintenanceTask(rows)
+        if err != nil {
+            return nil, err
+        }
+        tasks = append(tasks, t)
+    }
+    return tasks, rows.Err()
+}
+
+// GetMaintenanceTask retrieves one maintenance task.
+func GetMaintenanceTask(ctx context.Context, db *Database, id int) (MaintenanceTask, error) {
+    defer observe("GetMaintenanceTask", time.Now())
+    return scanMaintenanceTask(db.conn.QueryRowContext(ctx, `SELECT`+maintenanceColumns+maintenanceFrom+` WHERE t.id = ?`, id))
+}
+
+// validateMaintenanceTask normalizes a task before it is stored. A recurring task with no
+// due date is first due one interval from today.
+func validateMaintenanceTask(ctx context.Context, tx *sql.Tx, t *MaintenanceTask) error {
+    t.Name = strings.TrimSpace(t.Name)
+    if t.Name == "" || utf8.RuneCountInString(t.Name) > 128 {
+        return fmt.Errorf("task name must be 1 to 128 characters")
+    }
+    if err := t.Every.validate(); err != nil {
+        return err
+    }
+    if t.NextDue == nil {
+        if t.Every.Count == 0 {
+            return fmt.Errorf("a one-off task needs a due date")
+        }
+        due := t.Every.After(Today())
+        t.NextDue = &due
+    }
+    if t.ConsumeItemID == 0 {
+        t.ConsumeUnits = 0
+        return nil
+    }
+    if t.ConsumeUnits < 1 {
+        t.ConsumeUnits = 1
+    }
+    var name string
+    err := tx.QueryRowContext(ctx, `SELECT item_name FROM inventory_item WHERE id = ?`, t.ConsumeItemID).Scan(&name)
+    if errors.Is(err, sql.ErrNoRows) {
+        return fmt.Errorf("no consumable item with ID %d", t.ConsumeItemID)
+    }
+    return err
+}
+
+// AddMaintenanceTask schedules a task on an item. It returns sql.ErrNoRows when the item
+// does not exist.
+func AddMaintenanceTask(ctx context.Context, db *Database, t MaintenanceTask) (int64, error) {
+    defer observe("AddMaintenanceTask", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    var id int
+    if err := tx.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE id = ?`, t.ItemID).Scan(&id); err != nil {
+        return 0, err
+    }
+    if err := validateMaintenanceTask(ctx, tx, &t); err != nil {
+        return 0, err
+    }
+    res, err := tx.ExecContext(ctx, `
+        INSERT INTO maintenance_task (item_id, name, every_count, every_unit, next_due, consume_item_id, consume_units)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, t.ItemID, t.Name, t.Every.Count, t.Every.Unit, nullDate(t.NextDue), nullID(t.ConsumeItemID), t.ConsumeUnits)
+    if err != nil {
+        return 0, err
+    }
+    taskID, err := res.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+    return taskID, tx.Commit()
+}
+
+// UpdateMaintenanceTask changes a task's name, interval, next due date and consumable.
+// It returns sql.ErrNoRows when the task does not exist.
+func UpdateMaintenanceTask(ctx context.Context, db *Database, t MaintenanceTask) error {
+    defer observe("UpdateMaintenanceTask", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var id int
+    if err := tx.QueryRowContext(ctx, `SELECT id FROM maintenance_task WHERE id = ? FOR UPDATE`, t.ID).Scan(&id); err != nil {
+        return err
+    }
+    if err := validateMaintenanceTask(ctx, tx, &t); err != nil {
+        return err
+    }
+    _, err = tx.ExecContext(ctx, `
+        UPDATE maintenance_task
+        SET name = ?, every_count = ?, every_unit = ?, next_due = ?, consume_item_id = ?, consume_units = ?
+        WHERE id = ?
+    `, t.Name, t.Every.Count, t.Every.Unit, nullDate(t.NextDue), nullID(t.ConsumeItemID), t.ConsumeUnits, t.ID)
+    if err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// DeleteMaintenanceTask removes a task. Its log entries are kept. It returns
+// sql.ErrNoRows when the task does not exist.
+func DeleteMaintenanceTask(ctx context.Context, db *Database, id int) error {
+    defer observe("DeleteMaintenanceTask", time.Now())
+    res, err := db.conn.ExecContext(ctx, `DELETE FROM maintenance_task WHERE id = ?`, id)
+    if err != nil {
+        return err
+    }
+    if n, err := res.RowsAffected(); err == nil && n == 0 {
+        return sql.ErrNoRows
+    }
+    return err
+}
+
+// CompleteMaintenanceTask logs that a task was done and schedules its next occurrence one
+// interval after the completion date; a one-off task is no longer due. Unless skipped,
+// the task's consumable is used up like a "-" stock change, as far as it is in stock.
+// It returns sql.ErrNoRows when the task does not exist.
+func CompleteMaintenanceTask(ctx context.Context, db *Database, id int, done MaintenanceCompletion) (MaintenanceLogEntry, error) {
+    defer observe("CompleteMaintenanceTask", time.Now())
+    done.Note = strings.TrimSpace(done.Note)
+    if utf8.RuneCountInString(done.Note) > 255 {
+        return MaintenanceLogEntry{}, fmt.Errorf("note must be at most 255 characters")
+    }
+    today := Today()
+    if done.DoneDate.IsZero() {
+        done.DoneDate = today
+    }
+    if DaysBetween(today, done.DoneDate) > 0 {
+        return MaintenanceLogEntry{}, fmt.Errorf("completion date cannot be in the future")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return MaintenanceLogEntry{}, err
+    }
+    defer tx.Rollback()
+
+    var task MaintenanceTask
+    var nextDue sql.NullTime
+    err = tx.QueryRowContext(ctx, `
+        SELECT t.id, t.item_id, i.item_name, t.name, t.every_count, t.every_unit, t.next_due,
+            COALESCE(t.consume_item_id, 0), t.consume_units
+        FROM maintenance_task t
+        JOIN inventory_item i ON i.id = t.item_id
+        WHERE t.id = ?
+        FOR UPDATE
+    `, id).Scan(&task.ID, &task.ItemID, &task.ItemName, &task.Name, &task.Every.Count, &task.Every.Unit, &nextDue,
+        &task.ConsumeItemID, &task.ConsumeUnits)
+    if err != nil {
+        return MaintenanceLogEntry{}, err
+    }
+
+    entry := MaintenanceLogEntry{TaskID: task.ID, ItemID: task.ItemID, ItemName: task.ItemName, TaskName: task.Name, DoneDate: done.DoneDate, Note: done.Note}
+    if nextDue.Valid {
+        entry.DueDate = &nextDue.Time
+    }
+    if task.ConsumeItemID != 0 && !done.SkipConsume {
+        if entry.ConsumedItemName, entry.ConsumedUnits, err = consumeUnits(ctx, tx, task.ConsumeItemID, task.ConsumeUnits); err != nil {
+            return MaintenanceLogEntry{}, err
+        }
+    }
+
+    var next interface{}
+    if task.Every.Count > 0 {
+        next = task.Every.After(done.DoneDate).Format(time.DateOnly)
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE maintenance_task SET next_due = ? WHERE id = ?`, next, task.ID); err != nil {
+        return MaintenanceLogEntry{}, err
+    }
+    res, err := tx.ExecContext(ctx, `
+        INSERT INTO maintenance_log (task_id, item_id, item_name, task_name, due_date, done_date, note, consumed_item_name, consumed_units)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, task.ID, task.ItemID, task.ItemName, task.Name, nullDate(entry.DueDate), done.DoneDate.Format(time.DateOnly), entry.Note,
+        entry.ConsumedItemName, entry.ConsumedUnits)
+    if err != nil {
+        return MaintenanceLogEntry{}, err
+    }
+    logID, err := res.LastInsertId()
+    if err != nil {
+        return MaintenanceLogEntry{}, err
+    }
+    entry.ID = int(logID)
+    return entry, tx.Commit()
+}
+
+// consumeUnits uses up to units of an item inside tx, as many as are on hand and not lent
+// out, and records the change in the item's history. It returns the item's name and the
+// number of units used.
+func consumeUnits(ctx context.Context, tx *sql.Tx, itemID, units int) (string, int, error) {
+    var name string
+    var qty int
+    err := tx.QueryRowContext(ctx, `SELECT item_name, itemQTY FROM inventory_item WHERE id = ? FOR UPDATE`, itemID).Scan(&name, &qty)
+    if errors.Is(err, sql.ErrNoRows) {
+        // The consumable was deleted since the task was loaded.
+        return "", 0, nil
+    }
+    if err != nil {
+        return "", 0, err
+    }
+    lent, err := lentQuantity(ctx, tx, itemID)
+    if err != nil {
+        return "", 0, err
+    }
+    units = min(units, max(qty-lent, 0))
+    if units == 0 {
+        return name, 0, nil
+    }
+    _, err = tx.ExecContext(ctx, `
+        UPDATE inventory_item
+        SET itemQTY = itemQTY - ?, itemUsedToDate = itemUsedToDate + ?, lastModifiedDate = NOW()
+        WHERE id = ?
+    `, units, units, itemID)
+    if err != nil {
+        return "", 0, err
+    }
+    if err := removeItemExpirationXref(ctx, tx, int64(itemID), units); err != nil {
+        return "", 0, err
+    }
+    if err := recordItemHistory(ctx, tx, int64(itemID), HistoryUsed, units, qty-units); err != nil {
+        return "", 0, err
+    }
+    return name, units, nil
+}
+
+// GetMaintenanceLog retrieves an item's most recent task completions, or every item's
+// when itemID is 0, newest first. Entries for deleted items are kept.
+func GetMaintenanceLog(ctx context.Context, db *Database, itemID int, limit int) ([]MaintenanceLogEntry, error) {
+    defer observe("GetMaintenanceLog", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT id, COALESCE(task_id, 0), item_id, item_name, task_name, due_date, done_date, note, consumed_item_name, consumed_units
+        FROM maintenance_log
+        WHERE ? = 0 OR item_id = ?
+        ORDER BY done_date DESC, id DESC
+        LIMIT ?
+    `, itemID, itemID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    entries := []MaintenanceLogEntry{}
+    for rows.Next() {
+        var e MaintenanceLogEntry
+        var due sql.NullTime
+        if err := rows.Scan(&e.ID, &e.TaskID, &e.ItemID, &e.ItemName, &e.TaskName, &due, &e.DoneDate, &e.Note,
+            &e.ConsumedItemName, &e.ConsumedUnits); err != nil {
+            return nil, err
+        }
+        if due.Valid {
+            e.DueDate = &due.Time
+        }
+        entries = append(entries, e)
+    }
+    return entries, rows.Err()
+}
+
+// GetDueMaintenance lists the tasks due within the given number of days, including
+// overdue ones, and the asset warranties ending in that time, soonest first.
+func GetDueMaintenance(ctx context.Context, db *Database, days int) ([]MaintenanceDue, error) {
+    defer observe("GetDueMaintenance", time.Now())
+    today := Today()
+    date := today.Format(time.DateOnly)
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT ? AS kind, t.id, t.item_id, i.item_name, t.name, t.next_due AS due
+        FROM maintenance_task t
+        JOIN inventory_item i ON i.id = t.item_id
+        WHERE t.next_due < DATE_ADD(?, INTERVAL ? + 1 DAY)
+        UNION ALL
+        SELECT ?, 0, d.item_id, i.item_name, 'Warranty ends', d.warranty_expiry AS due
+        FROM item_asset d
+        JOIN inventory_item i ON i.id = d.item_id
+        WHERE d.warranty_expiry >= ? AND d.warranty_expiry < DATE_ADD(?, INTERVAL ? + 1 DAY)
+        ORDER BY due ASC, item_name ASC, name ASC
+    `, MaintenanceDueTask, date, days, MaintenanceDueWarranty, date, date, days)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    due := []MaintenanceDue{}
+    for rows.Next() {
+        var d MaintenanceDue
+        if err := rows.Scan(&d.Kind, &d.TaskID, &d.ItemID, &d.ItemName, &d.Name, &d.DueDate); err != nil {
+            return nil, err
+        }
+        d.DaysLeft = DaysBetween(today, d.DueDate)
+        d.Overdue = d.DaysLeft < 0
+        due = append(due, d)
+    }
+    return due, rows.Err()
+}
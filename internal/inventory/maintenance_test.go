@@ -0,0 +1,112 @@
+package inventory
+
+import (
+    "testing"
+    "time"
+)
+
+func TestParseInterval(t *testing.T) {
+    tests := []struct {
+        in   string
+        want Interval
+    }{
+        {"", Interval{}},
+        {"once", Interval{}},
+        {" Once ", Interval{}},
+        {"90d", Interval{90, IntervalDay}},
+        {"90 days", Interval{90, IntervalDay}},
+        {"1 day", Interval{1, IntervalDay}},
+        {"2w", Interval{2, IntervalWeek}},
+        {"2 weeks", Interval{2, IntervalWeek}},
+        {"6m", Interval{6, IntervalMonth}},
+        {"6 Months", Interval{6, IntervalMonth}},
+        {"1y", Interval{1, IntervalYear}},
+        {"every month", Interval{1, IntervalMonth}},
+        {"month", Interval{1, IntervalMonth}},
+        {"every 3 weeks", Interval{3, IntervalWeek}},
+        {"daily", Interval{1, IntervalDay}},
+        {"weekly", Interval{1, IntervalWeek}},
+        {"monthly", Interval{1, IntervalMonth}},
+        {"yearly", Interval{1, IntervalYear}},
+        {"annually", Interval{1, IntervalYear}},
+        {"1000d", Interval{1000, IntervalDay}},
+    }
+    for _, tt := range tests {
+        got, err := ParseInterval(tt.in)
+        if err != nil {
+            t.Errorf("ParseInterval(%q): %v", tt.in, err)
+            continue
+        }
+        if got != tt.want {
+            t.Errorf("ParseInterval(%q) = %+v, want %+v", tt.in, got, tt.want)
+        }
+    }
+
+    for _, in := range []string{"0d", "1001d", "5", "5 fortnights", "every", "-3d", "3.5m", "99999999999999999999d"} {
+        if got, err := ParseInterval(in); err == nil {
+            t.Errorf("ParseInterval(%q) = %+v, want an error", in, got)
+        }
+    }
+}
+
+func TestIntervalString(t *testing.T) {
+    tests := []struct {
+        every Interval
+        want  string
+    }{
+        {Interval{}, "once"},
+        {Interval{1, IntervalMonth}, "every month"},
+        {Interval{90, IntervalDay}, "every 90 days"},
+    }
+    for _, tt := range tests {
+        if got := tt.every.String(); got != tt.want {
+            t.Errorf("%+v.String() = %q, want %q", tt.every, got, tt.want)
+        }
+    }
+}
+
+func TestIntervalAfter(t *testing.T) {
+    date := func(s string) time.Time {
+        d, err := time.Parse(time.DateOnly, s)
+        if err != nil {
+            t.Fatal(err)
+        }
+        return d
+    }
+    tests := []struct {
+        every      Interval
+        from, want string
+    }{
+        {Interval{90, IntervalDay}, "2024-01-01", "2024-03-31"},
+        {Interval{1, IntervalDay}, "2024-12-31", "2025-01-01"},
+        {Interval{2, IntervalWeek}, "2024-02-20", "2024-03-05"},
+        {Interval{1, IntervalMonth}, "2024-03-15", "2024-04-15"},
+        // Month ends clamp to the last day of a shorter month.
+        {Interval{1, IntervalMonth}, "2024-01-31", "2024-02-29"},
+        {Interval{1, IntervalMonth}, "2023-01-31", "2023-02-28"},
+        {Interval{1, IntervalMonth}, "2024-03-31", "2024-04-30"},
+        {Interval{1, IntervalMonth}, "2024-08-31", "2024-09-30"},
+        {Interval{2, IntervalMonth}, "2024-12-31", "2025-02-28"},
+        {Interval{13, IntervalMonth}, "2024-01-31", "2025-02-28"},
+        // After works from the date it is given, so a clamped due date stays on that day.
+        {Interval{1, IntervalMonth}, "2024-02-29", "2024-03-29"},
+        {Interval{1, IntervalYear}, "2024-02-29", "2025-02-28"},
+        {Interval{4, IntervalYear}, "2024-02-29", "2028-02-29"},
+        {Interval{1, IntervalYear}, "2023-06-30", "2024-06-30"},
+        {Interval{}, "2024-05-05", "2024-05-05"},
+    }
+    for _, tt := range tests {
+        if got := tt.every.After(date(tt.from)).Format(time.DateOnly); got != tt.want {
+            t.Errorf("%v after %s = %s, want %s", tt.every, tt.from, got, tt.want)
+        }
+    }
+}
+
+// After keeps the location of its argument, so a local date stays at local midnight.
+func TestIntervalAfterKeepsLocation(t *testing.T) {
+    loc := time.FixedZone("UTC+10", 10*60*60)
+    got := Interval{1, IntervalMonth}.After(time.Date(2024, 1, 31, 0, 0, 0, 0, loc))
+    if want := time.Date(2024, 2, 29, 0, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+        t.Errorf("got %v, want %v", got, want)
+    }
+}
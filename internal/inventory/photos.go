@@ -0,0 +1,122 @@
+package inventory
+
+import (
+    "context"
+    "fmt"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// CheckPhotoCaption reports whether caption fits in a photo record.
+func CheckPhotoCaption(caption string) error {
+    if utf8.RuneCountInString(strings.TrimSpace(caption)) > 255 {
+        return fmt.Errorf("caption must be at most 255 characters")
+    }
+    return nil
+}
+
+// AddItemPhoto records a stored photo against an item and returns the record's ID.
+// Attaching the same image to an item twice only updates its caption. It returns
+// sql.ErrNoRows when the item does not exist.
+func AddItemPhoto(ctx context.Context, db *Database, photo ItemPhoto) (int64, error) {
+    defer observe("AddItemPhoto", time.Now())
+    photo.Caption = strings.TrimSpace(photo.Caption)
+    if err := CheckPhotoCaption(photo.Caption); err != nil {
+        return 0, err
+    }
+
+    var id int
+    if err := db.conn.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE id = ?`, photo.ItemID).Scan(&id); err != nil {
+        return 0, err
+    }
+
+    // LAST_INSERT_ID(id) makes LastInsertId return the existing record's ID too.
+    result, err := db.conn.ExecContext(ctx, `
+        INSERT INTO item_photo (item_id, file_name, content_type, width, height, size_bytes, caption)
+        VALUES (?, ?, ?, ?, ?, ?, ?)
+        ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id), caption = IF(VALUES(caption) = '', caption, VALUES(caption))
+    `, photo.ItemID, photo.FileName, photo.ContentType, photo.Width, photo.Height, photo.SizeBytes, photo.Caption)
+    if err != nil {
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// GetItemPhotos retrieves an item's photos, oldest first.
+func GetItemPhotos(ctx context.Context, db *Database, itemID int) ([]ItemPhoto, error) {
+    defer observe("GetItemPhotos", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT id, item_id, file_name, content_type, width, height, size_bytes, caption, createDate
+        FROM item_photo
+        WHERE item_id = ?
+        ORDER BY createDate ASC, id ASC
+    `, itemID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    photos := []ItemPhoto{}
+    for rows.Next() {
+        var p ItemPhoto
+        if err := rows.Scan(&p.ID, &p.ItemID, &p.FileName, &p.ContentType, &p.Width, &p.Height, &p.SizeBytes, &p.Caption, &p.CreateDate); err != nil {
+            return nil, err
+        }
+        photos = append(photos, p)
+    }
+    return photos, rows.Err()
+}
+
+// DeleteItemPhoto removes a photo from its item and returns it, reporting whether its
+// file is now unused by any other item and can be removed from disk. It returns
+// sql.ErrNoRows when the photo does not exist.
+func DeleteItemPhoto(ctx context.Context, db *Database, id int) (ItemPhoto, bool, error) {
+    defer observe("DeleteItemPhoto", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return ItemPhoto{}, false, err
+    }
+    defer tx.Rollback()
+
+    var p ItemPhoto
+    err = tx.QueryRowContext(ctx, `
+        SELECT id, item_id, file_name, content_type, width, height, size_bytes, caption, createDate
+        FROM item_photo WHERE id = ? FOR UPDATE
+    `, id).Scan(&p.ID, &p.ItemID, &p.FileName, &p.ContentType, &p.Width, &p.Height, &p.SizeBytes, &p.Caption, &p.CreateDate)
+    if err != nil {
+        return ItemPhoto{}, false, err
+    }
+    if _, err := tx.ExecContext(ctx, `DELETE FROM item_photo WHERE id = ?`, id); err != nil {
+        return ItemPhoto{}, false, err
+    }
+    var others int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM item_photo WHERE file_name = ?`, p.FileName).Scan(&others); err != nil {
+        return ItemPhoto{}, false, err
+    }
+    if err := tx.Commit(); err != nil {
+        return ItemPhoto{}, false, err
+    }
+    return p, others == 0, nil
+}
+
+// PhotoFileNames returns the names of every photo file still attached to an item, so
+// files left behind by deleted items can be pruned.
+func PhotoFileNames(ctx context.Context, db *Database) (map[string]bool, error) {
+    defer observe("PhotoFileNames", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `SELECT DISTINCT file_name FROM item_photo`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    names := map[string]bool{}
+    for rows.Next() {
+        var name string
+        if err := rows.Scan(&name); err != nil {
+            return nil, err
+        }
+        names[name] = true
+    }
+    return names, rows.Err()
+}
@@ -0,0 +1,177 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Scan modes used by rapid scan mode.
+const (
+    ScanModeIn  = "in"
+    ScanModeOut = "out"
+)
+
+// scanModeCodes maps printable command barcodes to the scan mode they switch to.
+var scanModeCodes = map[string]string{
+    "STOCK":   ScanModeIn,
+    "IN":      ScanModeIn,
+    "CONSUME": ScanModeOut,
+    "OUT":     ScanModeOut,
+}
+
+// ScanModeForCode returns the scan mode a command barcode switches to, if the code is one.
+func ScanModeForCode(code string) (string, bool) {
+    mode, ok := scanModeCodes[strings.ToUpper(strings.TrimSpace(code))]
+    return mode, ok
+}
+
+// ProcessScan applies a single scan in the given mode. Scans in "in" mode add one unit
+// of the item the barcode is assigned to, "out" mode uses one. Command barcodes switch
+// modes without touching inventory, and unknown barcodes are queued for later enrichment.
+func ProcessScan(ctx context.Context, db *Database, code string, mode string) (ScanResult, error) {
+    defer observe("ProcessScan", time.Now())
+    if newMode, ok := ScanModeForCode(code); ok {
+        return ScanResult{
+            Status:  "mode",
+            Mode:    newMode,
+            Message: fmt.Sprintf("Switched to %s mode", scanModeLabel(newMode)),
+        }, nil
+    }
+
+    if mode != ScanModeIn && mode != ScanModeOut {
+        return ScanResult{}, fmt.Errorf("invalid scan mode: must be %s or %s", ScanModeIn, ScanModeOut)
+    }
+
+    barcode, err := NormalizeBarcode(code)
+    if err != nil {
+        return ScanResult{Status: "error", Mode: mode, Barcode: code, Message: err.Error()}, nil
+    }
+
+    _, itemName, err := FindItemByBarcode(ctx, db, barcode)
+    if errors.Is(err, sql.ErrNoRows) {
+        if err := QueueUnknownScan(ctx, db, barcode, mode); err != nil {
+            return ScanResult{}, err
+        }
+        message := "Unknown barcode queued for later"
+        if product, err := GetCatalogProduct(ctx, db, barcode); err == nil {
+            message = fmt.Sprintf("Not in inventory yet (catalog: %s), queued for later", product.ProductName)
+        }
+        return ScanResult{Status: "queued", Mode: mode, Barcode: barcode, Message: message}, nil
+    }
+    if err != nil {
+        return ScanResult{}, err
+    }
+
+    item, err := AdjustItemQtyByBarcode(ctx, db, barcode, scanModeAction(mode))
+    if err != nil {
+        return ScanResult{Status: "error", Mode: mode, Barcode: barcode, Message: err.Error()}, nil
+    }
+
+    verb := "Added"
+    if mode == ScanModeOut {
+        verb = "Used"
+    }
+    return ScanResult{
+        Status:  mode,
+        Mode:    mode,
+        Barcode: barcode,
+        Message: fmt.Sprintf("%s one %s (now %v)", verb, itemName, item["itemQTY"]),
+        Item:    item,
+    }, nil
+}
+
+// AdjustItemQtyByBarcode is AdjustItemQty addressed by barcode instead of item ID.
+// Using an item with no units on hand, or with every unit lent out, is rejected.
+func AdjustItemQtyByBarcode(ctx context.Context, db *Database, code string, action string) (map[string]interface{}, error) {
+    defer observe("AdjustItemQtyByBarcode", time.Now())
+    itemID, _, err := FindItemByBarcode(ctx, db, code)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, fmt.Errorf("barcode %s is not assigned to any item", code)
+    }
+    if err != nil {
+        return nil, err
+    }
+    return AdjustItemQty(ctx, db, itemID, action)
+}
+
+// QueueUnknownScan records a barcode that is not assigned to any item, bumping its
+// scan count if it is already queued.
+func QueueUnknownScan(ctx context.Context, db *Database, barcode string, mode string) error {
+    defer observe("QueueUnknownScan", time.Now())
+    _, err := db.conn.ExecContext(ctx, `
+        INSERT INTO scan_queue (barcode, scan_mode, scan_count, first_scanned, last_scanned)
+        VALUES (?, ?, 1, NOW(), NOW())
+        ON DUPLICATE KEY UPDATE
+            scan_mode = VALUES(scan_mode),
+            scan_count = scan_count + 1,
+            last_scanned = NOW()
+    `, barcode, mode)
+    return err
+}
+
+// GetQueuedScans retrieves unknown barcodes waiting to be assigned, most recent first,
+// with any matching product catalog entry.
+func GetQueuedScans(ctx context.Context, db *Database) ([]QueuedScan, error) {
+    defer observe("GetQueuedScans", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT q.id, q.barcode, q.scan_mode, q.scan_count, q.first_scanned, q.last_scanned,
+            p.id, p.product_name, p.brand, p.category, p.quantity_label
+        FROM scan_queue q
+        LEFT JOIN product_catalog p ON p.barcode = q.barcode
+        ORDER BY q.last_scanned DESC
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    scans := []QueuedScan{}
+    for rows.Next() {
+        var s QueuedScan
+        var productID sql.NullInt64
+        var productName, brand, category, quantityLabel sql.NullString
+        if err := rows.Scan(&s.ID, &s.Barcode, &s.ScanMode, &s.ScanCount, &s.FirstScanned, &s.LastScanned,
+            &productID, &productName, &brand, &category, &quantityLabel); err != nil {
+            return nil, err
+        }
+        if productID.Valid {
+            s.Product = &CatalogProduct{
+                ID:            int(productID.Int64),
+                Barcode:       s.Barcode,
+                ProductName:   productName.String,
+                Brand:         brand.String,
+                Category:      category.String,
+                QuantityLabel: quantityLabel.String,
+            }
+        }
+        scans = append(scans, s)
+    }
+    return scans, rows.Err()
+}
+
+// RemoveQueuedScan deletes a barcode from the unknown scan queue.
+func RemoveQueuedScan(ctx context.Context, db *Database, barcode string) error {
+    defer observe("RemoveQueuedScan", time.Now())
+    _, err := db.conn.ExecContext(ctx, `DELETE FROM scan_queue WHERE barcode = ?`, barcode)
+    return err
+}
+
+// scanModeAction maps a scan mode to the AdjustItemQty action it performs.
+func scanModeAction(mode string) string {
+    if mode == ScanModeOut {
+        return "-"
+    }
+    return "+"
+}
+
+// scanModeLabel returns a human readable name for a scan mode.
+func scanModeLabel(mode string) string {
+    if mode == ScanModeOut {
+        return "stock-out"
+    }
+    return "stock-in"
+}
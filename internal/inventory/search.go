@@ -0,0 +1,375 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log/slog"
+    "sort"
+    "strings"
+    "time"
+    "unicode"
+    "unicode/utf8"
+)
+
+// defaultSearchLimit is how many results SearchItems returns when no limit is given.
+const defaultSearchLimit = 20
+
+// execer is the database or a transaction.
+type execer interface {
+    ExecContext(context.Context, string, ...interface{}) (sql.Result, error)
+}
+
+// itemSearchDocuments selects each item's ID and the text search matches against: its
+// name, aliases, barcodes, tags, note and, for durable goods, manufacturer, model and
+// serial number. Callers may append a WHERE clause on i.
+const itemSearchDocuments = `
+        SELECT i.id, CONCAT_WS(' ', i.item_name,
+            (SELECT GROUP_CONCAT(a.alias SEPARATOR ' ') FROM item_alias a WHERE a.item_id = i.id),
+            (SELECT GROUP_CONCAT(b.barcode SEPARATOR ' ') FROM item_barcode b WHERE b.item_id = i.id),
+            (SELECT GROUP_CONCAT(g.tag_name SEPARATOR ' ')
+                FROM item_tag_xref x JOIN item_tag g ON g.id = x.tag_id WHERE x.item_id = i.id),
+            (SELECT n.note FROM item_note n WHERE n.item_id = i.id),
+            (SELECT CONCAT_WS(' ', d.manufacturer, d.model, d.serial_number) FROM item_asset d WHERE d.item_id = i.id))
+        FROM inventory_item i`
+
+// refreshItemSearch rewrites an item's row in item_search after its name, aliases,
+// barcodes, tags, note or asset details change. q is the database or the transaction the change was made in.
+func refreshItemSearch(ctx context.Context, q execer, itemID int64) error {
+    _, err := q.ExecContext(ctx, `REPLACE INTO item_search (item_id, search_text)`+itemSearchDocuments+` WHERE i.id = ?`, itemID)
+    return err
+}
+
+// rebuildItemSearch rewrites every row of item_search.
+func rebuildItemSearch(ctx context.Context, q execer) error {
+    if _, err := q.ExecContext(ctx, `DELETE FROM item_search`); err != nil {
+        return err
+    }
+    _, err := q.ExecContext(ctx, `INSERT INTO item_search (item_id, search_text)`+itemSearchDocuments)
+    return err
+}
+
+// RebuildSearchIndex regenerates the search index from the item tables, picking up
+// items written before the index existed or changed outside the application.
+func RebuildSearchIndex(ctx context.Context, db *Database) error {
+    defer observe("RebuildSearchIndex", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if err := rebuildItemSearch(ctx, tx); err != nil {
+        return err
+    }
+    return tx.Commit()
+}
+
+// SearchItems finds items whose name, aliases, barcodes, tags or note match text, best
+// match first. It uses the FULLTEXT index, matching each word as a prefix. When that
+// finds nothing, or the database cannot run the FULLTEXT query, it falls back to
+// typo-tolerant matching in Go, so "chikpeas" still finds "Chickpeas".
+func SearchItems(ctx context.Context, db *Database, text string, limit int) ([]SearchResult, error) {
+    defer observe("SearchItems", time.Now())
+    terms := searchTerms(text)
+    if len(terms) == 0 {
+        return []SearchResult{}, nil
+    }
+    if limit <= 0 {
+        limit = defaultSearchLimit
+    }
+
+    ranked, err := fulltextSearch(ctx, db, terms, limit)
+    if err != nil {
+        if ctx.Err() != nil {
+            return nil, err
+        }
+        slog.WarnContext(ctx, "full-text search failed, using fuzzy matching", "error", err)
+    }
+    fuzzy := false
+    if len(ranked) == 0 {
+        if ranked, err = fuzzySearch(ctx, db, terms, limit); err != nil {
+            return nil, err
+        }
+        fuzzy = true
+    }
+    return searchResults(ctx, db, ranked, fuzzy)
+}
+
+// rankedItem is an item ID with its search score.
+type rankedItem struct {
+    id    int
+    score float64
+}
+
+// fulltextSearch ranks items with MATCH ... AGAINST in boolean mode. Every term is a
+// prefix match, and items matching more terms rank higher.
+func fulltextSearch(ctx context.Context, db *Database, terms []string, limit int) ([]rankedItem, error) {
+    against := strings.Join(terms, "* ") + "*"
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT item_id, MATCH (search_text) AGAINST (? IN BOOLEAN MODE) AS score
+        FROM item_search
+        WHERE MATCH (search_text) AGAINST (? IN BOOLEAN MODE)
+        ORDER BY score DESC, item_id ASC
+        LIMIT ?
+    `, against, against, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ranked []rankedItem
+    for rows.Next() {
+        var r rankedItem
+        if err := rows.Scan(&r.id, &r.score); err != nil {
+            return nil, err
+        }
+        ranked = append(ranked, r)
+    }
+    return ranked, rows.Err()
+}
+
+// fuzzySearch scores every item's search text in Go. It reads the item tables rather
+// than item_search, so it also works when the index is missing or stale.
+func fuzzySearch(ctx context.Context, db *Database, terms []string, limit int) ([]rankedItem, error) {
+    rows, err := db.conn.QueryContext(ctx, itemSearchDocuments)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ranked []rankedItem
+    for rows.Next() {
+        var id int
+        var text string
+        if err := rows.Scan(&id, &text); err != nil {
+            return nil, err
+        }
+        if score := fuzzyScore(terms, searchTerms(text)); score > 0 {
+            ranked = append(ranked, rankedItem{id: id, score: score})
+        }
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    sort.SliceStable(ranked, func(i, j int) bool {
+        if ranked[i].score != ranked[j].score {
+            return ranked[i].score > ranked[j].score
+        }
+        return ranked[i].id < ranked[j].id
+    })
+    if len(ranked) > limit {
+        ranked = ranked[:limit]
+    }
+    return ranked, nil
+}
+
+// searchResults loads the ranked items' details, keeping the ranking order.
+func searchResults(ctx context.Context, db *Database, ranked []rankedItem, fuzzy bool) ([]SearchResult, error) {
+    results := []SearchResult{}
+    if len(ranked) == 0 {
+        return results, nil
+    }
+
+    ids := make([]int, len(ranked))
+    for i, r := range ranked {
+        ids[i] = r.id
+    }
+    placeholders, args := idList(ids)
+    rows, err := db.conn.QueryContext(ctx, itemDetailsQuery+" AND i.id IN ("+placeholders+")", args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    items := map[int]InventoryItemWithDetails{}
+    for rows.Next() {
+        item, err := scanItemDetails(rows)
+        if err != nil {
+            return nil, err
+        }
+        items[item.ID] = item
+    }
+    if err := rows.Err(); err != nil {
+        return nil, err
+    }
+
+    barcodes, err := GetItemBarcodes(ctx, db, ids)
+    if err != nil {
+        return nil, err
+    }
+    for _, r := range ranked {
+        item, ok := items[r.id]
+        if !ok {
+            continue // deleted since it was ranked
+        }
+        item.Barcodes = barcodes[item.ID]
+        if item.Barcodes == nil {
+            item.Barcodes = []string{}
+        }
+        results = append(results, SearchResult{Item: item, Score: r.score, Fuzzy: fuzzy})
+    }
+
+    tags, err := itemTagNames(ctx, db, ids)
+    if err != nil {
+        return nil, err
+    }
+    for i := range results {
+        results[i].Item.Tags = append([]string{}, tags[results[i].Item.ID]...)
+    }
+    return results, nil
+}
+
+// searchTerms splits text into lower-case words of letters and digits. Everything else,
+// including the FULLTEXT boolean operators, separates words. Repeated words are dropped.
+func searchTerms(text string) []string {
+    fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+    })
+    seen := map[string]bool{}
+    terms := fields[:0]
+    for _, f := range fields {
+        if !seen[f] {
+            seen[f] = true
+            terms = append(terms, f)
+        }
+    }
+    return terms
+}
+
+// fuzzyScore rates how well words match the search terms, from 0 (some term matches
+// nothing) to 1 (every term is a prefix of a word).
+func fuzzyScore(terms, words []string) float64 {
+    total := 0.0
+    for _, term := range terms {
+        best := 0.0
+        for _, word := range words {
+            if s := termSimilarity(term, word); s > best {
+                best = s
+            }
+        }
+        if best == 0 {
+            return 0
+        }
+        total += best
+    }
+    return total / float64(len(terms))
+}
+
+// termSimilarity rates a search term against one word. Prefixes score 1; otherwise the
+// term may be a few edits away from the word, or from its start when the word is longer,
+// with longer terms allowed more typos.
+func termSimilarity(term, word string) float64 {
+    if strings.HasPrefix(word, term) {
+        return 1
+    }
+    t, w := []rune(term), []rune(word)
+    distance := editDistance(t, w)
+    if len(w) > len(t) {
+        if d := editDistance(t, w[:len(t)]); d < distance {
+            distance = d
+        }
+    }
+    if distance > allowedTypos(len(t)) {
+        return 0
+    }
+    return 1 - float64(distance)/float64(len(t)+1)
+}
+
+// allowedTypos is how many edits a search term of n runes may be from a word.
+func allowedTypos(n int) int {
+    switch {
+    case n < 4:
+        return 0
+    case n < 7:
+        return 1
+    default:
+        return 2
+    }
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of adjacent
+// runes needed to turn a into b (optimal string alignment distance).
+func editDistance(a, b []rune) int {
+    prev2 := make([]int, len(b)+1)
+    prev := make([]int, len(b)+1)
+    cur := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+    for i := 1; i <= len(a); i++ {
+        cur[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+            if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+                cur[j] = min(cur[j], prev2[j-2]+1)
+            }
+        }
+        prev2, prev, cur = prev, cur, prev2
+    }
+    return prev[len(b)]
+}
+
+// GetItemAliases retrieves an item's aliases in alphabetical order.
+func GetItemAliases(ctx context.Context, db *Database, itemID int) ([]string, error) {
+    defer observe("GetItemAliases", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `SELECT alias FROM item_alias WHERE item_id = ? ORDER BY alias ASC`, itemID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    aliases := []string{}
+    for rows.Next() {
+        var alias string
+        if err := rows.Scan(&alias); err != nil {
+            return nil, err
+        }
+        aliases = append(aliases, alias)
+    }
+    return aliases, rows.Err()
+}
+
+// AddItemAlias gives an item another name search can find it by, such as "garbanzo
+// beans" for "Chickpeas". Adding an alias the item already has does nothing. It returns
+// sql.ErrNoRows when the item does not exist.
+func AddItemAlias(ctx context.Context, db *Database, itemID int, alias string) error {
+    defer observe("AddItemAlias", time.Now())
+    alias = strings.TrimSpace(alias)
+    if alias == "" {
+        return fmt.Errorf("alias is required")
+    }
+    if utf8.RuneCountInString(alias) > 255 {
+        return fmt.Errorf("alias must be at most 255 characters")
+    }
+
+    var id int
+    if err := db.conn.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE id = ?`, itemID).Scan(&id); err != nil {
+        return err
+    }
+    if _, err := db.conn.ExecContext(ctx, `
+        INSERT INTO item_alias (item_id, alias) VALUES (?, ?)
+        ON DUPLICATE KEY UPDATE alias = alias
+    `, itemID, alias); err != nil {
+        return err
+    }
+    return refreshItemSearch(ctx, db.conn, int64(itemID))
+}
+
+// RemoveItemAlias removes one of an item's aliases.
+func RemoveItemAlias(ctx context.Context, db *Database, itemID int, alias string) error {
+    defer observe("RemoveItemAlias", time.Now())
+    result, err := db.conn.ExecContext(ctx, `DELETE FROM item_alias WHERE item_id = ? AND alias = ?`, itemID, strings.TrimSpace(alias))
+    if err != nil {
+        return err
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return fmt.Errorf("item %d has no alias %q", itemID, alias)
+    }
+    return refreshItemSearch(ctx, db.conn, int64(itemID))
+}
@@ -0,0 +1,117 @@
+package inventory
+
+import (
+    "math"
+    "slices"
+    "testing"
+)
+
+func TestSearchTerms(t *testing.T) {
+    tests := []struct {
+        text string
+        want []string
+    }{
+        {"Chickpeas", []string{"chickpeas"}},
+        {`+red -"kidney beans"*`, []string{"red", "kidney", "beans"}},
+        {"Beans beans BEANS", []string{"beans"}},
+        {"café-au-lait 2%", []string{"café", "au", "lait", "2"}},
+        {"Crème Brûlée", []string{"crème", "brûlée"}},
+        {"  -+*  ", []string{}},
+    }
+    for _, tt := range tests {
+        if got := searchTerms(tt.text); !slices.Equal(got, tt.want) {
+            t.Errorf("searchTerms(%q) = %q, want %q", tt.text, got, tt.want)
+        }
+    }
+}
+
+func TestEditDistance(t *testing.T) {
+    tests := []struct {
+        a, b string
+        want int
+    }{
+        {"", "", 0},
+        {"abc", "", 3},
+        {"", "abc", 3},
+        {"beans", "beans", 0},
+        {"kitten", "sitting", 3},
+        // Swapping adjacent runes is one edit.
+        {"chikcpeas", "chickpeas", 1},
+        {"ab", "ba", 1},
+        // Optimal string alignment does not edit a swapped pair again.
+        {"ca", "abc", 3},
+        // Runes, not bytes, are edited.
+        {"crème", "creme", 1},
+        {"über", "uber", 1},
+    }
+    for _, tt := range tests {
+        if got := editDistance([]rune(tt.a), []rune(tt.b)); got != tt.want {
+            t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+        }
+    }
+}
+
+func TestAllowedTypos(t *testing.T) {
+    tests := []struct {
+        n, want int
+    }{
+        {0, 0},
+        {3, 0},
+        {4, 1},
+        {6, 1},
+        {7, 2},
+        {20, 2},
+    }
+    for _, tt := range tests {
+        if got := allowedTypos(tt.n); got != tt.want {
+            t.Errorf("allowedTypos(%d) = %d, want %d", tt.n, got, tt.want)
+        }
+    }
+}
+
+func TestTermSimilarity(t *testing.T) {
+    tests := []struct {
+        term, word string
+        want       float64
+    }{
+        {"chick", "chickpeas", 1},
+        {"chickpeas", "chickpeas", 1},
+        {"chikcpeas", "chickpeas", 1 - 1.0/10},
+        // A typo near the start of a longer word is measured against its start.
+        {"chikc", "chickpeas", 1 - 1.0/6},
+        // Three runes allow no typos, four allow one.
+        {"cta", "cat", 0},
+        {"tnua", "tuna", 1 - 1.0/5},
+        // Six runes allow one typo, seven allow two.
+        {"tomxxo", "tomato", 0},
+        {"chixkxn", "chicken", 1 - 2.0/8},
+        // Lengths count runes, not bytes.
+        {"crème", "creme", 1 - 1.0/6},
+        {"brûlée", "brulee", 0},
+        {"rice", "beans", 0},
+    }
+    for _, tt := range tests {
+        if got := termSimilarity(tt.term, tt.word); math.Abs(got-tt.want) > 1e-9 {
+            t.Errorf("termSimilarity(%q, %q) = %v, want %v", tt.term, tt.word, got, tt.want)
+        }
+    }
+}
+
+func TestFuzzyScore(t *testing.T) {
+    tests := []struct {
+        terms, words []string
+        want         float64
+    }{
+        {[]string{"red", "beans"}, []string{"red", "kidney", "beans"}, 1},
+        {[]string{"chikcpeas"}, []string{"chickpeas"}, 1 - 1.0/10},
+        {[]string{"red", "baens"}, []string{"red", "kidney", "beans"}, (1 + 1 - 1.0/6) / 2},
+        // Every term has to match some word.
+        {[]string{"chick", "peas"}, []string{"chickpeas", "canned"}, 0},
+        {[]string{"red"}, nil, 0},
+    }
+    for _, tt := range tests {
+        if got := fuzzyScore(tt.terms, tt.words); math.Abs(got-tt.want) > 1e-9 {
+            t.Errorf("fuzzyScore(%q, %q) = %v, want %v", tt.terms, tt.words, got, tt.want)
+        }
+    }
+}
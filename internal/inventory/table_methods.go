@@ -1,17 +1,56 @@
 package inventory
 
 import (
+    "context"
+    "database/sql"
     "fmt"
+    "log/slog"
+    "strings"
+    "time"
 )
 
-// InsertItem inserts a new inventory item into the database.
-func InsertItem(db *Database, item InventoryItem) (int64, error) {
+// InsertItem inserts a new inventory item into the database along with any barcodes it carries.
+func InsertItem(ctx context.Context, db *Database, item InventoryItem) (int64, error) {
+    defer observe("InsertItem", time.Now())
+    item.ItemName = strings.TrimSpace(item.ItemName)
+    if item.ItemName == "" {
+        return 0, fmt.Errorf("item name is required")
+    }
+    barcodes := make([]string, 0, len(item.Barcodes))
+    for _, code := range item.Barcodes {
+        barcode, err := NormalizeBarcode(code)
+        if err != nil {
+            return 0, err
+        }
+        if _, _, err := FindItemByBarcode(ctx, db, barcode); err == nil {
+            return 0, fmt.Errorf("barcode %s is already assigned to another item", barcode)
+        }
+        barcodes = append(barcodes, barcode)
+    }
+
+    // The item, its units, barcodes, history and search row are written together, so a
+    // barcode taken in the meantime leaves no half-created item behind.
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    // Stock operations address items by name; the unique index on item_name backs this up.
+    var taken int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_item WHERE item_name = ?`, item.ItemName).Scan(&taken); err != nil {
+        return 0, err
+    }
+    if taken > 0 {
+        return 0, fmt.Errorf("an item named %q already exists", item.ItemName)
+    }
+
     query := `
         INSERT INTO inventory_item 
         (item_name, itemQTY, minimumQTY, itemUsedToDate, item_type_id, item_substitution_id, item_expiration_period, item_total_tossed)
         VALUES (?, ?, ?, ?, ?, ?, ?, ?)
     `
-    result, err := db.conn.Exec(query,
+    result, err := tx.ExecContext(ctx, query,
         item.ItemName,
         item.ItemQTY,
         item.MinimumQTY,
@@ -30,84 +69,210 @@ func InsertItem(db *Database, item InventoryItem) (int64, error) {
         return 0, err
     }
 
-    if err := insertItemExpirationXref(db, itemID, item.ItemExpirationPeriod, item.ItemQTY); err != nil {
+    if err := insertItemExpirationXref(ctx, tx, itemID, item.ItemExpirationPeriod, item.ItemQTY); err != nil {
         return 0, err
     }
 
+    for _, barcode := range barcodes {
+        if _, err := tx.ExecContext(ctx, `INSERT INTO item_barcode (item_id, barcode) VALUES (?, ?)`, itemID, barcode); err != nil {
+            return 0, fmt.Errorf("barcode %s: %w", barcode, err)
+        }
+        if _, err := tx.ExecContext(ctx, `DELETE FROM scan_queue WHERE barcode = ?`, barcode); err != nil {
+            return 0, err
+        }
+    }
+
+    if err := recordItemHistory(ctx, tx, itemID, HistoryAdded, item.ItemQTY, item.ItemQTY); err != nil {
+        return 0, err
+    }
+    if err := refreshItemSearch(ctx, tx, itemID); err != nil {
+        return 0, err
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    slog.InfoContext(ctx, "item added", "item", item.ItemName, "id", itemID, "qty", item.ItemQTY)
     return itemID, nil
 }
 
-// GetItemList retrieves a list of inventory items with their type and substitution names.
-func GetItemList(db *Database, limit int, itemType string, underMinimum bool) ([]InventoryItemWithDetails, error) {
-    query := `
+// itemDetailsFrom joins inventory items to their type, substitution and location, and to
+// the units currently lent out (ln.lent_qty). Callers append WHERE conditions.
+const itemDetailsFrom = `
+        FROM inventory_item i
+        LEFT JOIN item_type t ON i.item_type_id = t.id
+        LEFT JOIN item_substitution s ON i.item_substitution_id = s.id
+        LEFT JOIN item_location_xref lx ON lx.item_id = i.id
+        LEFT JOIN item_location l ON lx.location_id = l.id
+        ` + openLoansJoin + `
+        WHERE 1=1
+    `
+
+// openLoansJoin joins inventory items (i) to the units currently lent out (ln.lent_qty).
+const openLoansJoin = `LEFT JOIN (
+            SELECT item_id, SUM(quantity) AS lent_qty FROM item_loan WHERE returned_date IS NULL GROUP BY item_id
+        ) ln ON ln.item_id = i.id`
+
+// availableQTY is the quantity on hand: the units owned minus those lent out.
+const availableQTY = "(i.itemQTY - COALESCE(ln.lent_qty, 0))"
+
+// itemDetailsQuery selects inventory items with their type, substitution and location names.
+// itemQTY is the available quantity, excluding units lent out.
+// Callers append WHERE conditions and scan rows with scanItemDetails.
+const itemDetailsQuery = `
         SELECT 
             i.id, 
             i.item_name, 
-            i.itemQTY, 
+            ` + availableQTY + `,
+            COALESCE(ln.lent_qty, 0),
             i.minimumQTY, 
             i.itemUsedToDate,
             i.item_total_tossed, -- ✅ Added field here
+            COALESCE(i.item_type_id, 0),
             t.type_name,
+            COALESCE(i.item_substitution_id, 0),
             s.substitution_name,
+            COALESCE(i.item_expiration_period, 0),
+            COALESCE(l.id, 0),
+            COALESCE(l.location_name, ''),
             i.createDate, 
-            i.lastModifiedDate
-        FROM inventory_item i
-        LEFT JOIN item_type t ON i.item_type_id = t.id
-        LEFT JOIN item_substitution s ON i.item_substitution_id = s.id
-        WHERE 1=1
-    `
-    args := []interface{}{}
+            i.lastModifiedDate` + itemDetailsFrom
+
+// scanItemDetails scans a row selected by itemDetailsQuery.
+func scanItemDetails(row interface{ Scan(...interface{}) error }) (InventoryItemWithDetails, error) {
+    var item InventoryItemWithDetails
+    err := row.Scan(
+        &item.ID,
+        &item.ItemName,
+        &item.ItemQTY,
+        &item.LentQTY,
+        &item.MinimumQTY,
+        &item.ItemUsedToDate,
+        &item.ItemTotalTossed, // ✅ Added scan target
+        &item.ItemTypeID,
+        &item.ItemTypeName,
+        &item.ItemSubstitutionID,
+        &item.ItemSubstitutionName,
+        &item.ItemExpirationPeriod,
+        &item.LocationID,
+        &item.LocationName,
+        &item.CreateDate,
+        &item.LastModifiedDate,
+    )
+    return item, err
+}
 
-    if itemType != "" {
-        query += " AND t.type_name = ?"
-        args = append(args, itemType)
+// GetItemList retrieves a list of inventory items with their type and substitution names.
+func GetItemList(ctx context.Context, db *Database, limit int, itemType string, underMinimum bool) ([]InventoryItemWithDetails, error) {
+    page, err := ListItems(ctx, db, ItemListQuery{ItemType: itemType, UnderMinimum: underMinimum, Limit: limit})
+    return page.Items, err
+}
+
+// GetItemByID retrieves a single inventory item with its type and substitution names, barcodes and tags.
+func GetItemByID(ctx context.Context, db *Database, id int) (InventoryItemWithDetails, error) {
+    defer observe("GetItemByID", time.Now())
+    item, err := scanItemDetails(db.conn.QueryRowContext(ctx, itemDetailsQuery+" AND i.id = ?", id))
+    if err != nil {
+        return InventoryItemWithDetails{}, err
     }
 
-    if underMinimum {
-        query += " AND i.itemQTY < i.minimumQTY"
+    rows, err := db.conn.QueryContext(ctx, `SELECT barcode FROM item_barcode WHERE item_id = ? ORDER BY id ASC`, id)
+    if err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    defer rows.Close()
+
+    item.Barcodes = []string{}
+    for rows.Next() {
+        var barcode string
+        if err := rows.Scan(&barcode); err != nil {
+            return InventoryItemWithDetails{}, err
+        }
+        item.Barcodes = append(item.Barcodes, barcode)
+    }
+    if err := rows.Err(); err != nil {
+        return InventoryItemWithDetails{}, err
     }
 
-    query += " ORDER BY i.id ASC"
+    tagRows, err := db.conn.QueryContext(ctx, `
+        SELECT g.tag_name
+        FROM item_tag_xref x
+        JOIN item_tag g ON g.id = x.tag_id
+        WHERE x.item_id = ?
+        ORDER BY g.tag_name ASC
+    `, id)
+    if err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    defer tagRows.Close()
 
-    if limit > 0 {
-        query += " LIMIT ?"
-        args = append(args, limit)
+    item.Tags = []string{}
+    for tagRows.Next() {
+        var tag string
+        if err := tagRows.Scan(&tag); err != nil {
+            return InventoryItemWithDetails{}, err
+        }
+        item.Tags = append(item.Tags, tag)
     }
+    return item, tagRows.Err()
+}
 
-    rows, err := db.conn.Query(query, args...)
+// GetItemUnits retrieves the individual stock units of an item from item_expiration_xref,
+// soonest to expire first.
+func GetItemUnits(ctx context.Context, db *Database, itemID int) ([]ItemUnit, error) {
+    defer observe("GetItemUnits", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT id, item_id, item_creation_date, item_expiration_date
+        FROM item_expiration_xref
+        WHERE item_id = ?
+        ORDER BY item_expiration_date ASC, id ASC
+    `, itemID)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
 
-    items := []InventoryItemWithDetails{}
+    units := []ItemUnit{}
     for rows.Next() {
-        var item InventoryItemWithDetails
-        err := rows.Scan(
-            &item.ID,
-            &item.ItemName,
-            &item.ItemQTY,
-            &item.MinimumQTY,
-            &item.ItemUsedToDate,
-            &item.ItemTotalTossed, // ✅ Added scan target
-            &item.ItemTypeName,
-            &item.ItemSubstitutionName,
-            &item.CreateDate,
-            &item.LastModifiedDate,
-        )
-        if err != nil {
+        var u ItemUnit
+        if err := rows.Scan(&u.ID, &u.ItemID, &u.CreationDate, &u.ExpirationDate); err != nil {
             return nil, err
         }
-        items = append(items, item)
+        units = append(units, u)
     }
+    return units, rows.Err()
+}
+
+// GetUnitsByItem retrieves the stock units of the given items, or of every item when
+// itemIDs is nil, grouped by item ID and soonest to expire first.
+func GetUnitsByItem(ctx context.Context, db *Database, itemIDs []int) (map[int][]ItemUnit, error) {
+    defer observe("GetUnitsByItem", time.Now())
+    where, args := itemIDCondition("item_id", itemIDs)
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT id, item_id, item_creation_date, item_expiration_date
+        FROM item_expiration_xref`+where+`
+        ORDER BY item_id ASC, item_expiration_date ASC, id ASC
+    `, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
 
-    return items, nil
+    units := map[int][]ItemUnit{}
+    for rows.Next() {
+        var u ItemUnit
+        if err := rows.Scan(&u.ID, &u.ItemID, &u.CreationDate, &u.ExpirationDate); err != nil {
+            return nil, err
+        }
+        units[u.ItemID] = append(units[u.ItemID], u)
+    }
+    return units, rows.Err()
 }
 
 // GetItemTypes retrieves all item types from the database.
-func GetItemTypes(db *Database) ([]ItemType, error) {
+func GetItemTypes(ctx context.Context, db *Database) ([]ItemType, error) {
+    defer observe("GetItemTypes", time.Now())
     query := `SELECT id, type_name FROM item_type ORDER BY type_name ASC`
-    rows, err := db.conn.Query(query)
+    rows, err := db.conn.QueryContext(ctx, query)
     if err != nil {
         return nil, err
     }
@@ -125,9 +290,10 @@ func GetItemTypes(db *Database) ([]ItemType, error) {
 }
 
 // GetItemSubstitutions retrieves all item substitutions from the database.
-func GetItemSubstitutions(db *Database) ([]ItemSubstitution, error) {
+func GetItemSubstitutions(ctx context.Context, db *Database) ([]ItemSubstitution, error) {
+    defer observe("GetItemSubstitutions", time.Now())
     query := `SELECT id, substitution_name FROM item_substitution ORDER BY substitution_name ASC`
-    rows, err := db.conn.Query(query)
+    rows, err := db.conn.QueryContext(ctx, query)
     if err != nil {
         return nil, err
     }
@@ -144,87 +310,265 @@ func GetItemSubstitutions(db *Database) ([]ItemSubstitution, error) {
     return substitutions, nil
 }
 
-// UpdateItemQty updates the quantity and used count of an inventory item.
-func UpdateItemQty(db *Database, itemName string, action string) (map[string]interface{}, error) {
-    if action != "+" && action != "-" {
-        return nil, fmt.Errorf("invalid action: must be + or -")
+// AddItemType inserts a new item type and returns its ID.
+func AddItemType(ctx context.Context, db *Database, name string) (int64, error) {
+    defer observe("AddItemType", time.Now())
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return 0, fmt.Errorf("type name is required")
+    }
+    result, err := db.conn.ExecContext(ctx, `INSERT INTO item_type (type_name) VALUES (?)`, name)
+    if err != nil {
+        return 0, err
     }
+    return result.LastInsertId()
+}
 
-    var itemID, expirationPeriod int
-    err := db.conn.QueryRow(`
-        SELECT id, item_expiration_period
-        FROM inventory_item
-        WHERE item_name = ?
-    `, itemName).Scan(&itemID, &expirationPeriod)
+// AddItemSubstitution inserts a new item substitution and returns its ID.
+func AddItemSubstitution(ctx context.Context, db *Database, name string) (int64, error) {
+    defer observe("AddItemSubstitution", time.Now())
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return 0, fmt.Errorf("substitution name is required")
+    }
+    result, err := db.conn.ExecContext(ctx, `INSERT INTO item_substitution (substitution_name) VALUES (?)`, name)
+    if err != nil {
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// GetItemLocations retrieves all item locations from the database.
+func GetItemLocations(ctx context.Context, db *Database) ([]ItemLocation, error) {
+    defer observe("GetItemLocations", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `SELECT id, location_name FROM item_location ORDER BY location_name ASC`)
     if err != nil {
         return nil, err
     }
+    defer rows.Close()
 
-    var updateQuery string
-    if action == "+" {
-        updateQuery = `
-            UPDATE inventory_item
-            SET itemQTY = itemQTY + 1, lastModifiedDate = NOW()
-            WHERE item_name = ?
-        `
-    } else {
-        updateQuery = `
-            UPDATE inventory_item
-            SET itemQTY = itemQTY - 1, itemUsedToDate = itemUsedToDate + 1, lastModifiedDate = NOW()
-            WHERE item_name = ?
-        `
+    var locations []ItemLocation
+    for rows.Next() {
+        var l ItemLocation
+        if err := rows.Scan(&l.ID, &l.Name); err != nil {
+            return nil, err
+        }
+        locations = append(locations, l)
     }
+    return locations, rows.Err()
+}
 
-    _, err = db.conn.Exec(updateQuery, itemName)
+// AddItemLocation inserts a new item location and returns its ID.
+func AddItemLocation(ctx context.Context, db *Database, name string) (int64, error) {
+    defer observe("AddItemLocation", time.Now())
+    name = strings.TrimSpace(name)
+    if name == "" {
+        return 0, fmt.Errorf("location name is required")
+    }
+    result, err := db.conn.ExecContext(ctx, `INSERT INTO item_location (location_name) VALUES (?)`, name)
+    if err != nil {
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// GetExpiringUnits retrieves stock units expiring within the given number of days,
+// including ones already expired, grouped by item and expiration day.
+func GetExpiringUnits(ctx context.Context, db *Database, days int) ([]ExpiringUnits, error) {
+    defer observe("GetExpiringUnits", time.Now())
+    today := Today()
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT i.id, i.item_name, DATE(x.item_expiration_date) AS expires, COUNT(*)
+        FROM item_expiration_xref x
+        JOIN inventory_item i ON x.item_id = i.id
+        WHERE x.item_expiration_date < DATE_ADD(?, INTERVAL ? + 1 DAY)
+        GROUP BY i.id, i.item_name, expires
+        ORDER BY expires ASC, i.item_name ASC
+    `, today.Format(time.DateOnly), days)
     if err != nil {
         return nil, err
     }
+    defer rows.Close()
 
-    if action == "+" {
-        if err := insertItemExpirationXref(db, int64(itemID), expirationPeriod, 1); err != nil {
+    expiring := []ExpiringUnits{}
+    for rows.Next() {
+        var e ExpiringUnits
+        if err := rows.Scan(&e.ItemID, &e.ItemName, &e.ExpirationDate, &e.Units); err != nil {
             return nil, err
         }
-    } else {
-        if err := removeItemExpirationXref(db, int64(itemID), 1); err != nil {
+        e.DaysLeft = DaysBetween(today, e.ExpirationDate)
+        expiring = append(expiring, e)
+    }
+    return expiring, rows.Err()
+}
+
+// GetInventoryStats counts items, items under their minimum, units expiring within the
+// given number of days (including expired ones) and the units used and tossed to date.
+// An item is under its minimum when its available quantity is, as in the item list.
+func GetInventoryStats(ctx context.Context, db *Database, expiringDays int) (InventoryStats, error) {
+    defer observe("GetInventoryStats", time.Now())
+    var stats InventoryStats
+    err := db.conn.QueryRowContext(ctx, `
+        SELECT
+            COUNT(*),
+            COALESCE(SUM(` + availableQTY + ` < i.minimumQTY), 0),
+            COALESCE(SUM(i.itemUsedToDate), 0),
+            COALESCE(SUM(i.item_total_tossed), 0)
+        FROM inventory_item i
+        ` + openLoansJoin + `
+    `).Scan(&stats.Items, &stats.UnderMinimum, &stats.UnitsUsed, &stats.UnitsTossed)
+    if err != nil {
+        return stats, err
+    }
+    err = db.conn.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM item_expiration_xref
+        WHERE item_expiration_date < DATE_ADD(?, INTERVAL ? + 1 DAY)
+    `, Today().Format(time.DateOnly), expiringDays).Scan(&stats.ExpiringUnits)
+    return stats, err
+}
+
+// recordItemHistory appends an entry to an item's stock history. q is the database or
+// the transaction the change was made in.
+func recordItemHistory(ctx context.Context, q execer, itemID int64, event string, units, quantityAfter int) error {
+    _, err := q.ExecContext(ctx, `
+        INSERT INTO item_history (item_id, item_name, event, units, quantity_after)
+        SELECT id, item_name, ?, ?, ? FROM inventory_item WHERE id = ?
+    `, event, units, quantityAfter, itemID)
+    return err
+}
+
+// GetItemHistory retrieves an item's most recent stock changes, newest first.
+func GetItemHistory(ctx context.Context, db *Database, itemID int, limit int) ([]ItemHistoryEntry, error) {
+    defer observe("GetItemHistory", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT id, item_id, item_name, event, units, quantity_after, createDate
+        FROM item_history
+        WHERE item_id = ?
+        ORDER BY createDate DESC, id DESC
+        LIMIT ?
+    `, itemID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    history := []ItemHistoryEntry{}
+    for rows.Next() {
+        var h ItemHistoryEntry
+        if err := rows.Scan(&h.ID, &h.ItemID, &h.ItemName, &h.Event, &h.Units, &h.QuantityAfter, &h.CreateDate); err != nil {
             return nil, err
         }
+        history = append(history, h)
     }
+    return history, rows.Err()
+}
 
-    row := db.conn.QueryRow(`
-        SELECT id, item_name, itemQTY, itemUsedToDate
-        FROM inventory_item
-        WHERE item_name = ?
-    `, itemName)
+// UpdateItemQty adds ("+") or uses ("-") one unit of the item with the given name.
+func UpdateItemQty(ctx context.Context, db *Database, itemName string, action string) (map[string]interface{}, error) {
+    defer observe("UpdateItemQty", time.Now())
+    var itemID int
+    if err := db.conn.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE item_name = ?`, itemName).Scan(&itemID); err != nil {
+        return nil, err
+    }
+    return AdjustItemQty(ctx, db, itemID, action)
+}
+
+// AdjustItemQty adds ("+") or uses ("-") one unit of an item and records the change in
+// its history. Using an item with no units available, counting lent units as gone, is
+// rejected; the check and the change happen under one row lock, so concurrent scans
+// cannot both take the last unit. The returned itemQTY is the available quantity, as in
+// GetItemList. It returns sql.ErrNoRows when the item does not exist.
+func AdjustItemQty(ctx context.Context, db *Database, itemID int, action string) (map[string]interface{}, error) {
+    defer observe("AdjustItemQty", time.Now())
+    if action != "+" && action != "-" {
+        return nil, fmt.Errorf("invalid action: must be + or -")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
 
-    var id, qty, used int
     var name string
-    if err := row.Scan(&id, &name, &qty, &used); err != nil {
+    var qty, used, expirationPeriod int
+    err = tx.QueryRowContext(ctx, `
+        SELECT item_name, itemQTY, itemUsedToDate, item_expiration_period
+        FROM inventory_item
+        WHERE id = ?
+        FOR UPDATE
+    `, itemID).Scan(&name, &qty, &used, &expirationPeriod)
+    if err != nil {
+        return nil, err
+    }
+    lent, err := lentQuantity(ctx, tx, itemID)
+    if err != nil {
         return nil, err
     }
 
+    event := HistoryRestocked
+    if action == "+" {
+        _, err = tx.ExecContext(ctx, `
+            UPDATE inventory_item
+            SET itemQTY = itemQTY + 1, lastModifiedDate = NOW()
+            WHERE id = ?
+        `, itemID)
+        if err == nil {
+            err = insertItemExpirationXref(ctx, tx, int64(itemID), expirationPeriod, 1)
+        }
+        qty++
+    } else {
+        if err := checkAvailable(name, qty, lent); err != nil {
+            return nil, err
+        }
+        event = HistoryUsed
+        _, err = tx.ExecContext(ctx, `
+            UPDATE inventory_item
+            SET itemQTY = itemQTY - 1, itemUsedToDate = itemUsedToDate + 1, lastModifiedDate = NOW()
+            WHERE id = ?
+        `, itemID)
+        if err == nil {
+            err = removeItemExpirationXref(ctx, tx, int64(itemID), 1)
+        }
+        qty--
+        used++
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    if err := recordItemHistory(ctx, tx, int64(itemID), event, 1, qty); err != nil {
+        return nil, err
+    }
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    slog.InfoContext(ctx, "item quantity changed", "item", name, "action", action, "qty", qty)
     result := map[string]interface{}{
-        "id":             id,
+        "id":             itemID,
         "itemName":       name,
-        "itemQTY":        qty,
+        "itemQTY":        qty - lent,
         "itemUsedToDate": used,
     }
     return result, nil
 }
 
-// insertItemExpirationXref inserts expiration tracking rows for a new inventory item.
-func insertItemExpirationXref(db *Database, itemID int64, expirationPeriod int, quantity int) error {
+// insertItemExpirationXref inserts expiration tracking rows for new units of an item,
+// within the transaction that adds them.
+func insertItemExpirationXref(ctx context.Context, tx *sql.Tx, itemID int64, expirationPeriod int, quantity int) error {
     query := `
         INSERT INTO item_expiration_xref (item_id, item_creation_date, item_expiration_date)
         VALUES (?, NOW(), DATE_ADD(NOW(), INTERVAL ? DAY))
     `
-    stmt, err := db.conn.Prepare(query)
+    stmt, err := tx.PrepareContext(ctx, query)
     if err != nil {
         return err
     }
     defer stmt.Close()
 
     for i := 0; i < quantity; i++ {
-        if _, err := stmt.Exec(itemID, expirationPeriod); err != nil {
+        if _, err := stmt.ExecContext(ctx, itemID, expirationPeriod); err != nil {
             return err
         }
     }
@@ -232,8 +576,9 @@ func insertItemExpirationXref(db *Database, itemID int64, expirationPeriod int,
     return nil
 }
 
-// removeItemExpirationXref removes the oldest expiration tracking rows for an inventory item.
-func removeItemExpirationXref(db *Database, itemID int64, quantity int) error {
+// removeItemExpirationXref removes the oldest expiration tracking rows for an inventory item,
+// within the transaction that uses the units.
+func removeItemExpirationXref(ctx context.Context, tx *sql.Tx, itemID int64, quantity int) error {
     query := `
         DELETE FROM item_expiration_xref
         WHERE id IN (
@@ -245,54 +590,43 @@ func removeItemExpirationXref(db *Database, itemID int64, quantity int) error {
             ) AS sub
         )
     `
-    _, err := db.conn.Exec(query, itemID, quantity)
+    _, err := tx.ExecContext(ctx, query, itemID, quantity)
     return err
 }
 
-// DisposeItem removes the oldest expiration entry and increments total tossed.
-func DisposeItem(db *Database, itemName string) (map[string]interface{}, error) {
-    var itemID int
-    err := db.conn.QueryRow(`
-        SELECT id
-        FROM inventory_item
-        WHERE item_name = ?
-    `, itemName).Scan(&itemID)
+// DisposeItem removes the oldest expiration entry and increments total tossed. An item
+// whose units are all lent out has none on hand to toss. The returned itemQTY is the
+// available quantity, as in GetItemList.
+func DisposeItem(ctx context.Context, db *Database, itemName string) (map[string]interface{}, error) {
+    defer observe("DisposeItem", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
     if err != nil {
         return nil, err
     }
+    defer tx.Rollback()
 
-    tx, err := db.conn.Begin()
+    var itemID, qty, tossed int
+    err = tx.QueryRowContext(ctx, `
+        SELECT id, itemQTY, item_total_tossed
+        FROM inventory_item
+        WHERE item_name = ?
+        FOR UPDATE
+    `, itemName).Scan(&itemID, &qty, &tossed)
     if err != nil {
         return nil, err
     }
-
-    defer func() {
-        if p := recover(); p != nil {
-            tx.Rollback()
-            panic(p)
-        } else if err != nil {
-            tx.Rollback()
-        } else {
-            err = tx.Commit()
-        }
-    }()
-
-    _, err = tx.Exec(`
-        DELETE FROM item_expiration_xref
-        WHERE id IN (
-            SELECT id FROM (
-                SELECT id FROM item_expiration_xref
-                WHERE item_id = ?
-                ORDER BY item_expiration_date ASC
-                LIMIT 1
-            ) AS sub
-        )
-    `, itemID)
+    lent, err := lentQuantity(ctx, tx, itemID)
     if err != nil {
         return nil, err
     }
+    if err := checkAvailable(itemName, qty, lent); err != nil {
+        return nil, err
+    }
 
-    _, err = tx.Exec(`
+    if err := removeItemExpirationXref(ctx, tx, int64(itemID), 1); err != nil {
+        return nil, err
+    }
+    _, err = tx.ExecContext(ctx, `
         UPDATE inventory_item
         SET item_total_tossed = item_total_tossed + 1, lastModifiedDate = NOW()
         WHERE id = ?
@@ -300,21 +634,164 @@ func DisposeItem(db *Database, itemName string) (map[string]interface{}, error)
     if err != nil {
         return nil, err
     }
+    tossed++
 
-    var tossed, qty int
-    err = tx.QueryRow(`
-        SELECT item_total_tossed, itemQTY
-        FROM inventory_item
-        WHERE id = ?
-    `, itemID).Scan(&tossed, &qty)
-    if err != nil {
+    if err := recordItemHistory(ctx, tx, int64(itemID), HistoryDisposed, 1, qty); err != nil {
+        return nil, err
+    }
+    if err := tx.Commit(); err != nil {
         return nil, err
     }
 
+    slog.InfoContext(ctx, "item disposed", "item", itemName, "qty", qty, "tossed", tossed)
     result := map[string]interface{}{
         "itemTotalTossed": tossed,
-        "itemQTY":         qty,
+        "itemQTY":         qty - lent,
     }
 
     return result, nil
 }
+
+// checkAvailable returns an error unless a unit of an item with qty owned and lent out
+// is on hand.
+func checkAvailable(name string, qty, lent int) error {
+    switch {
+    case qty-lent > 0:
+        return nil
+    case lent > 0:
+        return fmt.Errorf("%w: every unit of %s is lent out", ErrItemLent, name)
+    default:
+        return fmt.Errorf("%s is out of stock", name)
+    }
+}
+
+// UpdateItem changes an item's name, type, substitution, location, minimum quantity and
+// expiration period, and returns the updated item. A type, substitution or location ID of
+// 0 clears it. With RecomputeExpirations set, units that have not expired yet get a new
+// expiration date based on the new period. It returns sql.ErrNoRows when the item does
+// not exist.
+func UpdateItem(ctx context.Context, db *Database, id int, update ItemUpdate) (InventoryItemWithDetails, error) {
+    defer observe("UpdateItem", time.Now())
+    name := strings.TrimSpace(update.ItemName)
+    switch {
+    case name == "":
+        return InventoryItemWithDetails{}, fmt.Errorf("item name is required")
+    case update.MinimumQTY < 0:
+        return InventoryItemWithDetails{}, fmt.Errorf("minimum quantity must not be negative")
+    case update.ItemExpirationPeriod < 0:
+        return InventoryItemWithDetails{}, fmt.Errorf("expiration period must not be negative")
+    case update.ItemTypeID < 0 || update.ItemSubstitutionID < 0:
+        return InventoryItemWithDetails{}, fmt.Errorf("invalid item type or substitution")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    defer tx.Rollback()
+
+    var qty int
+    if err := tx.QueryRowContext(ctx, `SELECT itemQTY FROM inventory_item WHERE id = ? FOR UPDATE`, id).Scan(&qty); err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+
+    // Stock operations address items by name, so names must stay unique; the unique index
+    // on item_name enforces it, this check gives a clearer error.
+    var taken int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_item WHERE item_name = ? AND id <> ?`, name, id).Scan(&taken); err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    if taken > 0 {
+        return InventoryItemWithDetails{}, fmt.Errorf("another item is already named %q", name)
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE inventory_item
+        SET item_name = ?, minimumQTY = ?, item_type_id = ?, item_substitution_id = ?,
+            item_expiration_period = ?, lastModifiedDate = NOW()
+        WHERE id = ?
+    `, name, update.MinimumQTY, nullID(update.ItemTypeID), nullID(update.ItemSubstitutionID), update.ItemExpirationPeriod, id)
+    if err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+
+    if update.LocationID > 0 {
+        _, err = tx.ExecContext(ctx, `
+            INSERT INTO item_location_xref (item_id, location_id) VALUES (?, ?)
+            ON DUPLICATE KEY UPDATE location_id = VALUES(location_id)
+        `, id, update.LocationID)
+    } else {
+        _, err = tx.ExecContext(ctx, `DELETE FROM item_location_xref WHERE item_id = ?`, id)
+    }
+    if err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+
+    recomputed := int64(0)
+    if update.RecomputeExpirations {
+        result, err := tx.ExecContext(ctx, `
+            UPDATE item_expiration_xref
+            SET item_expiration_date = DATE_ADD(item_creation_date, INTERVAL ? DAY)
+            WHERE item_id = ? AND item_expiration_date >= NOW()
+        `, update.ItemExpirationPeriod, id)
+        if err != nil {
+            return InventoryItemWithDetails{}, err
+        }
+        if recomputed, err = result.RowsAffected(); err != nil {
+            return InventoryItemWithDetails{}, err
+        }
+    }
+
+    if err := recordItemHistory(ctx, tx, int64(id), HistoryEdited, int(recomputed), qty); err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    if err := refreshItemSearch(ctx, tx, int64(id)); err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+    if err := tx.Commit(); err != nil {
+        return InventoryItemWithDetails{}, err
+    }
+
+    slog.InfoContext(ctx, "item edited", "item", name, "id", id, "recomputedUnits", recomputed)
+    return GetItemByID(ctx, db, id)
+}
+
+// DeleteItem deletes an item together with its stock units and barcodes. Its history is
+// kept, ending with a "deleted" entry. It returns the deleted item's name, or
+// sql.ErrNoRows when the item does not exist.
+func DeleteItem(ctx context.Context, db *Database, id int) (string, error) {
+    defer observe("DeleteItem", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return "", err
+    }
+    defer tx.Rollback()
+
+    var name string
+    var qty int
+    if err := tx.QueryRowContext(ctx, `SELECT item_name, itemQTY FROM inventory_item WHERE id = ? FOR UPDATE`, id).Scan(&name, &qty); err != nil {
+        return "", err
+    }
+    lent, err := lentQuantity(ctx, tx, id)
+    if err != nil {
+        return "", err
+    }
+    if lent > 0 {
+        return "", fmt.Errorf("%w: %d of %s still lent out; check them in before deleting it", ErrItemLent, lent, name)
+    }
+
+    if err := recordItemHistory(ctx, tx, int64(id), HistoryDeleted, qty, 0); err != nil {
+        return "", err
+    }
+    // Units, barcodes and tasks are removed by ON DELETE CASCADE; the history, the
+    // maintenance log and returned loans keep the item's name.
+    if _, err := tx.ExecContext(ctx, `DELETE FROM inventory_item WHERE id = ?`, id); err != nil {
+        return "", err
+    }
+    if err := tx.Commit(); err != nil {
+        return "", err
+    }
+
+    slog.InfoContext(ctx, "item deleted", "item", name, "id", id)
+    return name, nil
+}
@@ -1,86 +1,480 @@
 package inventory
 
 import (
+    "context"
     "fmt"
+    "log/slog"
     "os"
+    "strings"
     "time"
 )
 
-// EnsureTables verifies required tables exist and are properly structured.
-func (d *Database) EnsureTables() {
-    tables := []struct {
-        Name         string
-        CreateStmt   string
-        ExpectedCols []string
-    }{
-        {
-            Name: "inventory_item",
-            CreateStmt: `
-                CREATE TABLE inventory_item (
-                    id INT AUTO_INCREMENT PRIMARY KEY,
-                    item_name VARCHAR(255) NOT NULL,
-                    itemQTY INT NOT NULL,
-                    minimumQTY INT NOT NULL,
-                    itemUsedToDate INT NOT NULL DEFAULT 0,
-                    item_type_id INT,
-                    item_substitution_id INT,
-                    createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
-                    lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-                    item_expiration_period INT,
-                    item_total_tossed INT DEFAULT 0,
-                    FOREIGN KEY (item_type_id) REFERENCES item_type(id),
-                    FOREIGN KEY (item_substitution_id) REFERENCES item_substitution(id)
-                );
-            `,
-            ExpectedCols: []string{
-                "id", "item_name", "itemQTY", "minimumQTY", "itemUsedToDate",
-                "item_type_id", "item_substitution_id", "createDate", "lastModifiedDate",
-                "item_expiration_period", "item_total_tossed",
-            },
-        },
-        {
-            Name: "item_type",
-            CreateStmt: `
-                CREATE TABLE item_type (
-                    id INT AUTO_INCREMENT PRIMARY KEY,
-                    type_name VARCHAR(255) NOT NULL UNIQUE
-                );
-            `,
-            ExpectedCols: []string{"id", "type_name"},
-        },
-        {
-            Name: "item_substitution",
-            CreateStmt: `
-                CREATE TABLE item_substitution (
-                    id INT AUTO_INCREMENT PRIMARY KEY,
-                    substitution_name VARCHAR(255) NOT NULL UNIQUE
-                );
-            `,
-            ExpectedCols: []string{"id", "substitution_name"},
-        },
-        {
-            Name: "item_expiration_xref",
-            CreateStmt: `
-                CREATE TABLE item_expiration_xref (
-                    id INT AUTO_INCREMENT PRIMARY KEY,
-                    item_id INT NOT NULL,
-                    item_creation_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
-                    item_expiration_date DATETIME NOT NULL,
-                    FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
-                );
-            `,
-            ExpectedCols: []string{"id", "item_id", "item_creation_date", "item_expiration_date"},
+// SchemaVersion is the version of the table layout defined in schemaTables.
+// Bump it whenever a table is added or changed so backups and readiness checks
+// can tell which layout a database holds.
+const SchemaVersion = 10
+
+// tableSpec describes a table the application requires.
+type tableSpec struct {
+    Name         string
+    CreateStmt   string
+    ExpectedCols []string
+}
+
+// schemaTables lists every required table. Tables are ordered so that each one
+// only references tables listed before it, which is the order they are created in.
+var schemaTables = []tableSpec{
+    {
+        Name: "schema_info",
+        CreateStmt: `
+            CREATE TABLE schema_info (
+                id INT PRIMARY KEY,
+                schema_version INT NOT NULL,
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+            );
+        `,
+        ExpectedCols: []string{"id", "schema_version", "lastModifiedDate"},
+    },
+    {
+        Name: "item_type",
+        CreateStmt: `
+            CREATE TABLE item_type (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                type_name VARCHAR(255) NOT NULL UNIQUE
+            );
+        `,
+        ExpectedCols: []string{"id", "type_name"},
+    },
+    {
+        Name: "item_substitution",
+        CreateStmt: `
+            CREATE TABLE item_substitution (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                substitution_name VARCHAR(255) NOT NULL UNIQUE
+            );
+        `,
+        ExpectedCols: []string{"id", "substitution_name"},
+    },
+    {
+        Name: "inventory_item",
+        CreateStmt: `
+            CREATE TABLE inventory_item (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_name VARCHAR(255) NOT NULL UNIQUE,
+                itemQTY INT NOT NULL,
+                minimumQTY INT NOT NULL,
+                itemUsedToDate INT NOT NULL DEFAULT 0,
+                item_type_id INT,
+                item_substitution_id INT,
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                item_expiration_period INT,
+                item_total_tossed INT DEFAULT 0,
+                FOREIGN KEY (item_type_id) REFERENCES item_type(id),
+                FOREIGN KEY (item_substitution_id) REFERENCES item_substitution(id)
+            );
+        `,
+        ExpectedCols: []string{
+            "id", "item_name", "itemQTY", "minimumQTY", "itemUsedToDate",
+            "item_type_id", "item_substitution_id", "createDate", "lastModifiedDate",
+            "item_expiration_period", "item_total_tossed",
         },
+    },
+    {
+        Name: "item_expiration_xref",
+        CreateStmt: `
+            CREATE TABLE item_expiration_xref (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                item_creation_date DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                item_expiration_date DATETIME NOT NULL,
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "item_creation_date", "item_expiration_date"},
+    },
+    {
+        Name: "item_barcode",
+        CreateStmt: `
+            CREATE TABLE item_barcode (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                barcode VARCHAR(32) NOT NULL UNIQUE,
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "barcode", "createDate"},
+    },
+    {
+        Name: "product_catalog",
+        CreateStmt: `
+            CREATE TABLE product_catalog (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                barcode VARCHAR(32) NOT NULL UNIQUE,
+                product_name VARCHAR(255) NOT NULL,
+                brand VARCHAR(255),
+                category VARCHAR(255),
+                quantity_label VARCHAR(64),
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+            );
+        `,
+        ExpectedCols: []string{"id", "barcode", "product_name", "brand", "category", "quantity_label", "lastModifiedDate"},
+    },
+    {
+        Name: "scan_queue",
+        CreateStmt: `
+            CREATE TABLE scan_queue (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                barcode VARCHAR(32) NOT NULL UNIQUE,
+                scan_mode VARCHAR(8) NOT NULL,
+                scan_count INT NOT NULL DEFAULT 1,
+                first_scanned DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                last_scanned DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+            );
+        `,
+        ExpectedCols: []string{"id", "barcode", "scan_mode", "scan_count", "first_scanned", "last_scanned"},
+    },
+    {
+        // item_history has no foreign key and keeps the item's name so the history
+        // outlives a deleted item.
+        Name: "item_history",
+        CreateStmt: `
+            CREATE TABLE item_history (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                item_name VARCHAR(255) NOT NULL,
+                event VARCHAR(16) NOT NULL,
+                units INT NOT NULL,
+                quantity_after INT NOT NULL,
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                INDEX (item_id, createDate)
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "item_name", "event", "units", "quantity_after", "createDate"},
+    },
+    {
+        Name: "item_location",
+        CreateStmt: `
+            CREATE TABLE item_location (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                location_name VARCHAR(255) NOT NULL UNIQUE
+            );
+        `,
+        ExpectedCols: []string{"id", "location_name"},
+    },
+    {
+        // item_location_xref stores where an item is kept; an item has at most one location.
+        Name: "item_location_xref",
+        CreateStmt: `
+            CREATE TABLE item_location_xref (
+                item_id INT PRIMARY KEY,
+                location_id INT NOT NULL,
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE,
+                FOREIGN KEY (location_id) REFERENCES item_location(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"item_id", "location_id"},
+    },
+    {
+        Name: "item_alias",
+        CreateStmt: `
+            CREATE TABLE item_alias (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                alias VARCHAR(255) NOT NULL,
+                UNIQUE (item_id, alias),
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "alias"},
+    },
+    {
+        // item_search holds one text document per item for the FULLTEXT index. It is
+        // derived from the other tables and can be rebuilt with RebuildSearchIndex.
+        Name: "item_search",
+        CreateStmt: `
+            CREATE TABLE item_search (
+                item_id INT PRIMARY KEY,
+                search_text TEXT NOT NULL,
+                FULLTEXT (search_text),
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"item_id", "search_text"},
+    },
+    {
+        Name: "item_tag",
+        CreateStmt: `
+            CREATE TABLE item_tag (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                tag_name VARCHAR(64) NOT NULL UNIQUE
+            );
+        `,
+        ExpectedCols: []string{"id", "tag_name"},
+    },
+    {
+        Name: "item_tag_xref",
+        CreateStmt: `
+            CREATE TABLE item_tag_xref (
+                item_id INT NOT NULL,
+                tag_id INT NOT NULL,
+                PRIMARY KEY (item_id, tag_id),
+                INDEX (tag_id),
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE,
+                FOREIGN KEY (tag_id) REFERENCES item_tag(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"item_id", "tag_id"},
+    },
+    {
+        Name: "item_note",
+        CreateStmt: `
+            CREATE TABLE item_note (
+                item_id INT PRIMARY KEY,
+                note TEXT NOT NULL,
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"item_id", "note", "lastModifiedDate"},
+    },
+    {
+        Name: "item_photo",
+        CreateStmt: `
+            CREATE TABLE item_photo (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                file_name VARCHAR(80) NOT NULL,
+                content_type VARCHAR(32) NOT NULL,
+                width INT NOT NULL,
+                height INT NOT NULL,
+                size_bytes INT NOT NULL,
+                caption VARCHAR(255) NOT NULL DEFAULT '',
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                UNIQUE KEY (item_id, file_name),
+                INDEX (file_name),
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "file_name", "content_type", "width", "height", "size_bytes", "caption", "createDate"},
+    },
+    {
+        Name: "item_asset",
+        CreateStmt: `
+            CREATE TABLE item_asset (
+                item_id INT PRIMARY KEY,
+                manufacturer VARCHAR(128) NOT NULL DEFAULT '',
+                model VARCHAR(128) NOT NULL DEFAULT '',
+                serial_number VARCHAR(128) NOT NULL DEFAULT '',
+                purchase_date DATE NULL,
+                purchase_price DECIMAL(12,2) NULL,
+                warranty_expiry DATE NULL,
+                current_value DECIMAL(12,2) NULL,
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE
+            );
+        `,
+        ExpectedCols: []string{"item_id", "manufacturer", "model", "serial_number", "purchase_date", "purchase_price", "warranty_expiry", "current_value", "lastModifiedDate"},
+    },
+    {
+        Name: "maintenance_task",
+        CreateStmt: `
+            CREATE TABLE maintenance_task (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                name VARCHAR(128) NOT NULL,
+                every_count INT NOT NULL DEFAULT 0,
+                every_unit VARCHAR(8) NOT NULL DEFAULT '',
+                next_due DATE NULL,
+                consume_item_id INT NULL,
+                consume_units INT NOT NULL DEFAULT 0,
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                lastModifiedDate DATETIME DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+                INDEX (next_due),
+                FOREIGN KEY (item_id) REFERENCES inventory_item(id) ON DELETE CASCADE,
+                FOREIGN KEY (consume_item_id) REFERENCES inventory_item(id) ON DELETE SET NULL
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "name", "every_count", "every_unit", "next_due", "consume_item_id", "consume_units", "createDate", "lastModifiedDate"},
+    },
+    {
+        // maintenance_log has no foreign key on item_id and keeps the item's name, like
+        // item_history, so the log outlives a deleted item.
+        Name: "maintenance_log",
+        CreateStmt: `
+            CREATE TABLE maintenance_log (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                task_id INT NULL,
+                item_id INT NOT NULL,
+                item_name VARCHAR(255) NOT NULL,
+                task_name VARCHAR(128) NOT NULL,
+                due_date DATE NULL,
+                done_date DATE NOT NULL,
+                note VARCHAR(255) NOT NULL DEFAULT '',
+                consumed_item_name VARCHAR(255) NOT NULL DEFAULT '',
+                consumed_units INT NOT NULL DEFAULT 0,
+                createDate DATETIME DEFAULT CURRENT_TIMESTAMP,
+                INDEX (item_id, done_date),
+                FOREIGN KEY (task_id) REFERENCES maintenance_task(id) ON DELETE SET NULL
+            );
+        `,
+        ExpectedCols: []string{"id", "task_id", "item_id", "item_name", "task_name", "due_date", "done_date", "note", "consumed_item_name", "consumed_units", "createDate"},
+    },
+    {
+        // item_loan has no foreign key on item_id and keeps the item's name so returned
+        // loans outlive a deleted item. DeleteItem refuses items with loans still out.
+        Name: "item_loan",
+        CreateStmt: `
+            CREATE TABLE item_loan (
+                id INT AUTO_INCREMENT PRIMARY KEY,
+                item_id INT NOT NULL,
+                item_name VARCHAR(255) NOT NULL,
+                borrower VARCHAR(128) NOT NULL,
+                quantity INT NOT NULL,
+                lent_date DATETIME DEFAULT CURRENT_TIMESTAMP,
+                due_date DATE NULL,
+                returned_date DATETIME NULL,
+                note VARCHAR(255) NOT NULL DEFAULT '',
+                INDEX (returned_date, item_id),
+                INDEX (item_id, lent_date)
+            );
+        `,
+        ExpectedCols: []string{"id", "item_id", "item_name", "borrower", "quantity", "lent_date", "due_date", "returned_date", "note"},
+    },
+}
+
+// indexSpec describes an index added to a table after the table was first released.
+// Name matches the index CreateStmt creates, so new tables already have it.
+type indexSpec struct {
+    Table      string
+    Name       string
+    Definition string
+}
+
+// schemaIndexes lists indexes that existing databases gain through ensureIndexes.
+var schemaIndexes = []indexSpec{
+    // Stock changes address items by name, so names must be unique.
+    {Table: "inventory_item", Name: "item_name", Definition: "UNIQUE INDEX item_name (item_name)"},
+}
+
+// TableNames returns the names of all required tables in creation order.
+func TableNames() []string {
+    names := make([]string, 0, len(schemaTables))
+    for _, table := range schemaTables {
+        names = append(names, table.Name)
+    }
+    return names
+}
+
+// EnsureTables verifies required tables exist and are properly structured.
+func (d *Database) EnsureTables(ctx context.Context) {
+    for _, table := range schemaTables {
+        d.checkAndCreateTable(ctx, table.Name, table.CreateStmt, table.ExpectedCols)
+    }
+    if err := d.ensureIndexes(ctx); err != nil {
+        slog.ErrorContext(ctx, "failed to add index", "error", err)
+        os.Exit(1)
+    }
+
+    if err := d.recordSchemaVersion(ctx); err != nil {
+        slog.ErrorContext(ctx, "failed to record schema version", "error", err)
+        os.Exit(1)
+    }
+}
+
+// CreateMissingTables creates any required table that does not exist without prompting.
+// Unlike EnsureTables it never archives a table; an existing table with an unexpected
+// structure is reported as an error instead.
+func (d *Database) CreateMissingTables(ctx context.Context) error {
+    var mismatched []string
+    for _, table := range schemaTables {
+        exists, err := d.tableExists(ctx, table.Name)
+        if err != nil {
+            return err
+        }
+        if !exists {
+            if _, err := d.conn.ExecContext(ctx, table.CreateStmt); err != nil {
+                return fmt.Errorf("failed to create table '%s': %w", table.Name, err)
+            }
+            slog.InfoContext(ctx, "table created", "table", table.Name)
+            continue
+        }
+        if !d.ValidateTableStructure(ctx, table.Name, table.ExpectedCols) {
+            mismatched = append(mismatched, table.Name)
+        }
+    }
+    if len(mismatched) > 0 {
+        return fmt.Errorf("tables with unexpected structure: %s", strings.Join(mismatched, ", "))
+    }
+    if err := d.ensureIndexes(ctx); err != nil {
+        return err
     }
+    return d.recordSchemaVersion(ctx)
+}
 
-    for _, table := range tables {
-        d.checkAndCreateTable(table.Name, table.CreateStmt, table.ExpectedCols)
+// ensureIndexes adds any index in schemaIndexes that a table is missing. Adding a unique
+// index fails while the table holds duplicates; the error says which index to fix.
+func (d *Database) ensureIndexes(ctx context.Context) error {
+    for _, index := range schemaIndexes {
+        var count int
+        err := d.conn.QueryRowContext(ctx, `
+            SELECT COUNT(*)
+            FROM information_schema.statistics
+            WHERE table_schema = DATABASE()
+            AND table_name = ?
+            AND index_name = ?
+        `, index.Table, index.Name).Scan(&count)
+        if err != nil {
+            return err
+        }
+        if count > 0 {
+            continue
+        }
+        if _, err := d.conn.ExecContext(ctx, "ALTER TABLE "+index.Table+" ADD "+index.Definition); err != nil {
+            return fmt.Errorf("failed to add index '%s' to table '%s' (remove duplicate values first): %w", index.Name, index.Table, err)
+        }
+        slog.InfoContext(ctx, "index added", "table", index.Table, "index", index.Name)
     }
+    return nil
+}
+
+// GetSchemaVersion returns the schema version recorded in the database, or 0 if none is recorded.
+func (d *Database) GetSchemaVersion(ctx context.Context) (int, error) {
+    exists, err := d.tableExists(ctx, "schema_info")
+    if err != nil || !exists {
+        return 0, err
+    }
+
+    var version int
+    err = d.conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(schema_version), 0) FROM schema_info`).Scan(&version)
+    return version, err
+}
+
+// recordSchemaVersion stores SchemaVersion in the schema_info table.
+func (d *Database) recordSchemaVersion(ctx context.Context) error {
+    _, err := d.conn.ExecContext(ctx, `
+        INSERT INTO schema_info (id, schema_version) VALUES (1, ?)
+        ON DUPLICATE KEY UPDATE schema_version = VALUES(schema_version)
+    `, SchemaVersion)
+    return err
+}
+
+// tableExists reports whether a table exists in the current schema.
+func (d *Database) tableExists(ctx context.Context, tableName string) (bool, error) {
+    var count int
+    err := d.conn.QueryRowContext(ctx, `
+        SELECT COUNT(*)
+        FROM information_schema.tables
+        WHERE table_schema = DATABASE()
+        AND table_name = ?
+    `, tableName).Scan(&count)
+    return count > 0, err
 }
 
 // checkAndCreateTable verifies a table exists and matches the expected structure.
-func (d *Database) checkAndCreateTable(tableName, createStmt string, expectedCols []string) {
-    row := d.conn.QueryRow(`
+func (d *Database) checkAndCreateTable(ctx context.Context, tableName, createStmt string, expectedCols []string) {
+    row := d.conn.QueryRowContext(ctx, `
         SELECT COUNT(*)
         FROM information_schema.tables
         WHERE table_schema = DATABASE()
@@ -93,42 +487,42 @@ func (d *Database) checkAndCreateTable(tableName, createStmt string, expectedCol
     exists := (err == nil && count > 0)
 
     if !exists {
-        fmt.Printf("Table '%s' does not exist.\n", tableName)
+        slog.WarnContext(ctx, "table does not exist", "table", tableName)
         if confirm(fmt.Sprintf("Create table '%s'? (yes/no): ", tableName)) {
-            if _, err := d.conn.Exec(createStmt); err != nil {
-                fmt.Printf("Failed to create table '%s': %v\n", tableName, err)
+            if _, err := d.conn.ExecContext(ctx, createStmt); err != nil {
+                slog.ErrorContext(ctx, "failed to create table", "table", tableName, "error", err)
                 os.Exit(1)
             }
-            fmt.Printf("Table '%s' created successfully.\n", tableName)
+            slog.InfoContext(ctx, "table created", "table", tableName)
         } else {
-            fmt.Printf("Table '%s' creation aborted by user.\n", tableName)
+            slog.ErrorContext(ctx, "table creation aborted by user", "table", tableName)
             os.Exit(1)
         }
         return
     }
 
-    if !d.ValidateTableStructure(tableName, expectedCols) {
-        fmt.Printf("Table '%s' exists but is improperly structured.\n", tableName)
+    if !d.ValidateTableStructure(ctx, tableName, expectedCols) {
+        slog.WarnContext(ctx, "table exists but is improperly structured", "table", tableName)
         if confirm(fmt.Sprintf("Archive and recreate table '%s'? (yes/no): ", tableName)) {
             timestamp := time.Now().Format("20060102_150405")
             archiveName := fmt.Sprintf("%s_%s", tableName, timestamp)
             renameStmt := fmt.Sprintf("RENAME TABLE %s TO %s", tableName, archiveName)
-            if _, err := d.conn.Exec(renameStmt); err != nil {
-                fmt.Printf("Failed to archive table '%s': %v\n", tableName, err)
+            if _, err := d.conn.ExecContext(ctx, renameStmt); err != nil {
+                slog.ErrorContext(ctx, "failed to archive table", "table", tableName, "error", err)
                 os.Exit(1)
             }
-            fmt.Printf("Table '%s' archived as '%s'.\n", tableName, archiveName)
+            slog.InfoContext(ctx, "table archived", "table", tableName, "archive", archiveName)
 
-            if _, err := d.conn.Exec(createStmt); err != nil {
-                fmt.Printf("Failed to create new table '%s': %v\n", tableName, err)
+            if _, err := d.conn.ExecContext(ctx, createStmt); err != nil {
+                slog.ErrorContext(ctx, "failed to create new table", "table", tableName, "error", err)
                 os.Exit(1)
             }
-            fmt.Printf("Table '%s' created successfully.\n", tableName)
+            slog.InfoContext(ctx, "table created", "table", tableName)
         } else {
-            fmt.Printf("Table '%s' recreation aborted by user.\n", tableName)
+            slog.ErrorContext(ctx, "table recreation aborted by user", "table", tableName)
             os.Exit(1)
         }
     } else {
-        fmt.Printf("Table '%s' exists and structure is valid.\n", tableName)
+        slog.DebugContext(ctx, "table structure is valid", "table", tableName)
     }
 }
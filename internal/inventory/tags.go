@@ -0,0 +1,372 @@
+package inventory
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// maxNoteBytes is the most a note can hold; item_note.note is a TEXT column.
+const maxNoteBytes = 65535
+
+// normalizeTagName collapses runs of whitespace in a tag name and checks its length.
+func normalizeTagName(name string) (string, error) {
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        return "", fmt.Errorf("tag name is required")
+    }
+    if utf8.RuneCountInString(name) > 64 {
+        return "", fmt.Errorf("tag name must be at most 64 characters")
+    }
+    return name, nil
+}
+
+// GetItemTags retrieves every tag with the number of items carrying it.
+func GetItemTags(ctx context.Context, db *Database) ([]ItemTag, error) {
+    defer observe("GetItemTags", time.Now())
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT g.id, g.tag_name, COUNT(x.item_id)
+        FROM item_tag g
+        LEFT JOIN item_tag_xref x ON x.tag_id = g.id
+        GROUP BY g.id, g.tag_name
+        ORDER BY g.tag_name ASC
+    `)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    tags := []ItemTag{}
+    for rows.Next() {
+        var t ItemTag
+        if err := rows.Scan(&t.ID, &t.Name, &t.Items); err != nil {
+            return nil, err
+        }
+        tags = append(tags, t)
+    }
+    return tags, rows.Err()
+}
+
+// AddItemTag inserts a new tag and returns its ID.
+func AddItemTag(ctx context.Context, db *Database, name string) (int64, error) {
+    defer observe("AddItemTag", time.Now())
+    name, err := normalizeTagName(name)
+    if err != nil {
+        return 0, err
+    }
+    result, err := db.conn.ExecContext(ctx, `INSERT INTO item_tag (tag_name) VALUES (?)`, name)
+    if err != nil {
+        return 0, err
+    }
+    return result.LastInsertId()
+}
+
+// RenameItemTag renames a tag on every item carrying it. It returns sql.ErrNoRows when
+// the tag does not exist.
+func RenameItemTag(ctx context.Context, db *Database, id int, name string) error {
+    defer observe("RenameItemTag", time.Now())
+    name, err := normalizeTagName(name)
+    if err != nil {
+        return err
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var current string
+    if err := tx.QueryRowContext(ctx, `SELECT tag_name FROM item_tag WHERE id = ? FOR UPDATE`, id).Scan(&current); err != nil {
+        return err
+    }
+    if _, err := tx.ExecContext(ctx, `UPDATE item_tag SET tag_name = ? WHERE id = ?`, name, id); err != nil {
+        return err
+    }
+    itemIDs, err := taggedItemIDs(ctx, tx, id)
+    if err != nil {
+        return err
+    }
+    for _, itemID := range itemIDs {
+        if err := refreshItemSearch(ctx, tx, itemID); err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// DeleteItemTag deletes a tag and removes it from every item. It returns sql.ErrNoRows
+// when the tag does not exist.
+func DeleteItemTag(ctx context.Context, db *Database, id int) error {
+    defer observe("DeleteItemTag", time.Now())
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    itemIDs, err := taggedItemIDs(ctx, tx, id)
+    if err != nil {
+        return err
+    }
+    result, err := tx.ExecContext(ctx, `DELETE FROM item_tag WHERE id = ?`, id)
+    if err != nil {
+        return err
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return sql.ErrNoRows
+    }
+    for _, itemID := range itemIDs {
+        if err := refreshItemSearch(ctx, tx, itemID); err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// taggedItemIDs lists the items carrying a tag.
+func taggedItemIDs(ctx context.Context, tx *sql.Tx, tagID int) ([]int64, error) {
+    rows, err := tx.QueryContext(ctx, `SELECT item_id FROM item_tag_xref WHERE tag_id = ?`, tagID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var ids []int64
+    for rows.Next() {
+        var id int64
+        if err := rows.Scan(&id); err != nil {
+            return nil, err
+        }
+        ids = append(ids, id)
+    }
+    return ids, rows.Err()
+}
+
+// TagItems puts a tag on each of the given items, creating the tag if it is new, and
+// returns how many items gained it. Items that already carry the tag are left alone.
+// Nothing is tagged if any of the items does not exist.
+func TagItems(ctx context.Context, db *Database, tag string, itemIDs []int) (int, error) {
+    defer observe("TagItems", time.Now())
+    tag, err := normalizeTagName(tag)
+    if err != nil {
+        return 0, err
+    }
+    itemIDs = uniqueIDs(itemIDs)
+    if len(itemIDs) == 0 {
+        return 0, fmt.Errorf("no items given")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    placeholders, args := idList(itemIDs)
+    var found int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM inventory_item WHERE id IN (`+placeholders+`)`, args...).Scan(&found); err != nil {
+        return 0, err
+    }
+    if found != len(itemIDs) {
+        return 0, fmt.Errorf("%d of the %d items do not exist", len(itemIDs)-found, len(itemIDs))
+    }
+
+    // LAST_INSERT_ID(id) makes LastInsertId return the existing tag's ID too.
+    result, err := tx.ExecContext(ctx, `
+        INSERT INTO item_tag (tag_name) VALUES (?)
+        ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)
+    `, tag)
+    if err != nil {
+        return 0, err
+    }
+    tagID, err := result.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+
+    tagged := 0
+    for _, itemID := range itemIDs {
+        result, err := tx.ExecContext(ctx, `INSERT IGNORE INTO item_tag_xref (item_id, tag_id) VALUES (?, ?)`, itemID, tagID)
+        if err != nil {
+            return 0, err
+        }
+        if n, _ := result.RowsAffected(); n > 0 {
+            tagged++
+            if err := refreshItemSearch(ctx, tx, int64(itemID)); err != nil {
+                return 0, err
+            }
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    return tagged, nil
+}
+
+// UntagItems removes a tag from each of the given items and returns how many carried it.
+// The tag itself is kept.
+func UntagItems(ctx context.Context, db *Database, tag string, itemIDs []int) (int, error) {
+    defer observe("UntagItems", time.Now())
+    tag, err := normalizeTagName(tag)
+    if err != nil {
+        return 0, err
+    }
+    itemIDs = uniqueIDs(itemIDs)
+    if len(itemIDs) == 0 {
+        return 0, fmt.Errorf("no items given")
+    }
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    untagged := 0
+    for _, itemID := range itemIDs {
+        result, err := tx.ExecContext(ctx, `
+            DELETE x FROM item_tag_xref x
+            JOIN item_tag g ON g.id = x.tag_id
+            WHERE x.item_id = ? AND g.tag_name = ?
+        `, itemID, tag)
+        if err != nil {
+            return 0, err
+        }
+        if n, _ := result.RowsAffected(); n > 0 {
+            untagged++
+            if err := refreshItemSearch(ctx, tx, int64(itemID)); err != nil {
+                return 0, err
+            }
+        }
+    }
+    if err := tx.Commit(); err != nil {
+        return 0, err
+    }
+    return untagged, nil
+}
+
+// itemTagNames retrieves the tag names of the given items, or of every item when itemIDs
+// is nil, grouped by item ID.
+func itemTagNames(ctx context.Context, db *Database, itemIDs []int) (map[int][]string, error) {
+    where, args := itemIDCondition("x.item_id", itemIDs)
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT x.item_id, g.tag_name
+        FROM item_tag_xref x
+        JOIN item_tag g ON g.id = x.tag_id`+where+`
+        ORDER BY x.item_id ASC, g.tag_name ASC
+    `, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    tags := map[int][]string{}
+    for rows.Next() {
+        var itemID int
+        var name string
+        if err := rows.Scan(&itemID, &name); err != nil {
+            return nil, err
+        }
+        tags[itemID] = append(tags[itemID], name)
+    }
+    return tags, rows.Err()
+}
+
+// attachTags fills in the Tags of each item.
+func attachTags(ctx context.Context, db *Database, items []InventoryItemWithDetails) error {
+    tags, err := itemTagNames(ctx, db, itemIDs(items))
+    if err != nil {
+        return err
+    }
+    for i := range items {
+        items[i].Tags = tags[items[i].ID]
+        if items[i].Tags == nil {
+            items[i].Tags = []string{}
+        }
+    }
+    return nil
+}
+
+// GetItemNote retrieves an item's markdown note, or "" if it has none.
+func GetItemNote(ctx context.Context, db *Database, itemID int) (string, error) {
+    defer observe("GetItemNote", time.Now())
+    var note string
+    err := db.conn.QueryRowContext(ctx, `SELECT note FROM item_note WHERE item_id = ?`, itemID).Scan(&note)
+    if errors.Is(err, sql.ErrNoRows) {
+        return "", nil
+    }
+    return note, err
+}
+
+// SetItemNote replaces an item's markdown note; a blank note removes it. It returns
+// sql.ErrNoRows when the item does not exist.
+func SetItemNote(ctx context.Context, db *Database, itemID int, note string) error {
+    defer observe("SetItemNote", time.Now())
+    if len(note) > maxNoteBytes {
+        return fmt.Errorf("note must be at most %d bytes", maxNoteBytes)
+    }
+
+    var id int
+    if err := db.conn.QueryRowContext(ctx, `SELECT id FROM inventory_item WHERE id = ?`, itemID).Scan(&id); err != nil {
+        return err
+    }
+
+    var err error
+    if strings.TrimSpace(note) == "" {
+        _, err = db.conn.ExecContext(ctx, `DELETE FROM item_note WHERE item_id = ?`, itemID)
+    } else {
+        _, err = db.conn.ExecContext(ctx, `
+            INSERT INTO item_note (item_id, note) VALUES (?, ?)
+            ON DUPLICATE KEY UPDATE note = VALUES(note)
+        `, itemID, note)
+    }
+    if err != nil {
+        return err
+    }
+    return refreshItemSearch(ctx, db.conn, int64(itemID))
+}
+
+// uniqueTagNames returns the tag names with whitespace collapsed and duplicates removed,
+// comparing case-insensitively as the tag_name collation does.
+func uniqueTagNames(names []string) []string {
+    seen := map[string]bool{}
+    var unique []string
+    for _, name := range names {
+        name = strings.Join(strings.Fields(name), " ")
+        key := strings.ToLower(name)
+        if seen[key] {
+            continue
+        }
+        seen[key] = true
+        unique = append(unique, name)
+    }
+    return unique
+}
+
+// uniqueIDs returns the IDs sorted with duplicates removed.
+func uniqueIDs(ids []int) []int {
+    sorted := append([]int(nil), ids...)
+    sort.Ints(sorted)
+    unique := sorted[:0]
+    for _, id := range sorted {
+        if len(unique) == 0 || id != unique[len(unique)-1] {
+            unique = append(unique, id)
+        }
+    }
+    return unique
+}
+
+// idList returns "?, ?, ..." and the arguments for an IN clause over ids.
+func idList(ids []int) (string, []interface{}) {
+    args := make([]interface{}, len(ids))
+    for i, id := range ids {
+        args[i] = id
+    }
+    return strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", "), args
+}
@@ -0,0 +1,891 @@
+package inventory
+
+import (
+    "archive/zip"
+    "bytes"
+    "context"
+    "database/sql"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// ExportFormat identifies documents produced by ExportInventory.
+const ExportFormat = "myhomeinventory-export"
+
+// ExportFormatVersion is bumped whenever the export document layout changes.
+const ExportFormatVersion = 1
+
+// exportTimeFormat is used for dates in CSV files.
+const exportTimeFormat = "2006-01-02 15:04:05"
+
+// Export tables, used for CSV file names and the table parameter of imports.
+const (
+    ExportTableItems         = "items"
+    ExportTableTypes         = "types"
+    ExportTableSubstitutions = "substitutions"
+    ExportTableExpirations   = "expirations"
+)
+
+// ExportTables lists the exportable tables in the order they must be imported.
+var ExportTables = []string{ExportTableTypes, ExportTableSubstitutions, ExportTableItems, ExportTableExpirations}
+
+// ExportDocument is a portable snapshot of the inventory. Items reference types,
+// substitutions and each other by name so documents can move between instances.
+type ExportDocument struct {
+    Format            string             `json:"format"`
+    Version           int                `json:"version"`
+    ExportedAt        time.Time          `json:"exportedAt"`
+    ItemTypes         []string           `json:"itemTypes"`
+    ItemSubstitutions []string           `json:"itemSubstitutions"`
+    Items             []ExportItem       `json:"items"`
+    Expirations       []ExportExpiration `json:"expirations"`
+}
+
+// ExportItem is an inventory item in an ExportDocument.
+type ExportItem struct {
+    ItemName             string    `json:"itemName"`
+    ItemQTY              int       `json:"itemQTY"`
+    MinimumQTY           int       `json:"minimumQTY"`
+    ItemUsedToDate       int       `json:"itemUsedToDate"`
+    ItemTotalTossed      int       `json:"itemTotalTossed"`
+    ItemTypeName         string    `json:"itemTypeName"`
+    ItemSubstitutionName string    `json:"itemSubstitutionName"`
+    ItemExpirationPeriod int       `json:"itemExpirationPeriod"`
+    Barcodes             []string  `json:"barcodes"`
+    CreateDate           time.Time `json:"createDate"`
+
+    row int
+}
+
+// ExportExpiration is one stock unit from item_expiration_xref in an ExportDocument.
+type ExportExpiration struct {
+    ItemName       string    `json:"itemName"`
+    CreationDate   time.Time `json:"creationDate"`
+    ExpirationDate time.Time `json:"expirationDate"`
+
+    row int
+}
+
+// ImportOptions controls how ImportInventory applies a document.
+type ImportOptions struct {
+    // DryRun validates and applies the import inside a transaction that is always rolled back.
+    DryRun bool `json:"dryRun"`
+    // CreateMissing creates item types and substitutions referenced by items that do not exist yet.
+    CreateMissing bool `json:"createMissing"`
+}
+
+// ImportRowError describes a problem with one row of an import.
+type ImportRowError struct {
+    Table   string `json:"table"`
+    Row     int    `json:"row"`
+    Field   string `json:"field,omitempty"`
+    Message string `json:"message"`
+}
+
+// Error implements the error interface.
+func (e ImportRowError) Error() string {
+    if e.Field != "" {
+        return fmt.Sprintf("%s row %d: %s: %s", e.Table, e.Row, e.Field, e.Message)
+    }
+    return fmt.Sprintf("%s row %d: %s", e.Table, e.Row, e.Message)
+}
+
+// ImportReport summarizes an import. Nothing is written unless Applied is true.
+type ImportReport struct {
+    DryRun               bool             `json:"dryRun"`
+    Applied              bool             `json:"applied"`
+    TypesCreated         int              `json:"typesCreated"`
+    SubstitutionsCreated int              `json:"substitutionsCreated"`
+    ItemsCreated         int              `json:"itemsCreated"`
+    ItemsUpdated         int              `json:"itemsUpdated"`
+    ExpirationsImported  int              `json:"expirationsImported"`
+    Errors               []ImportRowError `json:"errors"`
+}
+
+// ExportInventory builds an ExportDocument from the current database contents.
+func ExportInventory(ctx context.Context, db *Database) (ExportDocument, error) {
+    defer observe("ExportInventory", time.Now())
+    doc := ExportDocument{
+        Format:     ExportFormat,
+        Version:    ExportFormatVersion,
+        ExportedAt: time.Now().UTC(),
+    }
+
+    types, err := GetItemTypes(ctx, db)
+    if err != nil {
+        return doc, err
+    }
+    doc.ItemTypes = []string{}
+    for _, t := range types {
+        doc.ItemTypes = append(doc.ItemTypes, t.Name)
+    }
+
+    substitutions, err := GetItemSubstitutions(ctx, db)
+    if err != nil {
+        return doc, err
+    }
+    doc.ItemSubstitutions = []string{}
+    for _, s := range substitutions {
+        doc.ItemSubstitutions = append(doc.ItemSubstitutions, s.Name)
+    }
+
+    barcodes, err := GetItemBarcodes(ctx, db, nil)
+    if err != nil {
+        return doc, err
+    }
+
+    rows, err := db.conn.QueryContext(ctx, `
+        SELECT i.id, i.item_name, i.itemQTY, i.minimumQTY, i.itemUsedToDate, i.item_total_tossed,
+            t.type_name, s.substitution_name, i.item_expiration_period, i.createDate
+        FROM inventory_item i
+        LEFT JOIN item_type t ON i.item_type_id = t.id
+        LEFT JOIN item_substitution s ON i.item_substitution_id = s.id
+        ORDER BY i.id ASC
+    `)
+    if err != nil {
+        return doc, err
+    }
+    defer rows.Close()
+
+    doc.Items = []ExportItem{}
+    for rows.Next() {
+        var id int
+        var item ExportItem
+        var typeName, substitutionName sql.NullString
+        var period, tossed sql.NullInt64
+        if err := rows.Scan(&id, &item.ItemName, &item.ItemQTY, &item.MinimumQTY, &item.ItemUsedToDate, &tossed,
+            &typeName, &substitutionName, &period, &item.CreateDate); err != nil {
+            return doc, err
+        }
+        item.ItemTotalTossed = int(tossed.Int64)
+        item.ItemTypeName = typeName.String
+        item.ItemSubstitutionName = substitutionName.String
+        item.ItemExpirationPeriod = int(period.Int64)
+        item.Barcodes = barcodes[id]
+        if item.Barcodes == nil {
+            item.Barcodes = []string{}
+        }
+        doc.Items = append(doc.Items, item)
+    }
+    if err := rows.Err(); err != nil {
+        return doc, err
+    }
+
+    expRows, err := db.conn.QueryContext(ctx, `
+        SELECT i.item_name, x.item_creation_date, x.item_expiration_date
+        FROM item_expiration_xref x
+        JOIN inventory_item i ON x.item_id = i.id
+        ORDER BY i.id ASC, x.item_expiration_date ASC
+    `)
+    if err != nil {
+        return doc, err
+    }
+    defer expRows.Close()
+
+    doc.Expirations = []ExportExpiration{}
+    for expRows.Next() {
+        var e ExportExpiration
+        if err := expRows.Scan(&e.ItemName, &e.CreationDate, &e.ExpirationDate); err != nil {
+            return doc, err
+        }
+        doc.Expirations = append(doc.Expirations, e)
+    }
+    return doc, expRows.Err()
+}
+
+// csvHeaders holds the column names of each exported CSV table.
+var csvHeaders = map[string][]string{
+    ExportTableTypes:         {"type_name"},
+    ExportTableSubstitutions: {"substitution_name"},
+    ExportTableItems: {
+        "item_name", "item_qty", "minimum_qty", "item_used_to_date", "item_total_tossed",
+        "item_type", "item_substitution", "item_expiration_period", "barcodes", "create_date",
+    },
+    ExportTableExpirations: {"item_name", "item_creation_date", "item_expiration_date"},
+}
+
+// WriteExportCSV writes one table of an ExportDocument as CSV.
+func WriteExportCSV(w io.Writer, doc ExportDocument, table string) error {
+    header, ok := csvHeaders[table]
+    if !ok {
+        return fmt.Errorf("unknown export table %q", table)
+    }
+
+    cw := csv.NewWriter(w)
+    if err := cw.Write(header); err != nil {
+        return err
+    }
+
+    switch table {
+    case ExportTableTypes:
+        for _, name := range doc.ItemTypes {
+            cw.Write([]string{name})
+        }
+    case ExportTableSubstitutions:
+        for _, name := range doc.ItemSubstitutions {
+            cw.Write([]string{name})
+        }
+    case ExportTableItems:
+        for _, item := range doc.Items {
+            cw.Write([]string{
+                item.ItemName,
+                strconv.Itoa(item.ItemQTY),
+                strconv.Itoa(item.MinimumQTY),
+                strconv.Itoa(item.ItemUsedToDate),
+                strconv.Itoa(item.ItemTotalTossed),
+                item.ItemTypeName,
+                item.ItemSubstitutionName,
+                strconv.Itoa(item.ItemExpirationPeriod),
+                strings.Join(item.Barcodes, ";"),
+                item.CreateDate.Format(exportTimeFormat),
+            })
+        }
+    case ExportTableExpirations:
+        for _, e := range doc.Expirations {
+            cw.Write([]string{
+                e.ItemName,
+                e.CreationDate.Format(exportTimeFormat),
+                e.ExpirationDate.Format(exportTimeFormat),
+            })
+        }
+    }
+
+    cw.Flush()
+    return cw.Error()
+}
+
+// WriteExportZip writes every table of an ExportDocument as CSV files inside a zip archive.
+func WriteExportZip(w io.Writer, doc ExportDocument) error {
+    zw := zip.NewWriter(w)
+    for _, table := range ExportTables {
+        f, err := zw.Create(table + ".csv")
+        if err != nil {
+            return err
+        }
+        if err := WriteExportCSV(f, doc, table); err != nil {
+            return err
+        }
+    }
+    return zw.Close()
+}
+
+// ReadImportJSON parses an ExportDocument.
+func ReadImportJSON(r io.Reader) (ExportDocument, error) {
+    var doc ExportDocument
+    if err := json.NewDecoder(r).Decode(&doc); err != nil {
+        return doc, fmt.Errorf("invalid JSON document: %w", err)
+    }
+    if doc.Format != "" && doc.Format != ExportFormat {
+        return doc, fmt.Errorf("unsupported document format %q", doc.Format)
+    }
+    if doc.Version > ExportFormatVersion {
+        return doc, fmt.Errorf("document version %d is newer than supported version %d", doc.Version, ExportFormatVersion)
+    }
+    for i := range doc.Items {
+        doc.Items[i].row = i + 1
+    }
+    for i := range doc.Expirations {
+        doc.Expirations[i].row = i + 1
+    }
+    return doc, nil
+}
+
+// ReadImportCSV parses one CSV table into an ExportDocument. When table is empty it is
+// inferred from the header row. Rows that cannot be parsed are returned as row errors.
+func ReadImportCSV(r io.Reader, table string) (ExportDocument, []ImportRowError, error) {
+    doc := ExportDocument{Format: ExportFormat, Version: ExportFormatVersion}
+
+    cr := csv.NewReader(r)
+    cr.FieldsPerRecord = -1
+    cr.TrimLeadingSpace = true
+
+    header, err := cr.Read()
+    if err != nil {
+        return doc, nil, fmt.Errorf("failed to read CSV header: %w", err)
+    }
+    columns := map[string]int{}
+    for i, name := range header {
+        columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+    }
+
+    if table == "" {
+        table = inferCSVTable(columns)
+        if table == "" {
+            return doc, nil, fmt.Errorf("cannot tell which table the CSV holds from its header")
+        }
+    }
+    required, ok := csvHeaders[table]
+    if !ok {
+        return doc, nil, fmt.Errorf("unknown import table %q", table)
+    }
+    if _, ok := columns[required[0]]; !ok {
+        return doc, nil, fmt.Errorf("CSV header is missing required column %q", required[0])
+    }
+
+    rowErrors := []ImportRowError{}
+    for line := 2; ; line++ {
+        record, err := cr.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            var parseErr *csv.ParseError
+            if errors.As(err, &parseErr) {
+                rowErrors = append(rowErrors, ImportRowError{Table: table, Row: line, Message: parseErr.Err.Error()})
+                continue
+            }
+            return doc, rowErrors, err
+        }
+
+        p := csvRow{table: table, row: line, columns: columns, record: record}
+        switch table {
+        case ExportTableTypes:
+            doc.ItemTypes = append(doc.ItemTypes, p.str("type_name"))
+        case ExportTableSubstitutions:
+            doc.ItemSubstitutions = append(doc.ItemSubstitutions, p.str("substitution_name"))
+        case ExportTableItems:
+            item := ExportItem{
+                ItemName:             p.str("item_name"),
+                ItemQTY:              p.int("item_qty"),
+                MinimumQTY:           p.int("minimum_qty"),
+                ItemUsedToDate:       p.int("item_used_to_date"),
+                ItemTotalTossed:      p.int("item_total_tossed"),
+                ItemTypeName:         p.str("item_type"),
+                ItemSubstitutionName: p.str("item_substitution"),
+                ItemExpirationPeriod: p.int("item_expiration_period"),
+                CreateDate:           p.time("create_date"),
+                row:                  line,
+            }
+            for _, code := range strings.FieldsFunc(p.str("barcodes"), func(r rune) bool { return r == ';' || r == ',' }) {
+                item.Barcodes = append(item.Barcodes, strings.TrimSpace(code))
+            }
+            doc.Items = append(doc.Items, item)
+        case ExportTableExpirations:
+            doc.Expirations = append(doc.Expirations, ExportExpiration{
+                ItemName:       p.str("item_name"),
+                CreationDate:   p.time("item_creation_date"),
+                ExpirationDate: p.time("item_expiration_date"),
+                row:            line,
+            })
+        }
+        rowErrors = append(rowErrors, p.errors...)
+    }
+
+    return doc, rowErrors, nil
+}
+
+// ReadImportZip parses a zip archive of CSV tables as written by WriteExportZip.
+func ReadImportZip(r io.ReaderAt, size int64) (ExportDocument, []ImportRowError, error) {
+    doc := ExportDocument{Format: ExportFormat, Version: ExportFormatVersion}
+    rowErrors := []ImportRowError{}
+
+    zr, err := zip.NewReader(r, size)
+    if err != nil {
+        return doc, nil, fmt.Errorf("invalid zip archive: %w", err)
+    }
+
+    for _, f := range zr.File {
+        table := strings.TrimSuffix(f.Name[strings.LastIndex(f.Name, "/")+1:], ".csv")
+        if _, ok := csvHeaders[table]; !ok {
+            continue
+        }
+        rc, err := f.Open()
+        if err != nil {
+            return doc, rowErrors, err
+        }
+        part, errs, err := ReadImportCSV(rc, table)
+        rc.Close()
+        if err != nil {
+            return doc, rowErrors, fmt.Errorf("%s: %w", f.Name, err)
+        }
+        doc.ItemTypes = append(doc.ItemTypes, part.ItemTypes...)
+        doc.ItemSubstitutions = append(doc.ItemSubstitutions, part.ItemSubstitutions...)
+        doc.Items = append(doc.Items, part.Items...)
+        doc.Expirations = append(doc.Expirations, part.Expirations...)
+        rowErrors = append(rowErrors, errs...)
+    }
+    return doc, rowErrors, nil
+}
+
+// inferCSVTable guesses the table a CSV file holds from its header columns.
+func inferCSVTable(columns map[string]int) string {
+    if _, ok := columns["item_expiration_date"]; ok {
+        return ExportTableExpirations
+    }
+    if _, ok := columns["item_name"]; ok {
+        return ExportTableItems
+    }
+    if _, ok := columns["type_name"]; ok {
+        return ExportTableTypes
+    }
+    if _, ok := columns["substitution_name"]; ok {
+        return ExportTableSubstitutions
+    }
+    return ""
+}
+
+// csvRow reads typed fields from a CSV record, collecting conversion errors.
+type csvRow struct {
+    table   string
+    row     int
+    columns map[string]int
+    record  []string
+    errors  []ImportRowError
+}
+
+// str returns the trimmed value of a column, or "" when the column is absent.
+func (p *csvRow) str(name string) string {
+    i, ok := p.columns[name]
+    if !ok || i >= len(p.record) {
+        return ""
+    }
+    return strings.TrimSpace(p.record[i])
+}
+
+// int returns a column parsed as an integer; blank values are zero.
+func (p *csvRow) int(name string) int {
+    v := p.str(name)
+    if v == "" {
+        return 0
+    }
+    n, err := strconv.Atoi(v)
+    if err != nil {
+        p.errors = append(p.errors, ImportRowError{Table: p.table, Row: p.row, Field: name, Message: fmt.Sprintf("%q is not a whole number", v)})
+    }
+    return n
+}
+
+// time returns a column parsed as a date or date-time; blank values are the zero time.
+func (p *csvRow) time(name string) time.Time {
+    v := p.str(name)
+    if v == "" {
+        return time.Time{}
+    }
+    for _, layout := range []string{exportTimeFormat, time.RFC3339, "2006-01-02"} {
+        if t, err := time.ParseInLocation(layout, v, time.Local); err == nil {
+            return t
+        }
+    }
+    p.errors = append(p.errors, ImportRowError{Table: p.table, Row: p.row, Field: name, Message: fmt.Sprintf("%q is not a valid date", v)})
+    return time.Time{}
+}
+
+// DetectImportFormat returns the import format: the explicit one when given, otherwise
+// inferred from the file name extension or the content itself.
+func DetectImportFormat(format, filename string, data []byte) string {
+    if format != "" {
+        return strings.ToLower(format)
+    }
+    lower := strings.ToLower(filename)
+    switch {
+    case strings.HasSuffix(lower, ".json"):
+        return "json"
+    case strings.HasSuffix(lower, ".csv"):
+        return "csv"
+    case strings.HasSuffix(lower, ".zip"):
+        return "zip"
+    case len(data) >= 4 && string(data[:4]) == "PK\x03\x04":
+        return "zip"
+    case len(strings.TrimSpace(string(data))) > 0 && strings.TrimSpace(string(data))[0] == '{':
+        return "json"
+    }
+    return "csv"
+}
+
+// ImportData parses data as JSON, CSV or a zip of CSV tables and imports it. Rows that
+// fail to parse are reported alongside validation errors and prevent the import from
+// being applied.
+func ImportData(ctx context.Context, db *Database, data []byte, format, table, filename string, opts ImportOptions) (ImportReport, error) {
+    defer observe("ImportData", time.Now())
+    var doc ExportDocument
+    var parseErrors []ImportRowError
+    var err error
+    switch DetectImportFormat(format, filename, data) {
+    case "json":
+        doc, err = ReadImportJSON(bytes.NewReader(data))
+    case "csv":
+        doc, parseErrors, err = ReadImportCSV(bytes.NewReader(data), table)
+    case "zip":
+        doc, parseErrors, err = ReadImportZip(bytes.NewReader(data), int64(len(data)))
+    default:
+        err = fmt.Errorf("invalid format %q: must be json, csv or zip", format)
+    }
+    if err != nil {
+        return ImportReport{}, err
+    }
+
+    validateOpts := opts
+    if len(parseErrors) > 0 {
+        validateOpts.DryRun = true
+    }
+
+    report, err := ImportInventory(ctx, db, doc, validateOpts)
+    report.DryRun = opts.DryRun
+    if len(parseErrors) > 0 {
+        report.Errors = append(parseErrors, report.Errors...)
+    }
+    return report, err
+}
+
+// ImportInventory validates and applies an ExportDocument inside a single transaction.
+// Items are matched to existing items by name and updated, or created when new.
+// If any row is invalid nothing is written and the problems are listed in the report.
+func ImportInventory(ctx context.Context, db *Database, doc ExportDocument, opts ImportOptions) (ImportReport, error) {
+    defer observe("ImportInventory", time.Now())
+    report := ImportReport{DryRun: opts.DryRun, Errors: []ImportRowError{}}
+
+    tx, err := db.conn.BeginTx(ctx, nil)
+    if err != nil {
+        return report, err
+    }
+    defer tx.Rollback()
+
+    im := importer{ctx: ctx, tx: tx, opts: opts, report: &report}
+    if err := im.run(doc); err != nil {
+        return report, err
+    }
+
+    if len(report.Errors) > 0 || opts.DryRun {
+        return report, nil
+    }
+    if err := tx.Commit(); err != nil {
+        return report, err
+    }
+    report.Applied = true
+    return report, nil
+}
+
+// importer carries the state of one ImportInventory call.
+type importer struct {
+    ctx           context.Context
+    tx            *sql.Tx
+    opts          ImportOptions
+    report        *ImportReport
+    types         map[string]int64
+    substitutions map[string]int64
+    items         map[string]importedItem
+}
+
+// importedItem tracks an item touched by the import.
+type importedItem struct {
+    id               int64
+    qty              int
+    expirationPeriod int
+}
+
+// rowError records a problem with one row.
+func (im *importer) rowError(table string, row int, field, message string) {
+    im.report.Errors = append(im.report.Errors, ImportRowError{Table: table, Row: row, Field: field, Message: message})
+}
+
+// run applies each section of the document in dependency order.
+func (im *importer) run(doc ExportDocument) error {
+    var err error
+    if im.types, err = loadNameIDs(im.ctx, im.tx, `SELECT id, type_name FROM item_type`); err != nil {
+        return err
+    }
+    if im.substitutions, err = loadNameIDs(im.ctx, im.tx, `SELECT id, substitution_name FROM item_substitution`); err != nil {
+        return err
+    }
+    im.items = map[string]importedItem{}
+
+    for i, name := range doc.ItemTypes {
+        if _, err := im.lookupOrCreate(ExportTableTypes, i+1, "type_name", name, true); err != nil {
+            return err
+        }
+    }
+    for i, name := range doc.ItemSubstitutions {
+        if _, err := im.lookupOrCreate(ExportTableSubstitutions, i+1, "substitution_name", name, true); err != nil {
+            return err
+        }
+    }
+
+    // Matched on trimmed names, as importItem and importExpiration store them.
+    withExpirations := map[string]bool{}
+    for _, e := range doc.Expirations {
+        withExpirations[strings.TrimSpace(e.ItemName)] = true
+    }
+
+    for _, item := range doc.Items {
+        if err := im.importItem(item, withExpirations[strings.TrimSpace(item.ItemName)]); err != nil {
+            return err
+        }
+    }
+
+    cleared := map[string]bool{}
+    for _, e := range doc.Expirations {
+        if err := im.importExpiration(e, cleared); err != nil {
+            return err
+        }
+    }
+    return nil
+}
+
+// loadNameIDs reads an id/name lookup table into a map.
+func loadNameIDs(ctx context.Context, tx *sql.Tx, query string) (map[string]int64, error) {
+    rows, err := tx.QueryContext(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    ids := map[string]int64{}
+    for rows.Next() {
+        var id int64
+        var name string
+        if err := rows.Scan(&id, &name); err != nil {
+            return nil, err
+        }
+        ids[strings.ToLower(name)] = id
+    }
+    return ids, rows.Err()
+}
+
+// lookupOrCreate maps a type or substitution name to its ID, creating it when allowed.
+// It returns 0 when the name is unknown and cannot be created; a row error is recorded.
+func (im *importer) lookupOrCreate(table string, row int, field, name string, create bool) (int64, error) {
+    name = strings.TrimSpace(name)
+    if name == "" {
+        im.rowError(table, row, field, "name is required")
+        return 0, nil
+    }
+
+    isType := field == "type_name" || field == "item_type"
+    ids, insert := im.substitutions, `INSERT INTO item_substitution (substitution_name) VALUES (?)`
+    if isType {
+        ids, insert = im.types, `INSERT INTO item_type (type_name) VALUES (?)`
+    }
+
+    if id, ok := ids[strings.ToLower(name)]; ok {
+        return id, nil
+    }
+    if !create {
+        im.rowError(table, row, field, fmt.Sprintf("%q does not exist (enable create missing to add it)", name))
+        return 0, nil
+    }
+
+    result, err := im.tx.ExecContext(im.ctx, insert, name)
+    if err != nil {
+        return 0, err
+    }
+    id, err := result.LastInsertId()
+    if err != nil {
+        return 0, err
+    }
+    ids[strings.ToLower(name)] = id
+    if isType {
+        im.report.TypesCreated++
+    } else {
+        im.report.SubstitutionsCreated++
+    }
+    return id, nil
+}
+
+// importItem validates one item row and creates or updates the matching item.
+func (im *importer) importItem(item ExportItem, hasExpirations bool) error {
+    const table = ExportTableItems
+    before := len(im.report.Errors)
+
+    name := strings.TrimSpace(item.ItemName)
+    if name == "" {
+        im.rowError(table, item.row, "item_name", "name is required")
+    }
+    if _, seen := im.items[name]; seen && name != "" {
+        im.rowError(table, item.row, "item_name", fmt.Sprintf("%q appears more than once", name))
+    }
+    for field, v := range map[string]int{
+        "item_qty":               item.ItemQTY,
+        "minimum_qty":            item.MinimumQTY,
+        "item_used_to_date":      item.ItemUsedToDate,
+        "item_total_tossed":      item.ItemTotalTossed,
+        "item_expiration_period": item.ItemExpirationPeriod,
+    } {
+        if v < 0 {
+            im.rowError(table, item.row, field, "must not be negative")
+        }
+    }
+
+    // A blank type or substitution is stored as NULL, as exported for items without one.
+    var typeID, substitutionID int64
+    var err error
+    if strings.TrimSpace(item.ItemTypeName) != "" {
+        if typeID, err = im.lookupOrCreate(table, item.row, "item_type", item.ItemTypeName, im.opts.CreateMissing); err != nil {
+            return err
+        }
+    }
+    if strings.TrimSpace(item.ItemSubstitutionName) != "" {
+        if substitutionID, err = im.lookupOrCreate(table, item.row, "item_substitution", item.ItemSubstitutionName, im.opts.CreateMissing); err != nil {
+            return err
+        }
+    }
+
+    barcodes := []string{}
+    for _, code := range item.Barcodes {
+        barcode, err := NormalizeBarcode(code)
+        if err != nil {
+            im.rowError(table, item.row, "barcodes", err.Error())
+            continue
+        }
+        barcodes = append(barcodes, barcode)
+    }
+
+    if len(im.report.Errors) > before {
+        return nil
+    }
+
+    // The existing item is locked so no loan is made between checking and updating it.
+    var id int64
+    err = im.tx.QueryRowContext(im.ctx, `SELECT id FROM inventory_item WHERE item_name = ? FOR UPDATE`, name).Scan(&id)
+    switch {
+    case errors.Is(err, sql.ErrNoRows):
+        createDate := item.CreateDate
+        if createDate.IsZero() {
+            createDate = time.Now()
+        }
+        result, err := im.tx.ExecContext(im.ctx, `
+            INSERT INTO inventory_item
+            (item_name, itemQTY, minimumQTY, itemUsedToDate, item_type_id, item_substitution_id, item_expiration_period, item_total_tossed, createDate)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+        `, name, item.ItemQTY, item.MinimumQTY, item.ItemUsedToDate, nullID(int(typeID)), nullID(int(substitutionID)),
+            item.ItemExpirationPeriod, item.ItemTotalTossed, createDate)
+        if err != nil {
+            return err
+        }
+        if id, err = result.LastInsertId(); err != nil {
+            return err
+        }
+        im.report.ItemsCreated++
+    case err != nil:
+        return err
+    default:
+        lent, err := lentQuantity(im.ctx, im.tx, int(id))
+        if err != nil {
+            return err
+        }
+        if item.ItemQTY < lent {
+            im.rowError(table, item.row, "item_qty", fmt.Sprintf("must be at least the %d units lent out", lent))
+            return nil
+        }
+        _, err = im.tx.ExecContext(im.ctx, `
+            UPDATE inventory_item
+            SET itemQTY = ?, minimumQTY = ?, itemUsedToDate = ?, item_type_id = ?, item_substitution_id = ?,
+                item_expiration_period = ?, item_total_tossed = ?, lastModifiedDate = NOW()
+            WHERE id = ?
+        `, item.ItemQTY, item.MinimumQTY, item.ItemUsedToDate, nullID(int(typeID)), nullID(int(substitutionID)),
+            item.ItemExpirationPeriod, item.ItemTotalTossed, id)
+        if err != nil {
+            return err
+        }
+        im.report.ItemsUpdated++
+    }
+    im.items[name] = importedItem{id: id, qty: item.ItemQTY, expirationPeriod: item.ItemExpirationPeriod}
+    if err := recordItemHistory(im.ctx, im.tx, id, HistoryImported, item.ItemQTY, item.ItemQTY); err != nil {
+        return err
+    }
+
+    for _, barcode := range barcodes {
+        var ownerID int64
+        err := im.tx.QueryRowContext(im.ctx, `SELECT item_id FROM item_barcode WHERE barcode = ?`, barcode).Scan(&ownerID)
+        switch {
+        case errors.Is(err, sql.ErrNoRows):
+            if _, err := im.tx.ExecContext(im.ctx, `INSERT INTO item_barcode (item_id, barcode) VALUES (?, ?)`, id, barcode); err != nil {
+                return err
+            }
+            if _, err := im.tx.ExecContext(im.ctx, `DELETE FROM scan_queue WHERE barcode = ?`, barcode); err != nil {
+                return err
+            }
+        case err != nil:
+            return err
+        case ownerID != id:
+            im.rowError(table, item.row, "barcodes", fmt.Sprintf("barcode %s is already assigned to another item", barcode))
+        }
+    }
+    if err := refreshItemSearch(im.ctx, im.tx, id); err != nil {
+        return err
+    }
+
+    // Items without explicit expiration rows keep one unit row per unit of quantity.
+    if !hasExpirations {
+        return syncExpirationUnits(im.ctx, im.tx, id, item.ItemQTY, item.ItemExpirationPeriod)
+    }
+    return nil
+}
+
+// syncExpirationUnits adds or removes expiration rows so an item has exactly qty units.
+func syncExpirationUnits(ctx context.Context, tx *sql.Tx, itemID int64, qty, expirationPeriod int) error {
+    var count int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM item_expiration_xref WHERE item_id = ?`, itemID).Scan(&count); err != nil {
+        return err
+    }
+
+    for ; count < qty; count++ {
+        if _, err := tx.ExecContext(ctx, `
+            INSERT INTO item_expiration_xref (item_id, item_creation_date, item_expiration_date)
+            VALUES (?, NOW(), DATE_ADD(NOW(), INTERVAL ? DAY))
+        `, itemID, expirationPeriod); err != nil {
+            return err
+        }
+    }
+    if count > qty {
+        _, err := tx.ExecContext(ctx, `
+            DELETE FROM item_expiration_xref
+            WHERE item_id = ?
+            ORDER BY item_expiration_date ASC
+            LIMIT ?
+        `, itemID, count-qty)
+        return err
+    }
+    return nil
+}
+
+// importExpiration validates one expiration row and inserts it, replacing the item's
+// existing expiration rows the first time the item is seen.
+func (im *importer) importExpiration(e ExportExpiration, cleared map[string]bool) error {
+    const table = ExportTableExpirations
+    name := strings.TrimSpace(e.ItemName)
+
+    item, ok := im.items[name]
+    if !ok {
+        err := im.tx.QueryRowContext(im.ctx, `SELECT id, itemQTY, item_expiration_period FROM inventory_item WHERE item_name = ?`, name).
+            Scan(&item.id, &item.qty, &item.expirationPeriod)
+        if errors.Is(err, sql.ErrNoRows) {
+            im.rowError(table, e.row, "item_name", fmt.Sprintf("item %q does not exist", name))
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+        im.items[name] = item
+    }
+
+    if e.ExpirationDate.IsZero() {
+        im.rowError(table, e.row, "item_expiration_date", "expiration date is required")
+        return nil
+    }
+    creation := e.CreationDate
+    if creation.IsZero() {
+        creation = time.Now()
+    }
+
+    if !cleared[name] {
+        if _, err := im.tx.ExecContext(im.ctx, `DELETE FROM item_expiration_xref WHERE item_id = ?`, item.id); err != nil {
+            return err
+        }
+        cleared[name] = true
+    }
+
+    if _, err := im.tx.ExecContext(im.ctx, `
+        INSERT INTO item_expiration_xref (item_id, item_creation_date, item_expiration_date)
+        VALUES (?, ?, ?)
+    `, item.id, creation, e.ExpirationDate); err != nil {
+        return err
+    }
+    im.report.ExpirationsImported++
+    return nil
+}
@@ -5,13 +5,59 @@ import (
     "fmt"
     "os"
     "strings"
+    "time"
 )
 
 // confirm prompts the user for yes/no confirmation and returns true for 'yes' or 'y'.
+// The prompt goes to standard error so it stays out of command output.
 func confirm(prompt string) bool {
-    fmt.Print(prompt)
+    fmt.Fprint(os.Stderr, prompt)
     reader := bufio.NewReader(os.Stdin)
     input, _ := reader.ReadString('\n')
     input = strings.TrimSpace(strings.ToLower(input))
     return input == "yes" || input == "y"
 }
+
+// Today returns the current date in the local time zone, at midnight. Date-window
+// queries are given this date rather than the server's CURDATE(), so they agree with the
+// day counts worked out here.
+func Today() time.Time {
+    y, m, d := time.Now().Date()
+    return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// DaysBetween returns the number of days from the calendar date of from to that of to,
+// negative when to is earlier. Each date is read in its own location, since DATE columns
+// are scanned as midnight UTC while Today is local.
+func DaysBetween(from, to time.Time) int {
+    fy, fm, fd := from.Date()
+    ty, tm, td := to.Date()
+    return int(time.Date(ty, tm, td, 0, 0, 0, 0, time.UTC).Sub(time.Date(fy, fm, fd, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
+
+// firstNonEmpty returns the first argument that is not blank after trimming whitespace.
+func firstNonEmpty(values ...string) string {
+    for _, v := range values {
+        if v = strings.TrimSpace(v); v != "" {
+            return v
+        }
+    }
+    return ""
+}
+
+// truncateRunes shortens s to at most max runes so it fits a VARCHAR column.
+func truncateRunes(s string, max int) string {
+    runes := []rune(s)
+    if len(runes) <= max {
+        return s
+    }
+    return string(runes[:max])
+}
+
+// nullID converts an optional row ID, 0 meaning none, to an argument.
+func nullID(id int) interface{} {
+    if id == 0 {
+        return nil
+    }
+    return id
+}
@@ -0,0 +1,30 @@
+package inventory
+
+import (
+    "testing"
+    "time"
+)
+
+func TestDaysBetween(t *testing.T) {
+    east := time.FixedZone("UTC+10", 10*60*60)
+    west := time.FixedZone("UTC-8", -8*60*60)
+    tests := []struct {
+        from, to time.Time
+        want     int
+    }{
+        {time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 0},
+        {time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 2},
+        {time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 28, 0, 0, 0, 0, time.UTC), -2},
+        // A local today against a DATE scanned as midnight UTC counts calendar days.
+        {time.Date(2024, 3, 1, 0, 0, 0, 0, east), time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 0},
+        {time.Date(2024, 3, 1, 0, 0, 0, 0, west), time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), 1},
+        // The time of day does not matter.
+        {time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC), time.Date(2024, 3, 2, 0, 1, 0, 0, time.UTC), 1},
+        {time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), 366},
+    }
+    for _, tt := range tests {
+        if got := DaysBetween(tt.from, tt.to); got != tt.want {
+            t.Errorf("DaysBetween(%v, %v) = %d, want %d", tt.from, tt.to, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,206 @@
+// Package label lays out printable item labels with QR codes on common Avery sheets.
+package label
+
+import (
+    "fmt"
+    "io"
+    "sort"
+
+    "myhomeinventory/internal/pdf"
+    "myhomeinventory/internal/qr"
+)
+
+// inch is one inch in PDF points.
+const inch = 72.0
+
+// mm is one millimetre in PDF points.
+const mm = inch / 25.4
+
+// Layout describes a sheet of labels. All measurements are in points.
+type Layout struct {
+    Name        string  `json:"name"`
+    Description string  `json:"description"`
+    PageWidth   float64 `json:"-"`
+    PageHeight  float64 `json:"-"`
+    Columns     int     `json:"columns"`
+    Rows        int     `json:"rows"`
+    LabelWidth  float64 `json:"-"`
+    LabelHeight float64 `json:"-"`
+    MarginTop   float64 `json:"-"`
+    MarginLeft  float64 `json:"-"`
+    PitchX      float64 `json:"-"`
+    PitchY      float64 `json:"-"`
+}
+
+// PerSheet returns the number of labels on one sheet.
+func (l Layout) PerSheet() int {
+    return l.Columns * l.Rows
+}
+
+// DefaultLayout is used when no layout is requested.
+const DefaultLayout = "5160"
+
+// layouts holds the supported label sheets keyed by product number.
+var layouts = map[string]Layout{
+    "5160": {
+        Name: "5160", Description: "Avery 5160 address labels, 1\" x 2-5/8\", 30 per Letter sheet",
+        PageWidth: pdf.LetterWidth, PageHeight: pdf.LetterHeight, Columns: 3, Rows: 10,
+        LabelWidth: 2.625 * inch, LabelHeight: 1 * inch,
+        MarginTop: 0.5 * inch, MarginLeft: 0.1875 * inch, PitchX: 2.75 * inch, PitchY: 1 * inch,
+    },
+    "5163": {
+        Name: "5163", Description: "Avery 5163 shipping labels, 2\" x 4\", 10 per Letter sheet",
+        PageWidth: pdf.LetterWidth, PageHeight: pdf.LetterHeight, Columns: 2, Rows: 5,
+        LabelWidth: 4 * inch, LabelHeight: 2 * inch,
+        MarginTop: 0.5 * inch, MarginLeft: 0.15625 * inch, PitchX: 4.1875 * inch, PitchY: 2 * inch,
+    },
+    "5164": {
+        Name: "5164", Description: "Avery 5164 shipping labels, 3-1/3\" x 4\", 6 per Letter sheet",
+        PageWidth: pdf.LetterWidth, PageHeight: pdf.LetterHeight, Columns: 2, Rows: 3,
+        LabelWidth: 4 * inch, LabelHeight: 3.3333 * inch,
+        MarginTop: 0.5 * inch, MarginLeft: 0.15625 * inch, PitchX: 4.1875 * inch, PitchY: 3.3333 * inch,
+    },
+    "22805": {
+        Name: "22805", Description: "Avery 22805 square labels, 1-1/2\" x 1-1/2\", 24 per Letter sheet",
+        PageWidth: pdf.LetterWidth, PageHeight: pdf.LetterHeight, Columns: 4, Rows: 6,
+        LabelWidth: 1.5 * inch, LabelHeight: 1.5 * inch,
+        MarginTop: 0.5 * inch, MarginLeft: 0.75 * inch, PitchX: 1.8125 * inch, PitchY: 1.6875 * inch,
+    },
+    "L7160": {
+        Name: "L7160", Description: "Avery L7160 address labels, 63.5 x 38.1 mm, 21 per A4 sheet",
+        PageWidth: pdf.A4Width, PageHeight: pdf.A4Height, Columns: 3, Rows: 7,
+        LabelWidth: 63.5 * mm, LabelHeight: 38.1 * mm,
+        MarginTop: 15.15 * mm, MarginLeft: 7.25 * mm, PitchX: 66.04 * mm, PitchY: 38.1 * mm,
+    },
+}
+
+// LookupLayout returns the layout with the given product number.
+func LookupLayout(name string) (Layout, error) {
+    if name == "" {
+        name = DefaultLayout
+    }
+    layout, ok := layouts[name]
+    if !ok {
+        return Layout{}, fmt.Errorf("unknown label layout %q", name)
+    }
+    return layout, nil
+}
+
+// Layouts returns every supported layout ordered by name.
+func Layouts() []Layout {
+    result := make([]Layout, 0, len(layouts))
+    for _, l := range layouts {
+        result = append(result, l)
+    }
+    sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+    return result
+}
+
+// Label is the content printed on a single label.
+type Label struct {
+    Title string
+    Lines []string
+    URL   string
+}
+
+// Options controls how a sheet is printed.
+type Options struct {
+    // Skip leaves the first N positions blank so partially used sheets can be reused.
+    Skip int
+    // Outline draws each label's border, useful for test prints on plain paper.
+    Outline bool
+}
+
+// Render writes a PDF with labels placed in order across as many sheets as needed.
+func Render(w io.Writer, layout Layout, labels []Label, opts Options) error {
+    doc := pdf.New(layout.PageWidth, layout.PageHeight)
+
+    position := opts.Skip
+    for _, l := range labels {
+        slot := position % layout.PerSheet()
+        if slot == 0 || doc.PageCount() == 0 {
+            doc.AddPage()
+        }
+        x := layout.MarginLeft + float64(slot%layout.Columns)*layout.PitchX
+        y := layout.MarginTop + float64(slot/layout.Columns)*layout.PitchY
+
+        if opts.Outline {
+            doc.Rect(x, y, layout.LabelWidth, layout.LabelHeight, false)
+        }
+        if err := drawLabel(doc, layout, x, y, l); err != nil {
+            return err
+        }
+        position++
+    }
+
+    _, err := doc.WriteTo(w)
+    return err
+}
+
+// drawLabel draws one label's QR code and text inside the box at (x, y).
+func drawLabel(doc *pdf.Document, layout Layout, x, y float64, l Label) error {
+    pad := min(layout.LabelHeight, layout.LabelWidth) * 0.08
+    innerW := layout.LabelWidth - 2*pad
+    innerH := layout.LabelHeight - 2*pad
+
+    // Square labels stack the QR code above the text; wide labels put it on the left.
+    stacked := layout.LabelWidth < layout.LabelHeight*1.2
+    qrSize := min(innerH, innerW*0.5)
+    if stacked {
+        qrSize = min(innerH*0.62, innerW)
+    }
+
+    textX, textY, textW := x+pad+qrSize+pad, y+pad, innerW-qrSize-pad
+    qrX, qrY := x+pad, y+pad
+    if stacked {
+        qrX = x + (layout.LabelWidth-qrSize)/2
+        textX, textY, textW = x+pad, y+pad+qrSize+pad/2, innerW
+    }
+
+    if l.URL != "" {
+        code, err := qr.Encode([]byte(l.URL))
+        if err != nil {
+            return err
+        }
+        drawQR(doc, code, qrX, qrY, qrSize)
+    } else {
+        textX, textW = x+pad, innerW
+    }
+
+    available := y + layout.LabelHeight - pad - textY
+    lines := 1 + len(l.Lines)
+    titleSize := min(12, available/(float64(lines)*1.2))
+    lineSize := titleSize * 0.8
+
+    cursor := textY + titleSize
+    doc.Text(textX, cursor, titleSize, true, pdf.FitText(l.Title, titleSize, true, textW))
+    for _, line := range l.Lines {
+        cursor += lineSize * 1.2
+        if cursor > y+layout.LabelHeight-pad {
+            break
+        }
+        doc.Text(textX, cursor, lineSize, false, pdf.FitText(line, lineSize, false, textW))
+    }
+    return nil
+}
+
+// drawQR draws a QR code with its quiet zone filling a size x size square at (x, y).
+func drawQR(doc *pdf.Document, code *qr.Code, x, y, size float64) {
+    const quiet = 2
+    module := size / float64(code.Size+2*quiet)
+    for row := 0; row < code.Size; row++ {
+        for col := 0; col < code.Size; {
+            if !code.Modules[row][col] {
+                col++
+                continue
+            }
+            // Merge horizontal runs of dark modules into one rectangle.
+            start := col
+            for col < code.Size && code.Modules[row][col] {
+                col++
+            }
+            doc.Rect(x+float64(start+quiet)*module, y+float64(row+quiet)*module,
+                float64(col-start)*module, module, true)
+        }
+    }
+}
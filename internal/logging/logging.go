@@ -0,0 +1,63 @@
+// Package logging configures the application's structured logger and carries the
+// request ID of an HTTP request through its context, so log lines written deep in
+// the inventory package can be tied back to the request that caused them.
+package logging
+
+import (
+    "context"
+    "io"
+    "log/slog"
+
+    "myhomeinventory/internal/config"
+)
+
+// Setup installs the default slog logger writing to w in the configured format and level.
+func Setup(w io.Writer, cfg config.Log) {
+    var level slog.Level
+    if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+        level = slog.LevelInfo
+    }
+    opts := &slog.HandlerOptions{Level: level}
+
+    var handler slog.Handler
+    if cfg.Format == "json" {
+        handler = slog.NewJSONHandler(w, opts)
+    } else {
+        handler = slog.NewTextHandler(w, opts)
+    }
+    slog.SetDefault(slog.New(contextHandler{handler}))
+}
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+    return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by ctx, or "" if there is none.
+func RequestID(ctx context.Context) string {
+    id, _ := ctx.Value(requestIDKey{}).(string)
+    return id
+}
+
+// contextHandler adds the request ID from the context to every record logged with one.
+type contextHandler struct {
+    slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+    if id := RequestID(ctx); id != "" {
+        r.AddAttrs(slog.String("request_id", id))
+    }
+    return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+    return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+    return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,176 @@
+// Package markdown renders the small subset of Markdown used in item notes: paragraphs,
+// headings, lists, quotes, code, emphasis and links. Every piece of the input is escaped
+// before it is placed in the output, and links are limited to http, https and mailto
+// URLs, so a note cannot inject markup or script into the page.
+package markdown
+
+import (
+    "html"
+    "html/template"
+    "net/url"
+    "regexp"
+    "strings"
+)
+
+// Render converts Markdown to HTML that is safe to place in a page. Single line breaks
+// inside a paragraph are kept, as notes are usually typed like plain text.
+func Render(src string) template.HTML {
+    lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+    var out strings.Builder
+    renderBlocks(&out, lines)
+    return template.HTML(out.String())
+}
+
+var (
+    headingPattern   = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*$`)
+    unorderedPattern = regexp.MustCompile(`^[-*+]\s+(.*)$`)
+    orderedPattern   = regexp.MustCompile(`^\d{1,9}[.)]\s+(.*)$`)
+    rulePattern      = regexp.MustCompile(`^(-\s*){3,}$|^(\*\s*){3,}$|^(_\s*){3,}$`)
+)
+
+// renderBlocks writes the block elements of lines.
+func renderBlocks(out *strings.Builder, lines []string) {
+    var paragraph []string
+    flush := func() {
+        if len(paragraph) > 0 {
+            out.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+            paragraph = nil
+        }
+    }
+
+    for i := 0; i < len(lines); i++ {
+        line := strings.TrimSpace(lines[i])
+        switch {
+        case line == "":
+            flush()
+
+        case strings.HasPrefix(line, "```"):
+            flush()
+            var code []string
+            for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```"); i++ {
+                code = append(code, lines[i])
+            }
+            out.WriteString("<pre><code>" + html.EscapeString(strings.Join(code, "\n")) + "</code></pre>\n")
+
+        case rulePattern.MatchString(line):
+            flush()
+            out.WriteString("<hr>\n")
+
+        case headingPattern.MatchString(line):
+            flush()
+            m := headingPattern.FindStringSubmatch(line)
+            // Notes sit under the page's own headings, so # starts at <h3>.
+            level := string(rune('0' + min(len(m[1])+2, 6)))
+            out.WriteString("<h" + level + ">" + inline(m[2]) + "</h" + level + ">\n")
+
+        case unorderedPattern.MatchString(line), orderedPattern.MatchString(line):
+            flush()
+            pattern, tag := unorderedPattern, "ul"
+            if !unorderedPattern.MatchString(line) {
+                pattern, tag = orderedPattern, "ol"
+            }
+            out.WriteString("<" + tag + ">\n")
+            for ; i < len(lines); i++ {
+                m := pattern.FindStringSubmatch(strings.TrimSpace(lines[i]))
+                if m == nil {
+                    i--
+                    break
+                }
+                out.WriteString("<li>" + inline(m[1]) + "</li>\n")
+            }
+            out.WriteString("</" + tag + ">\n")
+
+        case strings.HasPrefix(line, ">"):
+            flush()
+            var quoted []string
+            for ; i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">"); i++ {
+                quoted = append(quoted, strings.TrimPrefix(strings.TrimSpace(lines[i]), ">"))
+            }
+            i--
+            out.WriteString("<blockquote>\n")
+            renderBlocks(out, quoted)
+            out.WriteString("</blockquote>\n")
+
+        default:
+            paragraph = append(paragraph, inline(line))
+        }
+    }
+    flush()
+}
+
+var (
+    // linkPattern matches [text](url) links and bare http(s) URLs.
+    linkPattern   = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)|https?://[^\s<>"'` + "`" + `]+`)
+    strongPattern = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+    emPattern     = regexp.MustCompile(`\*([^*]+)\*|\b_([^_]+)_\b`)
+    delPattern    = regexp.MustCompile(`~~([^~]+)~~`)
+)
+
+// inline renders the inline elements of one line of text. Code spans are split out
+// first so nothing inside them is formatted.
+func inline(text string) string {
+    var out strings.Builder
+    parts := strings.Split(text, "`")
+    for i, part := range parts {
+        switch {
+        case i%2 == 1 && i < len(parts)-1:
+            out.WriteString("<code>" + html.EscapeString(part) + "</code>")
+        case i%2 == 1:
+            // An unmatched backtick is literal.
+            out.WriteString("`" + links(part))
+        default:
+            out.WriteString(links(part))
+        }
+    }
+    return out.String()
+}
+
+// links renders links in text and the emphasis around and inside them.
+func links(text string) string {
+    var out strings.Builder
+    last := 0
+    for _, m := range linkPattern.FindAllStringSubmatchIndex(text, -1) {
+        out.WriteString(emphasis(text[last:m[0]]))
+        last = m[1]
+
+        label, target := text[m[0]:m[1]], text[m[0]:m[1]]
+        if m[2] >= 0 {
+            label, target = text[m[2]:m[3]], text[m[4]:m[5]]
+        } else {
+            // Sentence punctuation after a bare URL is not part of it.
+            trimmed := strings.TrimRight(target, ".,;:!?)")
+            last -= len(target) - len(trimmed)
+            label, target = trimmed, trimmed
+        }
+        if !safeURL(target) {
+            out.WriteString(emphasis(label))
+            continue
+        }
+        out.WriteString(`<a href="` + html.EscapeString(target) + `" rel="nofollow noopener noreferrer">` + emphasis(label) + "</a>")
+    }
+    out.WriteString(emphasis(text[last:]))
+    return out.String()
+}
+
+// emphasis escapes text and renders bold, italic and strikethrough.
+func emphasis(text string) string {
+    s := html.EscapeString(text)
+    s = strongPattern.ReplaceAllString(s, "<strong>$1$2</strong>")
+    s = emPattern.ReplaceAllString(s, "<em>$1$2</em>")
+    s = delPattern.ReplaceAllString(s, "<del>$1</del>")
+    return s
+}
+
+// safeURL allows http, https and mailto links and relative links, and rejects schemes
+// such as javascript: that could run code.
+func safeURL(raw string) bool {
+    u, err := url.Parse(raw)
+    if err != nil {
+        return false
+    }
+    switch strings.ToLower(u.Scheme) {
+    case "http", "https", "mailto", "":
+        return true
+    }
+    return false
+}
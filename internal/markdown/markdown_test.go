@@ -0,0 +1,80 @@
+package markdown
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestRender(t *testing.T) {
+    tests := []struct {
+        name, in, want string
+    }{
+        {"paragraph", "Two cans\non the shelf", "<p>Two cans<br>\non the shelf</p>\n"},
+        {"heading", "# Care", "<h3>Care</h3>\n"},
+        {"list", "- one\n- **two**", "<ul>\n<li>one</li>\n<li><strong>two</strong></li>\n</ul>\n"},
+        {"quote", "> kept *cold*", "<blockquote>\n<p>kept <em>cold</em></p>\n</blockquote>\n"},
+        {"link", "[manual](https://example.com/a?b=1&c=2)", `<p><a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener noreferrer">manual</a></p>` + "\n"},
+        {"bare url", "See https://example.com/x.", `<p>See <a href="https://example.com/x" rel="nofollow noopener noreferrer">https://example.com/x</a>.</p>` + "\n"},
+        {"mailto", "[me](mailto:me@example.com)", `<p><a href="mailto:me@example.com" rel="nofollow noopener noreferrer">me</a></p>` + "\n"},
+    }
+    for _, tt := range tests {
+        if got := string(Render(tt.in)); got != tt.want {
+            t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+        }
+    }
+}
+
+// Nothing a note contains may come out as markup of its own or as a link that runs code.
+func TestRenderEscapes(t *testing.T) {
+    tests := []struct {
+        name, in, want string
+    }{
+        {"html", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>\n"},
+        {"javascript link", "[click](javascript:alert(1))", "<p>click)</p>\n"},
+        {"javascript link upper case", "[click](JavaScript:alert%281%29)", "<p>click</p>\n"},
+        {"data link", "[click](data:text/html;base64,PHNjcmlwdD4=)", "<p>click</p>\n"},
+        {"vbscript link", "[click](vbscript:msgbox)", "<p>click</p>\n"},
+        {"quote in link", `[x](https://example.com/"onmouseover="alert(1))`, `<p><a href="https://example.com/&#34;onmouseover=&#34;alert(1" rel="nofollow noopener noreferrer">x</a>)</p>` + "\n"},
+        {"html in link text", "[<img src=x onerror=alert(1)>](https://example.com)", `<p><a href="https://example.com" rel="nofollow noopener noreferrer">&lt;img src=x onerror=alert(1)&gt;</a></p>` + "\n"},
+        {"html in strong", "**<b>bold</b>**", "<p><strong>&lt;b&gt;bold&lt;/b&gt;</strong></p>\n"},
+        {"html in em", "*<i onclick=x>*", "<p><em>&lt;i onclick=x&gt;</em></p>\n"},
+        {"html in del", "~~<s>~~", "<p><del>&lt;s&gt;</del></p>\n"},
+        {"html in code span", "`<script>**x**</script>`", "<p><code>&lt;script&gt;**x**&lt;/script&gt;</code></p>\n"},
+        {"html in code block", "```\n<script>\n[a](javascript:x)\n```", "<pre><code>&lt;script&gt;\n[a](javascript:x)</code></pre>\n"},
+        {"html in heading", "## <h1>x</h1>", "<h4>&lt;h1&gt;x&lt;/h1&gt;</h4>\n"},
+        {"html in list", "1. <a href=x>", "<ol>\n<li>&lt;a href=x&gt;</li>\n</ol>\n"},
+        {"entity", "&lt;b&gt; & &#39;", "<p>&amp;lt;b&amp;gt; &amp; &amp;#39;</p>\n"},
+    }
+    for _, tt := range tests {
+        got := string(Render(tt.in))
+        if got != tt.want {
+            t.Errorf("%s:\n got %q\nwant %q", tt.name, got, tt.want)
+        }
+        if strings.Contains(strings.ToLower(got), "javascript:") && !strings.Contains(got, "<code>") {
+            t.Errorf("%s: output %q contains javascript:", tt.name, got)
+        }
+    }
+}
+
+func TestSafeURL(t *testing.T) {
+    tests := []struct {
+        url  string
+        want bool
+    }{
+        {"https://example.com", true},
+        {"http://example.com", true},
+        {"mailto:a@example.com", true},
+        {"/items/3", true},
+        {"javascript:alert(1)", false},
+        {"JAVASCRIPT:alert(1)", false},
+        {"java\tscript:alert(1)", false},
+        {"data:text/html,x", false},
+        {"vbscript:x", false},
+        {"file:///etc/passwd", false},
+    }
+    for _, tt := range tests {
+        if got := safeURL(tt.url); got != tt.want {
+            t.Errorf("safeURL(%q) = %v, want %v", tt.url, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,209 @@
+// Package metrics keeps counters and histograms in memory and writes them in the
+// Prometheus text exposition format. It covers just what the application records,
+// so the server needs no client library.
+package metrics
+
+import (
+    "fmt"
+    "io"
+    "math"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// DefaultBuckets are latency bucket upper bounds in seconds.
+var DefaultBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Metrics recorded by the application.
+var (
+    HTTPRequests = NewCounterVec("inventory_http_requests_total",
+        "HTTP requests served, by route, method and status code.", "route", "method", "status")
+    HTTPDuration = NewHistogramVec("inventory_http_request_duration_seconds",
+        "Time spent serving HTTP requests, by route.", DefaultBuckets, "route")
+    DBQueryDuration = NewHistogramVec("inventory_db_query_duration_seconds",
+        "Time spent in inventory database operations, by operation.", DefaultBuckets, "operation")
+)
+
+// collector is a metric family that can write itself.
+type collector interface {
+    write(w io.Writer)
+}
+
+var (
+    registryMu sync.Mutex
+    registry   []collector
+)
+
+func register(c collector) {
+    registryMu.Lock()
+    defer registryMu.Unlock()
+    registry = append(registry, c)
+}
+
+// Write writes every registered metric family to w.
+func Write(w io.Writer) {
+    registryMu.Lock()
+    collectors := append([]collector(nil), registry...)
+    registryMu.Unlock()
+    for _, c := range collectors {
+        c.write(w)
+    }
+}
+
+// WriteGauge writes a single unlabelled gauge, for values read at scrape time.
+func WriteGauge(w io.Writer, name, help string, value float64) {
+    writeHeader(w, name, help, "gauge")
+    fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+}
+
+// WriteCounter writes a single unlabelled counter, for totals read at scrape time.
+func WriteCounter(w io.Writer, name, help string, value float64) {
+    writeHeader(w, name, help, "counter")
+    fmt.Fprintf(w, "%s %s\n", name, formatFloat(value))
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+    name   string
+    help   string
+    labels []string
+
+    mu     sync.Mutex
+    values map[string]float64
+    keys   map[string][]string
+}
+
+// NewCounterVec creates and registers a counter with the given label names.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+    c := &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}, keys: map[string][]string{}}
+    register(c)
+    return c
+}
+
+// Inc adds one to the counter for the label values, given in label order.
+func (c *CounterVec) Inc(values ...string) {
+    key := strings.Join(values, "\xff")
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    if _, ok := c.keys[key]; !ok {
+        c.keys[key] = values
+    }
+    c.values[key]++
+}
+
+func (c *CounterVec) write(w io.Writer) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    writeHeader(w, c.name, c.help, "counter")
+    for _, key := range sortedKeys(c.keys) {
+        fmt.Fprintf(w, "%s%s %s\n", c.name, labelString(c.labels, c.keys[key], "", ""), formatFloat(c.values[key]))
+    }
+}
+
+// HistogramVec is a histogram partitioned by label values.
+type HistogramVec struct {
+    name    string
+    help    string
+    labels  []string
+    buckets []float64
+
+    mu     sync.Mutex
+    series map[string]*histogram
+    keys   map[string][]string
+}
+
+// histogram holds the cumulative state of one labelled series.
+type histogram struct {
+    counts []uint64 // per bucket, not cumulative
+    count  uint64
+    sum    float64
+}
+
+// NewHistogramVec creates and registers a histogram with the given buckets and label names.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+    h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets,
+        series: map[string]*histogram{}, keys: map[string][]string{}}
+    register(h)
+    return h
+}
+
+// Observe records one value for the label values, given in label order.
+func (h *HistogramVec) Observe(value float64, values ...string) {
+    key := strings.Join(values, "\xff")
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    s, ok := h.series[key]
+    if !ok {
+        s = &histogram{counts: make([]uint64, len(h.buckets))}
+        h.series[key] = s
+        h.keys[key] = values
+    }
+    if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+        s.counts[i]++
+    }
+    s.count++
+    s.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    writeHeader(w, h.name, h.help, "histogram")
+    for _, key := range sortedKeys(h.keys) {
+        s, values := h.series[key], h.keys[key]
+        var cumulative uint64
+        for i, bound := range h.buckets {
+            cumulative += s.counts[i]
+            fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, values, "le", formatFloat(bound)), cumulative)
+        }
+        fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, labelString(h.labels, values, "le", "+Inf"), s.count)
+        fmt.Fprintf(w, "%s_sum%s %s\n", h.name, labelString(h.labels, values, "", ""), formatFloat(s.sum))
+        fmt.Fprintf(w, "%s_count%s %d\n", h.name, labelString(h.labels, values, "", ""), s.count)
+    }
+}
+
+func writeHeader(w io.Writer, name, help, kind string) {
+    fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// labelString formats {name="value",...}, appending an extra label when extraName is set.
+func labelString(names, values []string, extraName, extraValue string) string {
+    parts := make([]string, 0, len(names)+1)
+    for i, name := range names {
+        value := ""
+        if i < len(values) {
+            value = values[i]
+        }
+        parts = append(parts, name+`="`+escapeLabel(value)+`"`)
+    }
+    if extraName != "" {
+        parts = append(parts, extraName+`="`+extraValue+`"`)
+    }
+    if len(parts) == 0 {
+        return ""
+    }
+    return "{" + strings.Join(parts, ",") + "}"
+}
+
+// escapeLabel escapes a label value as the exposition format requires.
+func escapeLabel(s string) string {
+    return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(v float64) string {
+    if math.IsInf(v, 1) {
+        return "+Inf"
+    }
+    return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string][]string) []string {
+    keys := make([]string, 0, len(m))
+    for k := range m {
+        keys = append(keys, k)
+    }
+    sort.Strings(keys)
+    return keys
+}
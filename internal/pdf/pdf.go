@@ -0,0 +1,203 @@
+// Package pdf writes simple PDF documents using only the standard library.
+// It covers what the application prints: text in the built-in Helvetica fonts,
+// filled and outlined rectangles, on any number of fixed-size pages.
+// Coordinates are in points with the origin at the top-left corner of the page.
+package pdf
+
+import (
+    "bytes"
+    "compress/zlib"
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Common page sizes in points.
+const (
+    LetterWidth  = 612.0
+    LetterHeight = 792.0
+    A4Width      = 595.28
+    A4Height     = 841.89
+)
+
+// Document is a PDF being built page by page.
+type Document struct {
+    width  float64
+    height float64
+    pages  []*bytes.Buffer
+}
+
+// New creates an empty document whose pages are width x height points.
+func New(width, height float64) *Document {
+    return &Document{width: width, height: height}
+}
+
+// AddPage starts a new page; subsequent drawing goes to it.
+func (d *Document) AddPage() {
+    d.pages = append(d.pages, &bytes.Buffer{})
+}
+
+// PageCount returns the number of pages added so far.
+func (d *Document) PageCount() int {
+    return len(d.pages)
+}
+
+// current returns the page being drawn on, starting one if needed.
+func (d *Document) current() *bytes.Buffer {
+    if len(d.pages) == 0 {
+        d.AddPage()
+    }
+    return d.pages[len(d.pages)-1]
+}
+
+// Text draws s with its baseline starting at (x, y).
+func (d *Document) Text(x, y, size float64, bold bool, s string) {
+    font := "F1"
+    if bold {
+        font = "F2"
+    }
+    fmt.Fprintf(d.current(), "BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+        font, num(size), num(x), num(d.height-y), escape(s))
+}
+
+// Rect draws a rectangle with its top-left corner at (x, y), filled or as a thin outline.
+func (d *Document) Rect(x, y, w, h float64, fill bool) {
+    op := "S"
+    if fill {
+        op = "f"
+    } else {
+        fmt.Fprint(d.current(), "0.25 w ")
+    }
+    fmt.Fprintf(d.current(), "%s %s %s %s re %s\n", num(x), num(d.height-y-h), num(w), num(h), op)
+}
+
+// Line draws a thin line from (x1, y1) to (x2, y2).
+func (d *Document) Line(x1, y1, x2, y2 float64) {
+    fmt.Fprintf(d.current(), "0.5 w %s %s m %s %s l S\n", num(x1), num(d.height-y1), num(x2), num(d.height-y2))
+}
+
+// WriteTo serializes the document.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+    if len(d.pages) == 0 {
+        d.AddPage()
+    }
+
+    var out bytes.Buffer
+    offsets := []int{}
+    object := func(body string) {
+        offsets = append(offsets, out.Len())
+        fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+    }
+
+    out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+    // Objects 1-4 are fixed; each page then takes a page object and a content stream.
+    kids := make([]string, len(d.pages))
+    for i := range d.pages {
+        kids[i] = fmt.Sprintf("%d 0 R", 5+i*2)
+    }
+    object("<< /Type /Catalog /Pages 2 0 R >>")
+    object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+    object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+    object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+
+    for i, page := range d.pages {
+        object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+            num(d.width), num(d.height), 6+i*2))
+
+        var compressed bytes.Buffer
+        zw := zlib.NewWriter(&compressed)
+        zw.Write(page.Bytes())
+        zw.Close()
+        object(fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", compressed.Len(), compressed.Bytes()))
+    }
+
+    xref := out.Len()
+    fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+    for _, off := range offsets {
+        fmt.Fprintf(&out, "%010d 00000 n \n", off)
+    }
+    fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+    n, err := w.Write(out.Bytes())
+    return int64(n), err
+}
+
+// TextWidth returns the width of s in points when set in Helvetica at the given size.
+func TextWidth(s string, size float64, bold bool) float64 {
+    widths := helveticaWidths
+    if bold {
+        widths = helveticaBoldWidths
+    }
+    total := 0
+    for _, r := range s {
+        if r >= 32 && r <= 126 {
+            total += widths[r-32]
+        } else {
+            total += 556
+        }
+    }
+    return float64(total) * size / 1000
+}
+
+// FitText shortens s with an ellipsis so it is no wider than maxWidth.
+func FitText(s string, size float64, bold bool, maxWidth float64) string {
+    if TextWidth(s, size, bold) <= maxWidth {
+        return s
+    }
+    runes := []rune(s)
+    for len(runes) > 0 {
+        runes = runes[:len(runes)-1]
+        candidate := strings.TrimRight(string(runes), " ") + "..."
+        if TextWidth(candidate, size, bold) <= maxWidth {
+            return candidate
+        }
+    }
+    return ""
+}
+
+// num formats a coordinate compactly.
+func num(v float64) string {
+    s := fmt.Sprintf("%.2f", v)
+    s = strings.TrimRight(s, "0")
+    return strings.TrimSuffix(s, ".")
+}
+
+// escape encodes s as a WinAnsi PDF string literal body. Characters outside Latin-1 become '?'.
+func escape(s string) string {
+    var b strings.Builder
+    for _, r := range s {
+        switch {
+        case r == '(' || r == ')' || r == '\\':
+            b.WriteByte('\\')
+            b.WriteRune(r)
+        case r >= 32 && r <= 126:
+            b.WriteRune(r)
+        case r >= 0xA0 && r <= 0xFF:
+            fmt.Fprintf(&b, "\\%03o", r)
+        default:
+            b.WriteByte('?')
+        }
+    }
+    return b.String()
+}
+
+// helveticaWidths holds Helvetica advance widths for characters 32 through 126, in 1/1000 em.
+var helveticaWidths = [95]int{
+    278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+    556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+    1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+    667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+    333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+    556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+// helveticaBoldWidths holds Helvetica-Bold advance widths for characters 32 through 126.
+var helveticaBoldWidths = [95]int{
+    278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+    556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+    975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+    667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+    333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+    611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+}
@@ -0,0 +1,155 @@
+package pdf
+
+import (
+    "bytes"
+    "compress/zlib"
+    "fmt"
+    "io"
+    "regexp"
+    "strconv"
+    "strings"
+    "testing"
+)
+
+// TestWriteToCrossReference checks that startxref points at the xref table and that every
+// entry's offset points at the object with that number.
+func TestWriteToCrossReference(t *testing.T) {
+    for pages := 0; pages <= 3; pages++ {
+        t.Run(fmt.Sprintf("%d pages", pages), func(t *testing.T) {
+            d := New(LetterWidth, LetterHeight)
+            for i := 0; i < pages; i++ {
+                d.AddPage()
+                d.Text(10, 20, 12, i%2 == 0, fmt.Sprintf("Page %d (of %d)", i+1, pages))
+                d.Rect(10, 30, 100, 50, false)
+            }
+            var buf bytes.Buffer
+            if _, err := d.WriteTo(&buf); err != nil {
+                t.Fatal(err)
+            }
+            out := buf.Bytes()
+
+            m := regexp.MustCompile(`startxref\n(\d+)\n%%EOF\n$`).FindSubmatch(out)
+            if m == nil {
+                t.Fatal("missing startxref trailer")
+            }
+            xref, _ := strconv.Atoi(string(m[1]))
+            if !bytes.HasPrefix(out[xref:], []byte("xref\n")) {
+                t.Fatalf("startxref %d does not point at the xref table", xref)
+            }
+
+            lines := strings.Split(string(out[xref:]), "\n")
+            var first, count int
+            if _, err := fmt.Sscanf(lines[1], "%d %d", &first, &count); err != nil {
+                t.Fatal(err)
+            }
+            // Catalog, page tree and two fonts, then a page and a content stream per page.
+            wantObjects := 4 + 2*max(pages, 1)
+            if first != 0 || count != wantObjects+1 {
+                t.Fatalf("xref subsection %d %d, want 0 %d", first, count, wantObjects+1)
+            }
+            if !strings.Contains(string(out), fmt.Sprintf("/Size %d ", count)) {
+                t.Errorf("trailer /Size does not match %d xref entries", count)
+            }
+            for n := 1; n < count; n++ {
+                entry := lines[2+n]
+                if len(entry) != 19 || !strings.HasSuffix(entry, " 00000 n ") {
+                    t.Fatalf("entry %d is %q, want 20-byte in-use entry", n, entry)
+                }
+                off, _ := strconv.Atoi(entry[:10])
+                if want := fmt.Sprintf("%d 0 obj\n", n); !bytes.HasPrefix(out[off:], []byte(want)) {
+                    t.Errorf("object %d offset %d points at %q", n, off, out[off:min(off+12, len(out))])
+                }
+            }
+        })
+    }
+}
+
+// TestWriteToStreams checks that each content stream's /Length matches its bytes and that
+// it inflates to the drawing operators.
+func TestWriteToStreams(t *testing.T) {
+    d := New(A4Width, A4Height)
+    d.Text(72, 100, 10, true, "Pantry (shelf 2)")
+    d.AddPage()
+    d.Line(0, 0, 10, 10)
+
+    var buf bytes.Buffer
+    if _, err := d.WriteTo(&buf); err != nil {
+        t.Fatal(err)
+    }
+
+    streams := regexp.MustCompile(`(?s)<< /Length (\d+) /Filter /FlateDecode >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(buf.Bytes(), -1)
+    want := []string{
+        "BT /F2 10 Tf 72 741.89 Td (Pantry \\(shelf 2\\)) Tj ET\n",
+        "0.5 w 0 841.89 m 10 831.89 l S\n",
+    }
+    if len(streams) != len(want) {
+        t.Fatalf("got %d streams, want %d", len(streams), len(want))
+    }
+    for i, s := range streams {
+        length, _ := strconv.Atoi(string(s[1]))
+        if length != len(s[2]) {
+            t.Errorf("stream %d: /Length %d, stream has %d bytes", i, length, len(s[2]))
+        }
+        zr, err := zlib.NewReader(bytes.NewReader(s[2]))
+        if err != nil {
+            t.Fatal(err)
+        }
+        content, err := io.ReadAll(zr)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if string(content) != want[i] {
+            t.Errorf("stream %d:\n got %q\nwant %q", i, content, want[i])
+        }
+    }
+}
+
+func TestEscape(t *testing.T) {
+    tests := []struct {
+        in, want string
+    }{
+        {"plain", "plain"},
+        {`a(b)c\d`, `a\(b\)c\\d`},
+        {"café", `caf\351`},
+        {"snow☃man", "snow?man"},
+        {"tab\there", "tab?here"},
+    }
+    for _, tt := range tests {
+        if got := escape(tt.in); got != tt.want {
+            t.Errorf("escape(%q) = %q, want %q", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestNum(t *testing.T) {
+    tests := []struct {
+        in   float64
+        want string
+    }{
+        {0, "0"},
+        {12, "12"},
+        {12.5, "12.5"},
+        {595.28, "595.28"},
+        {1.004, "1"},
+        {-3.25, "-3.25"},
+    }
+    for _, tt := range tests {
+        if got := num(tt.in); got != tt.want {
+            t.Errorf("num(%v) = %q, want %q", tt.in, got, tt.want)
+        }
+    }
+}
+
+func TestFitText(t *testing.T) {
+    if got := FitText("Rice", 10, false, 100); got != "Rice" {
+        t.Errorf("short text changed to %q", got)
+    }
+    long := "Extra virgin olive oil, cold pressed"
+    got := FitText(long, 10, false, 60)
+    if !strings.HasSuffix(got, "...") || TextWidth(got, 10, false) > 60 {
+        t.Errorf("FitText = %q (%.1fpt), want an ellipsized string within 60pt", got, TextWidth(got, 10, false))
+    }
+    if got := FitText(long, 10, false, 1); got != "" {
+        t.Errorf("FitText into 1pt = %q, want empty", got)
+    }
+}
@@ -0,0 +1,321 @@
+// Package photo stores item photos on local disk. Files are named by the SHA-256 of
+// their contents, so an image uploaded twice is stored once and a name always refers to
+// the same bytes. Metadata such as EXIF (which often holds GPS coordinates and the
+// camera's serial number) is stripped before storing, and a JPEG thumbnail is made for
+// each photo. Only the standard library is used.
+package photo
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "image"
+    "image/draw"
+    "image/jpeg"
+    _ "image/png" // registers the PNG decoder with image.Decode
+    "net/http"
+    "os"
+    "path/filepath"
+    "regexp"
+    "strings"
+)
+
+// ErrInvalidImage is wrapped by Check, Store and Save errors caused by the upload rather
+// than the disk.
+var ErrInvalidImage = errors.New("invalid image")
+
+const (
+    // ThumbnailSize is the longest side of a thumbnail in pixels.
+    ThumbnailSize = 320
+    // maxPixels guards against images that are small files but huge once decoded.
+    maxPixels = 60_000_000
+    // thumbDir is the subdirectory thumbnails are kept in.
+    thumbDir = "thumbs"
+)
+
+// namePattern matches the names Store gives files.
+var namePattern = regexp.MustCompile(`^[0-9a-f]{64}\.(jpg|png)$`)
+
+// Image describes a stored photo.
+type Image struct {
+    // FileName is the content-addressed name, e.g. "3a7bd3e2...c1.jpg".
+    FileName    string
+    ContentType string
+    Width       int
+    Height      int
+    Size        int64
+}
+
+// Store keeps photos in a directory, two levels deep by hash prefix so no directory
+// grows too large, with thumbnails under thumbs/.
+type Store struct {
+    dir string
+}
+
+// NewStore returns a store rooted at dir. The directory is created on the first Save.
+func NewStore(dir string) *Store {
+    return &Store{dir: dir}
+}
+
+// Dir returns the directory the store keeps photos in.
+func (s *Store) Dir() string {
+    return s.dir
+}
+
+// Upload is an image that passed Check: a JPEG or PNG with its metadata stripped and a
+// size within the pixel limit. It has not been decoded yet.
+type Upload struct {
+    clean       []byte
+    orientation int
+    contentType string
+    ext         string
+}
+
+// Check strips metadata from a JPEG or PNG image and reads its header, without decoding
+// the pixels, so a batch of uploads can be checked cheaply before any is stored.
+func Check(data []byte) (*Upload, error) {
+    u := &Upload{contentType: http.DetectContentType(data)}
+    var err error
+    switch u.contentType {
+    case "image/jpeg":
+        u.clean, u.orientation, err = stripJPEG(data)
+        u.ext = ".jpg"
+    case "image/png":
+        u.clean, err = stripPNG(data)
+        u.ext = ".png"
+    default:
+        return nil, fmt.Errorf("%w: %s is not supported, upload a JPEG or PNG", ErrInvalidImage, u.contentType)
+    }
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+    }
+
+    cfg, _, err := image.DecodeConfig(bytes.NewReader(u.clean))
+    if err != nil {
+        return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+    }
+    if cfg.Width*cfg.Height > maxPixels {
+        return nil, fmt.Errorf("%w: %dx%d is too large", ErrInvalidImage, cfg.Width, cfg.Height)
+    }
+    return u, nil
+}
+
+// Save checks a JPEG or PNG image, stores it with a thumbnail, and describes the stored
+// file.
+func (s *Store) Save(data []byte) (Image, error) {
+    u, err := Check(data)
+    if err != nil {
+        return Image{}, err
+    }
+    return s.Store(u)
+}
+
+// Store decodes a checked image and writes it with a thumbnail. JPEGs whose EXIF data
+// says they were taken rotated are turned upright first, since the orientation tag was
+// removed with the rest of the metadata.
+func (s *Store) Store(u *Upload) (Image, error) {
+    clean := u.clean
+    img, _, err := image.Decode(bytes.NewReader(clean))
+    if err != nil {
+        return Image{}, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+    }
+
+    if u.orientation > 1 && u.orientation <= 8 {
+        img = orient(toRGBA(img), u.orientation)
+        var buf bytes.Buffer
+        if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 92}); err != nil {
+            return Image{}, err
+        }
+        clean = buf.Bytes()
+    }
+
+    sum := sha256.Sum256(clean)
+    hash := hex.EncodeToString(sum[:])
+    stored := Image{
+        FileName:    hash + u.ext,
+        ContentType: u.contentType,
+        Width:       img.Bounds().Dx(),
+        Height:      img.Bounds().Dy(),
+        Size:        int64(len(clean)),
+    }
+
+    if err := writeOnce(s.path(stored.FileName, false), clean); err != nil {
+        return Image{}, err
+    }
+    thumbPath := s.path(stored.FileName, true)
+    if _, err := os.Stat(thumbPath); err == nil {
+        return stored, nil
+    }
+    var thumb bytes.Buffer
+    if err := jpeg.Encode(&thumb, thumbnail(img, ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+        return Image{}, err
+    }
+    if err := writeOnce(thumbPath, thumb.Bytes()); err != nil {
+        return Image{}, err
+    }
+    return stored, nil
+}
+
+// Path returns the file holding the photo with the given name, or its thumbnail. Names
+// are checked so a request cannot reach outside the store.
+func (s *Store) Path(name string, thumb bool) (string, error) {
+    if !namePattern.MatchString(name) {
+        return "", fmt.Errorf("invalid photo name %q", name)
+    }
+    return s.path(name, thumb), nil
+}
+
+// path returns where a validated name is stored.
+func (s *Store) path(name string, thumb bool) string {
+    if thumb {
+        hash := strings.TrimSuffix(name, filepath.Ext(name))
+        return filepath.Join(s.dir, thumbDir, name[:2], hash+".jpg")
+    }
+    return filepath.Join(s.dir, name[:2], name)
+}
+
+// Remove deletes a photo and its thumbnail. Missing files are not an error.
+func (s *Store) Remove(name string) error {
+    if !namePattern.MatchString(name) {
+        return fmt.Errorf("invalid photo name %q", name)
+    }
+    for _, thumb := range []bool{false, true} {
+        if err := os.Remove(s.path(name, thumb)); err != nil && !errors.Is(err, os.ErrNotExist) {
+            return err
+        }
+    }
+    return nil
+}
+
+// Prune deletes every stored photo whose name is not in keep, with its thumbnail, and
+// returns the names removed.
+func (s *Store) Prune(keep map[string]bool) ([]string, error) {
+    entries, err := filepath.Glob(filepath.Join(s.dir, "??", "*"))
+    if err != nil {
+        return nil, err
+    }
+    var removed []string
+    for _, path := range entries {
+        name := filepath.Base(path)
+        if !namePattern.MatchString(name) || keep[name] {
+            continue
+        }
+        if err := s.Remove(name); err != nil {
+            return removed, err
+        }
+        removed = append(removed, name)
+    }
+    return removed, nil
+}
+
+// writeOnce writes data to path unless the file already exists. As files are named by
+// their content an existing file already holds the same bytes. The data is written to a
+// temporary file and renamed so a crash never leaves a partial photo under its name.
+func writeOnce(path string, data []byte) error {
+    if _, err := os.Stat(path); err == nil {
+        return nil
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+        return err
+    }
+    tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    if _, err := tmp.Write(data); err != nil {
+        tmp.Close()
+        return err
+    }
+    if err := tmp.Close(); err != nil {
+        return err
+    }
+    return os.Rename(tmp.Name(), path)
+}
+
+// toRGBA converts img to RGBA, flattening any transparency onto white.
+func toRGBA(img image.Image) *image.RGBA {
+    b := img.Bounds()
+    dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+    if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+        draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+        return dst
+    }
+    draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+    draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+    return dst
+}
+
+// orient turns src upright according to an EXIF orientation value from 2 to 8.
+func orient(src *image.RGBA, orientation int) *image.RGBA {
+    w, h := src.Bounds().Dx(), src.Bounds().Dy()
+    dw, dh := w, h
+    if orientation >= 5 {
+        dw, dh = h, w
+    }
+    dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+    for y := 0; y < h; y++ {
+        for x := 0; x < w; x++ {
+            var dx, dy int
+            switch orientation {
+            case 2: // mirrored
+                dx, dy = w-1-x, y
+            case 3: // upside down
+                dx, dy = w-1-x, h-1-y
+            case 4: // mirrored upside down
+                dx, dy = x, h-1-y
+            case 5: // mirrored, on its side
+                dx, dy = y, x
+            case 6: // needs a quarter turn clockwise
+                dx, dy = h-1-y, x
+            case 7: // mirrored, on its other side
+                dx, dy = h-1-y, w-1-x
+            case 8: // needs a quarter turn counter-clockwise
+                dx, dy = y, w-1-x
+            }
+            copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], src.Pix[src.PixOffset(x, y):src.PixOffset(x, y)+4])
+        }
+    }
+    return dst
+}
+
+// thumbnail scales img down to fit a size×size square, averaging the source pixels
+// under each thumbnail pixel. Images already small enough are only flattened.
+func thumbnail(img image.Image, size int) *image.RGBA {
+    src := toRGBA(img)
+    w, h := src.Bounds().Dx(), src.Bounds().Dy()
+    if w <= size && h <= size {
+        return src
+    }
+    tw, th := size, h*size/w
+    if h > w {
+        tw, th = w*size/h, size
+    }
+    tw, th = max(tw, 1), max(th, 1)
+
+    dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+    for ty := 0; ty < th; ty++ {
+        y0, y1 := ty*h/th, max((ty+1)*h/th, ty*h/th+1)
+        for tx := 0; tx < tw; tx++ {
+            x0, x1 := tx*w/tw, max((tx+1)*w/tw, tx*w/tw+1)
+            var sum [4]int
+            for y := y0; y < y1; y++ {
+                row := src.Pix[src.PixOffset(x0, y):src.PixOffset(x1-1, y)+4]
+                for i := 0; i < len(row); i += 4 {
+                    sum[0] += int(row[i])
+                    sum[1] += int(row[i+1])
+                    sum[2] += int(row[i+2])
+                    sum[3] += int(row[i+3])
+                }
+            }
+            n := (x1 - x0) * (y1 - y0)
+            off := dst.PixOffset(tx, ty)
+            for c := 0; c < 4; c++ {
+                dst.Pix[off+c] = uint8(sum[c] / n)
+            }
+        }
+    }
+    return dst
+}
@@ -0,0 +1,152 @@
+package photo
+
+import (
+    "bytes"
+    "encoding/binary"
+    "errors"
+)
+
+// JPEG markers stripJPEG looks at.
+const (
+    markerSOS  = 0xDA // start of scan: compressed image data follows
+    markerAPP0 = 0xE0
+    markerAPP1 = 0xE1 // EXIF and XMP
+    markerAPP2 = 0xE2 // ICC profile, among others
+    markerAPPE = 0xEE // Adobe colour transform, needed to decode some CMYK files
+    markerAPPF = 0xEF
+    markerCOM  = 0xFE
+)
+
+// stripJPEG removes application and comment segments that carry metadata (EXIF, XMP,
+// IPTC, comments) from a JPEG without re-encoding it, keeping the JFIF header, the ICC
+// colour profile and the Adobe segment. It returns the EXIF orientation, or 0 when the
+// file has none.
+func stripJPEG(data []byte) ([]byte, int, error) {
+    if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+        return nil, 0, errors.New("not a JPEG file")
+    }
+    out := bytes.NewBuffer(make([]byte, 0, len(data)))
+    out.Write(data[:2])
+    orientation := 0
+
+    for i := 2; i < len(data); {
+        if i+2 > len(data) || data[i] != 0xFF {
+            return nil, 0, errors.New("malformed JPEG segment")
+        }
+        marker := data[i+1]
+        if marker == 0xFF {
+            // Fill byte.
+            i++
+            continue
+        }
+        if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+            out.Write(data[i : i+2])
+            i += 2
+            continue
+        }
+        if i+4 > len(data) {
+            return nil, 0, errors.New("truncated JPEG")
+        }
+        end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+        if end > len(data) {
+            return nil, 0, errors.New("truncated JPEG")
+        }
+        if marker == markerSOS {
+            out.Write(data[i:])
+            break
+        }
+
+        payload := data[i+4 : end]
+        keep := true
+        switch {
+        case marker == markerAPP1:
+            if o := exifOrientation(payload); o != 0 {
+                orientation = o
+            }
+            keep = false
+        case marker == markerAPP0:
+            keep = bytes.HasPrefix(payload, []byte("JFIF\x00")) || bytes.HasPrefix(payload, []byte("JFXX\x00"))
+        case marker == markerAPP2:
+            keep = bytes.HasPrefix(payload, []byte("ICC_PROFILE\x00"))
+        case marker == markerAPPE:
+            keep = bytes.HasPrefix(payload, []byte("Adobe"))
+        case marker > markerAPP2 && marker <= markerAPPF, marker == markerCOM:
+            keep = false
+        }
+        if keep {
+            out.Write(data[i:end])
+        }
+        i = end
+    }
+    return out.Bytes(), orientation, nil
+}
+
+// exifOrientation reads the orientation tag from an APP1 EXIF payload, or returns 0.
+func exifOrientation(payload []byte) int {
+    if !bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+        return 0
+    }
+    tiff := payload[6:]
+    if len(tiff) < 8 {
+        return 0
+    }
+    var order binary.ByteOrder
+    switch string(tiff[:2]) {
+    case "II":
+        order = binary.LittleEndian
+    case "MM":
+        order = binary.BigEndian
+    default:
+        return 0
+    }
+    ifd := int(order.Uint32(tiff[4:]))
+    if ifd+2 > len(tiff) {
+        return 0
+    }
+    count := int(order.Uint16(tiff[ifd:]))
+    for n := 0; n < count; n++ {
+        entry := ifd + 2 + n*12
+        if entry+12 > len(tiff) {
+            return 0
+        }
+        if order.Uint16(tiff[entry:]) == 0x0112 {
+            return int(order.Uint16(tiff[entry+8:]))
+        }
+    }
+    return 0
+}
+
+// pngMetadataChunks are the PNG chunks stripPNG removes: text (which may hold XMP or
+// camera details), EXIF and the modification time.
+var pngMetadataChunks = map[string]bool{"tEXt": true, "zTXt": true, "iTXt": true, "eXIf": true, "tIME": true}
+
+// stripPNG removes metadata chunks from a PNG. Each chunk carries its own checksum, so
+// the rest of the file is copied unchanged.
+func stripPNG(data []byte) ([]byte, error) {
+    const signature = "\x89PNG\r\n\x1a\n"
+    if !bytes.HasPrefix(data, []byte(signature)) {
+        return nil, errors.New("not a PNG file")
+    }
+    out := bytes.NewBuffer(make([]byte, 0, len(data)))
+    out.WriteString(signature)
+
+    for i := len(signature); i < len(data); {
+        if i+8 > len(data) {
+            return nil, errors.New("truncated PNG")
+        }
+        length := int(binary.BigEndian.Uint32(data[i:]))
+        end := i + 12 + length
+        if length < 0 || end > len(data) {
+            return nil, errors.New("truncated PNG")
+        }
+        chunk := string(data[i+4 : i+8])
+        if !pngMetadataChunks[chunk] {
+            out.Write(data[i:end])
+        }
+        i = end
+        if chunk == "IEND" {
+            break
+        }
+    }
+    return out.Bytes(), nil
+}
@@ -0,0 +1,465 @@
+// Package qr implements a small QR Code encoder for printing item labels.
+// It supports byte-mode payloads at error correction level M for versions 1 through 10,
+// which comfortably fits the item URLs printed on labels.
+package qr
+
+import (
+    "fmt"
+)
+
+// maxVersion is the largest QR version the encoder produces.
+const maxVersion = 10
+
+// eccCodewordsPerBlock and numECCBlocks hold the level M error correction layout per version.
+var (
+    eccCodewordsPerBlock = [maxVersion + 1]int{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26}
+    numECCBlocks         = [maxVersion + 1]int{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5}
+)
+
+// formatBitsLevelM is the two-bit error correction indicator for level M.
+const formatBitsLevelM = 0
+
+// Code is an encoded QR symbol. Modules are indexed [y][x]; true is a dark module.
+type Code struct {
+    Version int
+    Size    int
+    Modules [][]bool
+}
+
+// Encode builds a QR code holding data in byte mode, choosing the smallest version that fits.
+func Encode(data []byte) (*Code, error) {
+    return encode(data, -1)
+}
+
+// encode is Encode with a fixed mask pattern from 0 to 7, or with the mask of lowest
+// penalty when mask is negative.
+func encode(data []byte, mask int) (*Code, error) {
+    version := 0
+    for v := 1; v <= maxVersion; v++ {
+        if 4+charCountBits(v)+len(data)*8 <= numDataCodewords(v)*8 {
+            version = v
+            break
+        }
+    }
+    if version == 0 {
+        return nil, fmt.Errorf("qr: %d bytes is too long to encode", len(data))
+    }
+
+    c := &Code{Version: version, Size: version*4 + 17}
+    c.Modules = make([][]bool, c.Size)
+    function := make([][]bool, c.Size)
+    for i := range c.Modules {
+        c.Modules[i] = make([]bool, c.Size)
+        function[i] = make([]bool, c.Size)
+    }
+
+    c.drawFunctionPatterns(function)
+    c.drawCodewords(function, addErrorCorrection(version, encodeData(version, data)))
+
+    if mask < 0 {
+        bestPenalty := -1
+        for m := 0; m < 8; m++ {
+            c.applyMask(function, m)
+            c.drawFormatBits(function, m)
+            if p := c.penalty(); bestPenalty < 0 || p < bestPenalty {
+                mask, bestPenalty = m, p
+            }
+            c.applyMask(function, m)
+        }
+    }
+    c.applyMask(function, mask)
+    c.drawFormatBits(function, mask)
+
+    return c, nil
+}
+
+// charCountBits returns the width of the byte-mode character count field for a version.
+func charCountBits(version int) int {
+    if version <= 9 {
+        return 8
+    }
+    return 16
+}
+
+// numRawDataModules returns the number of modules available for data and error correction.
+func numRawDataModules(version int) int {
+    result := (16*version+128)*version + 64
+    if version >= 2 {
+        numAlign := version/7 + 2
+        result -= (25*numAlign-10)*numAlign - 55
+        if version >= 7 {
+            result -= 36
+        }
+    }
+    return result
+}
+
+// numDataCodewords returns the number of 8-bit data codewords a version holds at level M.
+func numDataCodewords(version int) int {
+    return numRawDataModules(version)/8 - eccCodewordsPerBlock[version]*numECCBlocks[version]
+}
+
+// bitBuffer accumulates bits most significant first.
+type bitBuffer []bool
+
+// appendBits appends the low n bits of value.
+func (b *bitBuffer) appendBits(value, n int) {
+    for i := n - 1; i >= 0; i-- {
+        *b = append(*b, (value>>i)&1 == 1)
+    }
+}
+
+// encodeData builds the data codewords: mode, count, payload, terminator and padding.
+func encodeData(version int, data []byte) []byte {
+    var bits bitBuffer
+    bits.appendBits(0x4, 4)
+    bits.appendBits(len(data), charCountBits(version))
+    for _, b := range data {
+        bits.appendBits(int(b), 8)
+    }
+
+    capacity := numDataCodewords(version) * 8
+    bits.appendBits(0, min(4, capacity-len(bits)))
+    bits.appendBits(0, (8-len(bits)%8)%8)
+    for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+        bits.appendBits(pad, 8)
+    }
+
+    codewords := make([]byte, len(bits)/8)
+    for i, bit := range bits {
+        if bit {
+            codewords[i>>3] |= 1 << (7 - uint(i&7))
+        }
+    }
+    return codewords
+}
+
+// addErrorCorrection splits data into blocks, appends Reed-Solomon codewords and interleaves them.
+func addErrorCorrection(version int, data []byte) []byte {
+    numBlocks := numECCBlocks[version]
+    blockECCLen := eccCodewordsPerBlock[version]
+    rawCodewords := numRawDataModules(version) / 8
+    numShortBlocks := numBlocks - rawCodewords%numBlocks
+    shortBlockLen := rawCodewords / numBlocks
+
+    divisor := reedSolomonDivisor(blockECCLen)
+    blocks := make([][]byte, 0, numBlocks)
+    k := 0
+    for i := 0; i < numBlocks; i++ {
+        n := shortBlockLen - blockECCLen
+        if i >= numShortBlocks {
+            n++
+        }
+        block := append([]byte{}, data[k:k+n]...)
+        k += n
+        ecc := reedSolomonRemainder(block, divisor)
+        if i < numShortBlocks {
+            block = append(block, 0)
+        }
+        blocks = append(blocks, append(block, ecc...))
+    }
+
+    result := make([]byte, 0, rawCodewords)
+    for i := range blocks[0] {
+        for j, block := range blocks {
+            if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+                result = append(result, block[i])
+            }
+        }
+    }
+    return result
+}
+
+// reedSolomonDivisor returns the generator polynomial coefficients for the given degree.
+func reedSolomonDivisor(degree int) []byte {
+    result := make([]byte, degree)
+    result[degree-1] = 1
+    root := byte(1)
+    for i := 0; i < degree; i++ {
+        for j := range result {
+            result[j] = gfMultiply(result[j], root)
+            if j+1 < len(result) {
+                result[j] ^= result[j+1]
+            }
+        }
+        root = gfMultiply(root, 0x02)
+    }
+    return result
+}
+
+// reedSolomonRemainder computes the error correction codewords for a block.
+func reedSolomonRemainder(data, divisor []byte) []byte {
+    result := make([]byte, len(divisor))
+    for _, b := range data {
+        factor := b ^ result[0]
+        copy(result, result[1:])
+        result[len(result)-1] = 0
+        for i := range result {
+            result[i] ^= gfMultiply(divisor[i], factor)
+        }
+    }
+    return result
+}
+
+// gfMultiply multiplies two elements of GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1.
+func gfMultiply(x, y byte) byte {
+    z := 0
+    for i := 7; i >= 0; i-- {
+        z = (z << 1) ^ ((z >> 7) * 0x11D)
+        z ^= int((y>>uint(i))&1) * int(x)
+    }
+    return byte(z)
+}
+
+// set writes a function module and marks it as reserved.
+func (c *Code) set(function [][]bool, x, y int, dark bool) {
+    c.Modules[y][x] = dark
+    function[y][x] = true
+}
+
+// drawFunctionPatterns draws finder, timing and alignment patterns and reserves format/version areas.
+func (c *Code) drawFunctionPatterns(function [][]bool) {
+    for i := 0; i < c.Size; i++ {
+        c.set(function, 6, i, i%2 == 0)
+        c.set(function, i, 6, i%2 == 0)
+    }
+
+    c.drawFinderPattern(function, 3, 3)
+    c.drawFinderPattern(function, c.Size-4, 3)
+    c.drawFinderPattern(function, 3, c.Size-4)
+
+    positions := alignmentPatternPositions(c.Version, c.Size)
+    last := len(positions) - 1
+    for i, x := range positions {
+        for j, y := range positions {
+            if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+                continue
+            }
+            for dy := -2; dy <= 2; dy++ {
+                for dx := -2; dx <= 2; dx++ {
+                    c.set(function, x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+                }
+            }
+        }
+    }
+
+    c.drawFormatBits(function, 0)
+    c.drawVersion(function)
+}
+
+// drawFinderPattern draws a finder pattern and its separator centred on (x, y).
+func (c *Code) drawFinderPattern(function [][]bool, x, y int) {
+    for dy := -4; dy <= 4; dy++ {
+        for dx := -4; dx <= 4; dx++ {
+            xx, yy := x+dx, y+dy
+            if xx < 0 || xx >= c.Size || yy < 0 || yy >= c.Size {
+                continue
+            }
+            dist := max(abs(dx), abs(dy))
+            c.set(function, xx, yy, dist != 2 && dist != 4)
+        }
+    }
+}
+
+// alignmentPatternPositions returns the centre coordinates of alignment patterns for a version.
+func alignmentPatternPositions(version, size int) []int {
+    if version == 1 {
+        return nil
+    }
+    numAlign := version/7 + 2
+    step := (version*4 + numAlign*2 + 1) / (numAlign*2 - 2) * 2
+    result := make([]int, numAlign)
+    result[0] = 6
+    for i, pos := numAlign-1, size-7; i >= 1; i, pos = i-1, pos-step {
+        result[i] = pos
+    }
+    return result
+}
+
+// drawFormatBits writes both copies of the format information for a mask.
+func (c *Code) drawFormatBits(function [][]bool, mask int) {
+    data := formatBitsLevelM<<3 | mask
+    rem := data
+    for i := 0; i < 10; i++ {
+        rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+    }
+    bits := (data<<10 | rem) ^ 0x5412
+
+    bit := func(i int) bool { return (bits>>uint(i))&1 == 1 }
+
+    for i := 0; i <= 5; i++ {
+        c.set(function, 8, i, bit(i))
+    }
+    c.set(function, 8, 7, bit(6))
+    c.set(function, 8, 8, bit(7))
+    c.set(function, 7, 8, bit(8))
+    for i := 9; i < 15; i++ {
+        c.set(function, 14-i, 8, bit(i))
+    }
+
+    for i := 0; i < 8; i++ {
+        c.set(function, c.Size-1-i, 8, bit(i))
+    }
+    for i := 8; i < 15; i++ {
+        c.set(function, 8, c.Size-15+i, bit(i))
+    }
+    c.set(function, 8, c.Size-8, true)
+}
+
+// drawVersion writes the version information blocks for versions 7 and up.
+func (c *Code) drawVersion(function [][]bool) {
+    if c.Version < 7 {
+        return
+    }
+    rem := c.Version
+    for i := 0; i < 12; i++ {
+        rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+    }
+    bits := c.Version<<12 | rem
+
+    for i := 0; i < 18; i++ {
+        dark := (bits>>uint(i))&1 == 1
+        a := c.Size - 11 + i%3
+        b := i / 3
+        c.set(function, a, b, dark)
+        c.set(function, b, a, dark)
+    }
+}
+
+// drawCodewords places the interleaved codewords in the zigzag pattern, skipping function modules.
+func (c *Code) drawCodewords(function [][]bool, data []byte) {
+    i := 0
+    for right := c.Size - 1; right >= 1; right -= 2 {
+        if right == 6 {
+            right = 5
+        }
+        for vert := 0; vert < c.Size; vert++ {
+            for j := 0; j < 2; j++ {
+                x := right - j
+                upward := (right+1)&2 == 0
+                y := vert
+                if upward {
+                    y = c.Size - 1 - vert
+                }
+                if !function[y][x] && i < len(data)*8 {
+                    c.Modules[y][x] = (data[i>>3]>>(7-uint(i&7)))&1 == 1
+                    i++
+                }
+            }
+        }
+    }
+}
+
+// applyMask XORs a mask pattern over every non-function module. Applying it twice undoes it.
+func (c *Code) applyMask(function [][]bool, mask int) {
+    for y := 0; y < c.Size; y++ {
+        for x := 0; x < c.Size; x++ {
+            if function[y][x] {
+                continue
+            }
+            var invert bool
+            switch mask {
+            case 0:
+                invert = (x+y)%2 == 0
+            case 1:
+                invert = y%2 == 0
+            case 2:
+                invert = x%3 == 0
+            case 3:
+                invert = (x+y)%3 == 0
+            case 4:
+                invert = (x/3+y/2)%2 == 0
+            case 5:
+                invert = x*y%2+x*y%3 == 0
+            case 6:
+                invert = (x*y%2+x*y%3)%2 == 0
+            case 7:
+                invert = ((x+y)%2+x*y%3)%2 == 0
+            }
+            if invert {
+                c.Modules[y][x] = !c.Modules[y][x]
+            }
+        }
+    }
+}
+
+// penalty scores the symbol with the standard mask evaluation rules; lower is better.
+func (c *Code) penalty() int {
+    size := c.Size
+    result := 0
+
+    line := func(get func(i int) bool) {
+        run := 1
+        for i := 1; i <= size; i++ {
+            if i < size && get(i) == get(i-1) {
+                run++
+                continue
+            }
+            if run >= 5 {
+                result += run - 2
+            }
+            run = 1
+        }
+
+        finder := []bool{true, false, true, true, true, false, true}
+        for i := 0; i+7 <= size; i++ {
+            match := true
+            for k, want := range finder {
+                if get(i+k) != want {
+                    match = false
+                    break
+                }
+            }
+            if !match {
+                continue
+            }
+            lightBefore, lightAfter := true, true
+            for k := 1; k <= 4; k++ {
+                if i-k >= 0 && get(i-k) {
+                    lightBefore = false
+                }
+                if i+6+k < size && get(i+6+k) {
+                    lightAfter = false
+                }
+            }
+            if lightBefore || lightAfter {
+                result += 40
+            }
+        }
+    }
+
+    for y := 0; y < size; y++ {
+        line(func(i int) bool { return c.Modules[y][i] })
+    }
+    for x := 0; x < size; x++ {
+        line(func(i int) bool { return c.Modules[i][x] })
+    }
+
+    dark := 0
+    for y := 0; y < size; y++ {
+        for x := 0; x < size; x++ {
+            if c.Modules[y][x] {
+                dark++
+            }
+            if x+1 < size && y+1 < size {
+                v := c.Modules[y][x]
+                if v == c.Modules[y][x+1] && v == c.Modules[y+1][x] && v == c.Modules[y+1][x+1] {
+                    result += 3
+                }
+            }
+        }
+    }
+
+    total := size * size
+    k := (abs(dark*20-total*10)+total-1)/total - 1
+    result += k * 10
+    return result
+}
+
+// abs returns the absolute value of an int.
+func abs(v int) int {
+    if v < 0 {
+        return -v
+    }
+    return v
+}
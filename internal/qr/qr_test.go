@@ -0,0 +1,132 @@
+package qr
+
+import (
+    "bytes"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+// readMatrix loads a reference symbol from testdata: one line per row, '#' for a dark
+// module and '.' for a light one.
+func readMatrix(t *testing.T, name string) []string {
+    t.Helper()
+    data, err := os.ReadFile(filepath.Join("testdata", name))
+    if err != nil {
+        t.Fatal(err)
+    }
+    return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// rows formats c's modules the way readMatrix reads them.
+func (c *Code) rows() []string {
+    rows := make([]string, c.Size)
+    for y, line := range c.Modules {
+        var b strings.Builder
+        for _, dark := range line {
+            if dark {
+                b.WriteByte('#')
+            } else {
+                b.WriteByte('.')
+            }
+        }
+        rows[y] = b.String()
+    }
+    return rows
+}
+
+// The reference symbols were produced by an independent encoder (rsc.io/qr) for the same
+// payload, version and mask, and cover single and multiple blocks, alignment patterns,
+// version information and the 16-bit character count of version 10.
+func TestEncodeMatchesReference(t *testing.T) {
+    tests := []struct {
+        data    string
+        mask    int
+        version int
+        file    string
+    }{
+        {"HELLO", 0, 1, "v1-mask0.txt"},
+        {"http://localhost:8080/items/42", 2, 3, "v3-mask2.txt"},
+        {strings.Repeat("0123456789", 8), 4, 5, "v5-mask4.txt"},
+        {strings.Repeat("item-", 24), 5, 7, "v7-mask5.txt"},
+        {strings.Repeat("https://inventory.example/items/", 6), 7, 10, "v10-mask7.txt"},
+    }
+    for _, tt := range tests {
+        t.Run(tt.file, func(t *testing.T) {
+            c, err := encode([]byte(tt.data), tt.mask)
+            if err != nil {
+                t.Fatal(err)
+            }
+            if c.Version != tt.version || c.Size != tt.version*4+17 {
+                t.Fatalf("version %d size %d, want version %d size %d", c.Version, c.Size, tt.version, tt.version*4+17)
+            }
+            want := readMatrix(t, tt.file)
+            got := c.rows()
+            for y := range want {
+                if got[y] != want[y] {
+                    t.Fatalf("row %d:\n got %s\nwant %s", y, got[y], want[y])
+                }
+            }
+        })
+    }
+}
+
+func TestEncodeChoosesSmallestVersion(t *testing.T) {
+    tests := []struct {
+        length  int
+        version int
+    }{
+        {0, 1},
+        {14, 1},
+        {15, 2},
+        {122, 7},
+        {123, 8},
+        {213, 10},
+    }
+    for _, tt := range tests {
+        c, err := Encode(bytes.Repeat([]byte("a"), tt.length))
+        if err != nil {
+            t.Fatalf("%d bytes: %v", tt.length, err)
+        }
+        if c.Version != tt.version {
+            t.Errorf("%d bytes: version %d, want %d", tt.length, c.Version, tt.version)
+        }
+    }
+
+    if _, err := Encode(bytes.Repeat([]byte("a"), 214)); err == nil {
+        t.Error("214 bytes: expected an error")
+    }
+}
+
+// Encode must leave exactly one mask applied, with format bits naming that mask.
+func TestEncodeAppliesOneMask(t *testing.T) {
+    data := []byte("http://localhost:8080/items/1234")
+    c, err := Encode(data)
+    if err != nil {
+        t.Fatal(err)
+    }
+    matches := 0
+    for mask := 0; mask < 8; mask++ {
+        fixed, err := encode(data, mask)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if strings.Join(fixed.rows(), "\n") == strings.Join(c.rows(), "\n") {
+            matches++
+        }
+    }
+    if matches != 1 {
+        t.Errorf("Encode output matches %d masks, want 1", matches)
+    }
+}
+
+// The data and error correction codewords of the version 1-M "01234567" example in
+// ISO/IEC 18004 Annex I.
+func TestReedSolomonRemainder(t *testing.T) {
+    data := []byte{0x10, 0x20, 0x0C, 0x56, 0x61, 0x80, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11}
+    want := []byte{0xA5, 0x24, 0xD4, 0xC1, 0xED, 0x36, 0xC7, 0x87, 0x2C, 0x55}
+    if got := reedSolomonRemainder(data, reedSolomonDivisor(len(want))); !bytes.Equal(got, want) {
+        t.Errorf("got % X, want % X", got, want)
+    }
+}
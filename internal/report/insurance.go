@@ -0,0 +1,172 @@
+// Package report renders printable reports about the inventory.
+package report
+
+import (
+    "fmt"
+    "io"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/pdf"
+)
+
+// Insurance report page layout, in points. Pages are Letter landscape.
+const (
+    pageWidth  = pdf.LetterHeight
+    pageHeight = pdf.LetterWidth
+    margin     = 36.0
+    rowSize    = 8.5
+    rowHeight  = 13.0
+    cellPad    = 4.0
+)
+
+// column is one column of the asset table.
+type column struct {
+    title string
+    width float64
+    right bool // right-aligned, for numbers
+    value func(line inventory.InsuranceLine) string
+}
+
+// insuranceColumns lays out the asset table; the widths fill the space between margins.
+var insuranceColumns = []column{
+    {"Item", 140, false, func(l inventory.InsuranceLine) string { return l.ItemName }},
+    {"Qty", 28, true, func(l inventory.InsuranceLine) string { return fmt.Sprint(l.Quantity) }},
+    {"Make / Model", 130, false, func(l inventory.InsuranceLine) string { return joinNonEmpty(l.Manufacturer, l.Model) }},
+    {"Serial Number", 96, false, func(l inventory.InsuranceLine) string { return l.SerialNumber }},
+    {"Purchased", 60, false, func(l inventory.InsuranceLine) string { return date(l.PurchaseDate) }},
+    {"Price", 66, true, func(l inventory.InsuranceLine) string { return money(l.PurchasePrice) }},
+    {"Warranty", 60, false, func(l inventory.InsuranceLine) string { return date(l.WarrantyExpiry) }},
+    {"Value", 66, true, func(l inventory.InsuranceLine) string { return money(l.CurrentValue) }},
+    {"Total Value", 74, true, func(l inventory.InsuranceLine) string { return inventory.FormatMoney(l.ValueTotal) }},
+}
+
+// InsurancePDF writes the insurance report as a PDF: one table per room with a room
+// total, then the grand totals. Rooms that do not fit on the current page continue on
+// the next, repeating the column headings.
+func InsurancePDF(w io.Writer, report inventory.InsuranceReport) error {
+    doc := pdf.New(pageWidth, pageHeight)
+    y := 0.0
+    page := 0
+
+    newPage := func() {
+        doc.AddPage()
+        page++
+        doc.Text(margin, margin+14, 16, true, "Home Inventory for Insurance")
+        generated := "Generated " + report.Generated.Format("January 2, 2006 15:04")
+        doc.Text(pageWidth-margin-pdf.TextWidth(generated, 9, false), margin+14, 9, false, generated)
+        footer := fmt.Sprintf("Page %d", page)
+        doc.Text(pageWidth-margin-pdf.TextWidth(footer, 8, false), pageHeight-margin/2, 8, false, footer)
+        y = margin + 36
+    }
+    // ensure starts a new page unless height more points fit on this one.
+    ensure := func(height float64) bool {
+        if page == 0 || y+height > pageHeight-margin {
+            newPage()
+            return true
+        }
+        return false
+    }
+
+    ensure(0)
+    if len(report.Rooms) == 0 {
+        doc.Text(margin, y, 11, false, "No durable goods are recorded. Mark items as assets on their pages to list them here.")
+    }
+    for _, room := range report.Rooms {
+        ensure(rowHeight * 4)
+        doc.Text(margin, y, 12, true, room.Room)
+        y += rowHeight
+        tableHeader(doc, &y)
+
+        for _, line := range room.Lines {
+            if ensure(rowHeight) {
+                doc.Text(margin, y, 12, true, room.Room+" (continued)")
+                y += rowHeight
+                tableHeader(doc, &y)
+            }
+            row(doc, y, func(c column) string { return c.value(line) }, false)
+            y += rowHeight
+        }
+
+        doc.Line(margin, y-rowHeight+3, pageWidth-margin, y-rowHeight+3)
+        totals := map[string]string{
+            "Item":        room.Room + " total",
+            "Price":       inventory.FormatMoney(room.PurchaseTotal),
+            "Total Value": inventory.FormatMoney(room.ValueTotal),
+        }
+        row(doc, y, func(c column) string { return totals[c.title] }, true)
+        y += rowHeight * 2
+    }
+
+    if len(report.Rooms) > 0 {
+        ensure(rowHeight * 4)
+        doc.Line(margin, y-rowHeight+3, pageWidth-margin, y-rowHeight+3)
+        doc.Text(margin, y, 11, true, fmt.Sprintf("Total: %d assets in %d rooms", report.Items, len(report.Rooms)))
+        y += rowHeight * 1.4
+        doc.Text(margin, y, 10, false, "Purchase price total: "+inventory.FormatMoney(report.PurchaseTotal))
+        y += rowHeight
+        doc.Text(margin, y, 10, true, "Current value total: "+inventory.FormatMoney(report.ValueTotal))
+        y += rowHeight
+        if report.Unvalued > 0 {
+            doc.Text(margin, y, 9, false, fmt.Sprintf(
+                "%d of the assets have no current value recorded and count as zero in the value totals.", report.Unvalued))
+        }
+    }
+
+    _, err := doc.WriteTo(w)
+    return err
+}
+
+// tableHeader draws the column headings at y and moves y below them.
+func tableHeader(doc *pdf.Document, y *float64) {
+    row(doc, *y, func(c column) string { return c.title }, true)
+    doc.Line(margin, *y+3, pageWidth-margin, *y+3)
+    *y += rowHeight
+}
+
+// row draws one table row with its baseline at y, shortening text that does not fit.
+func row(doc *pdf.Document, y float64, text func(column) string, bold bool) {
+    x := margin
+    for _, c := range insuranceColumns {
+        s := pdf.FitText(text(c), rowSize, bold, c.width-cellPad)
+        switch {
+        case s == "":
+        case c.right:
+            doc.Text(x+c.width-cellPad-pdf.TextWidth(s, rowSize, bold), y, rowSize, bold, s)
+        default:
+            doc.Text(x, y, rowSize, bold, s)
+        }
+        x += c.width
+    }
+}
+
+// money formats an optional amount, leaving unknown amounts blank.
+func money(cents *int64) string {
+    if cents == nil {
+        return ""
+    }
+    return inventory.FormatMoney(*cents)
+}
+
+// date formats an optional date, leaving unknown dates blank.
+func date(t *time.Time) string {
+    if t == nil {
+        return ""
+    }
+    return t.Format(time.DateOnly)
+}
+
+// joinNonEmpty joins the non-blank values with a space.
+func joinNonEmpty(values ...string) string {
+    s := ""
+    for _, v := range values {
+        if v == "" {
+            continue
+        }
+        if s != "" {
+            s += " "
+        }
+        s += v
+    }
+    return s
+}
@@ -0,0 +1,199 @@
+package tui
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// Backend is where the terminal UI reads and changes the inventory.
+type Backend interface {
+    // Name describes the backend for the title bar.
+    Name() string
+    Items() ([]inventory.InventoryItemWithDetails, error)
+    Types() ([]inventory.ItemType, error)
+    Substitutions() ([]inventory.ItemSubstitution, error)
+    Expiring(days int) ([]inventory.ExpiringUnits, error)
+    // Adjust adds ("+") or uses up ("-") one unit of the named item.
+    Adjust(itemName, action string) error
+    // Dispose throws out the oldest unit of the named item.
+    Dispose(itemName string) error
+    AddItem(item inventory.InventoryItem) error
+}
+
+// backendTimeout bounds each call to a backend so a stuck database or server cannot
+// freeze the screen.
+const backendTimeout = 10 * time.Second
+
+// DatabaseBackend talks to the database directly.
+type DatabaseBackend struct {
+    DB *inventory.Database
+}
+
+// Name describes the backend for the title bar.
+func (b DatabaseBackend) Name() string {
+    return "database " + b.DB.Name()
+}
+
+// Items lists every inventory item.
+func (b DatabaseBackend) Items() ([]inventory.InventoryItemWithDetails, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    return inventory.GetItemList(ctx, b.DB, 0, "", false)
+}
+
+// Types lists the item types.
+func (b DatabaseBackend) Types() ([]inventory.ItemType, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    return inventory.GetItemTypes(ctx, b.DB)
+}
+
+// Substitutions lists the item substitutions.
+func (b DatabaseBackend) Substitutions() ([]inventory.ItemSubstitution, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    return inventory.GetItemSubstitutions(ctx, b.DB)
+}
+
+// Expiring lists units expiring within the given number of days.
+func (b DatabaseBackend) Expiring(days int) ([]inventory.ExpiringUnits, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    return inventory.GetExpiringUnits(ctx, b.DB, days)
+}
+
+// Adjust adds or uses up one unit of the named item.
+func (b DatabaseBackend) Adjust(itemName, action string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    _, err := inventory.UpdateItemQty(ctx, b.DB, itemName, action)
+    return err
+}
+
+// Dispose throws out the oldest unit of the named item.
+func (b DatabaseBackend) Dispose(itemName string) error {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    _, err := inventory.DisposeItem(ctx, b.DB, itemName)
+    return err
+}
+
+// AddItem inserts a new item.
+func (b DatabaseBackend) AddItem(item inventory.InventoryItem) error {
+    ctx, cancel := context.WithTimeout(context.Background(), backendTimeout)
+    defer cancel()
+    _, err := inventory.InsertItem(ctx, b.DB, item)
+    return err
+}
+
+// APIBackend talks to a running server over its HTTP API.
+type APIBackend struct {
+    BaseURL string
+    Client  *http.Client
+}
+
+// NewAPIBackend returns a backend for the server at baseURL, e.g. http://localhost:8080.
+func NewAPIBackend(baseURL string) *APIBackend {
+    return &APIBackend{
+        BaseURL: strings.TrimRight(baseURL, "/"),
+        Client:  &http.Client{Timeout: backendTimeout},
+    }
+}
+
+// Name describes the backend for the title bar.
+func (b *APIBackend) Name() string {
+    return b.BaseURL
+}
+
+// Items lists every inventory item.
+func (b *APIBackend) Items() ([]inventory.InventoryItemWithDetails, error) {
+    var items []inventory.InventoryItemWithDetails
+    return items, b.get("/items", &items)
+}
+
+// Types lists the item types.
+func (b *APIBackend) Types() ([]inventory.ItemType, error) {
+    var types []inventory.ItemType
+    return types, b.get("/types", &types)
+}
+
+// Substitutions lists the item substitutions.
+func (b *APIBackend) Substitutions() ([]inventory.ItemSubstitution, error) {
+    var substitutions []inventory.ItemSubstitution
+    return substitutions, b.get("/substitutions", &substitutions)
+}
+
+// Expiring lists units expiring within the given number of days.
+func (b *APIBackend) Expiring(days int) ([]inventory.ExpiringUnits, error) {
+    var expiring []inventory.ExpiringUnits
+    return expiring, b.get("/expiring?days="+strconv.Itoa(days), &expiring)
+}
+
+// Adjust adds or uses up one unit of the named item.
+func (b *APIBackend) Adjust(itemName, action string) error {
+    return b.post("/item/update", url.Values{"itemName": {itemName}, "action": {action}})
+}
+
+// Dispose throws out the oldest unit of the named item.
+func (b *APIBackend) Dispose(itemName string) error {
+    return b.post("/item/dispose", url.Values{"itemName": {itemName}})
+}
+
+// AddItem inserts a new item.
+func (b *APIBackend) AddItem(item inventory.InventoryItem) error {
+    form := url.Values{
+        "itemName":             {item.ItemName},
+        "itemQTY":              {strconv.Itoa(item.ItemQTY)},
+        "minimumQTY":           {strconv.Itoa(item.MinimumQTY)},
+        "itemTypeID":           {strconv.Itoa(item.ItemTypeID)},
+        "itemSubstitutionID":   {strconv.Itoa(item.ItemSubstitutionID)},
+        "itemExpirationPeriod": {strconv.Itoa(item.ItemExpirationPeriod)},
+        "barcode":              item.Barcodes,
+    }
+    return b.post("/item/add", form)
+}
+
+// get fetches path and decodes the JSON response into v.
+func (b *APIBackend) get(path string, v interface{}) error {
+    resp, err := b.Client.Get(b.BaseURL + path)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    if err := checkResponse(resp); err != nil {
+        return err
+    }
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// post submits form to path.
+func (b *APIBackend) post(path string, form url.Values) error {
+    resp, err := b.Client.PostForm(b.BaseURL+path, form)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+    return checkResponse(resp)
+}
+
+// checkResponse turns an error status into an error carrying the server's message.
+func checkResponse(resp *http.Response) error {
+    if resp.StatusCode < 300 {
+        return nil
+    }
+    body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+    message := strings.TrimSpace(string(body))
+    if message == "" {
+        message = resp.Status
+    }
+    return fmt.Errorf("%s", message)
+}
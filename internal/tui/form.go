@@ -0,0 +1,132 @@
+package tui
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// formField is one input of the add-item form: free text, a number, or a choice from a list.
+type formField struct {
+    label    string
+    value    string
+    numeric  bool
+    optional bool
+    choices  []string
+    choice   int
+}
+
+// itemForm mirrors the add-item form on the web page.
+type itemForm struct {
+    fields        []*formField
+    focus         int
+    err           string
+    types         []inventory.ItemType
+    substitutions []inventory.ItemSubstitution
+}
+
+// Indexes of the fields in itemForm.fields.
+const (
+    fieldName = iota
+    fieldType
+    fieldSubstitution
+    fieldQTY
+    fieldMinimumQTY
+    fieldExpirationPeriod
+    fieldBarcode
+)
+
+// newItemForm creates an empty add-item form offering the given types and substitutions.
+func newItemForm(types []inventory.ItemType, substitutions []inventory.ItemSubstitution) *itemForm {
+    typeNames := make([]string, len(types))
+    for i, t := range types {
+        typeNames[i] = t.Name
+    }
+    substitutionNames := make([]string, len(substitutions))
+    for i, s := range substitutions {
+        substitutionNames[i] = s.Name
+    }
+
+    return &itemForm{
+        types:         types,
+        substitutions: substitutions,
+        fields: []*formField{
+            fieldName:             {label: "Item Name"},
+            fieldType:             {label: "Item Type", choices: typeNames},
+            fieldSubstitution:     {label: "Item Substitution", choices: substitutionNames},
+            fieldQTY:              {label: "Quantity", numeric: true},
+            fieldMinimumQTY:       {label: "Minimum Quantity", numeric: true},
+            fieldExpirationPeriod: {label: "Expiration Period (Days)", numeric: true},
+            fieldBarcode:          {label: "Barcode (optional)", optional: true},
+        },
+    }
+}
+
+// handleKey moves between fields, cycles choices and edits text.
+func (f *itemForm) handleKey(k key) {
+    field := f.fields[f.focus]
+    switch k.code {
+    case keyTab, keyDown:
+        f.focus = (f.focus + 1) % len(f.fields)
+    case keyBackTab, keyUp:
+        f.focus = (f.focus + len(f.fields) - 1) % len(f.fields)
+    case keyLeft:
+        if len(field.choices) > 0 {
+            field.choice = (field.choice + len(field.choices) - 1) % len(field.choices)
+        }
+    case keyRight:
+        if len(field.choices) > 0 {
+            field.choice = (field.choice + 1) % len(field.choices)
+        }
+    case keyBackspace:
+        field.value = dropLastRune(field.value)
+    case keyRune:
+        switch {
+        case field.choices != nil:
+            if k.r == ' ' && len(field.choices) > 0 {
+                field.choice = (field.choice + 1) % len(field.choices)
+            }
+        case field.numeric && (k.r < '0' || k.r > '9'):
+        default:
+            field.value += string(k.r)
+        }
+    }
+}
+
+// item validates the form and builds the item it describes.
+func (f *itemForm) item() (inventory.InventoryItem, error) {
+    name := strings.TrimSpace(f.fields[fieldName].value)
+    if name == "" {
+        return inventory.InventoryItem{}, fmt.Errorf("Item Name is required")
+    }
+    if len(f.types) == 0 {
+        return inventory.InventoryItem{}, fmt.Errorf("no item types exist; add one first")
+    }
+    if len(f.substitutions) == 0 {
+        return inventory.InventoryItem{}, fmt.Errorf("no item substitutions exist; add one first")
+    }
+
+    numbers := map[int]int{}
+    for _, i := range []int{fieldQTY, fieldMinimumQTY, fieldExpirationPeriod} {
+        n, err := strconv.Atoi(f.fields[i].value)
+        if err != nil {
+            return inventory.InventoryItem{}, fmt.Errorf("%s must be a number", f.fields[i].label)
+        }
+        numbers[i] = n
+    }
+
+    item := inventory.InventoryItem{
+        ItemName:             name,
+        ItemQTY:              numbers[fieldQTY],
+        MinimumQTY:           numbers[fieldMinimumQTY],
+        ItemTypeID:           f.types[f.fields[fieldType].choice].ID,
+        ItemSubstitutionID:   f.substitutions[f.fields[fieldSubstitution].choice].ID,
+        ItemExpirationPeriod: numbers[fieldExpirationPeriod],
+    }
+    if barcode := strings.TrimSpace(f.fields[fieldBarcode].value); barcode != "" {
+        item.Barcodes = []string{barcode}
+    }
+    return item, nil
+}
@@ -0,0 +1,100 @@
+package tui
+
+import "unicode/utf8"
+
+// keyCode identifies a special key; printable characters use keyRune.
+type keyCode int
+
+const (
+    keyRune keyCode = iota
+    keyUp
+    keyDown
+    keyLeft
+    keyRight
+    keyPageUp
+    keyPageDown
+    keyHome
+    keyEnd
+    keyEnter
+    keyEscape
+    keyBackspace
+    keyTab
+    keyBackTab
+    keyCtrlC
+)
+
+// key is a single key press.
+type key struct {
+    code keyCode
+    r    rune
+}
+
+// escapeSequences maps the terminal escape sequences the UI understands to keys.
+var escapeSequences = map[string]keyCode{
+    "[A": keyUp, "OA": keyUp,
+    "[B": keyDown, "OB": keyDown,
+    "[C": keyRight, "OC": keyRight,
+    "[D": keyLeft, "OD": keyLeft,
+    "[H": keyHome, "OH": keyHome, "[1~": keyHome, "[7~": keyHome,
+    "[F": keyEnd, "OF": keyEnd, "[4~": keyEnd, "[8~": keyEnd,
+    "[5~": keyPageUp,
+    "[6~": keyPageDown,
+    "[Z": keyBackTab,
+}
+
+// parseKeys decodes the bytes of one read from a raw terminal into key presses.
+func parseKeys(b []byte) []key {
+    var keys []key
+    for len(b) > 0 {
+        switch c := b[0]; {
+        case c == 0x1b:
+            if len(b) == 1 {
+                keys = append(keys, key{code: keyEscape})
+                return keys
+            }
+            n := sequenceLength(b)
+            if code, ok := escapeSequences[string(b[1:n])]; ok {
+                keys = append(keys, key{code: code})
+            } else if n == 1 {
+                keys = append(keys, key{code: keyEscape})
+            }
+            b = b[n:]
+        case c == '\r' || c == '\n':
+            keys = append(keys, key{code: keyEnter})
+            b = b[1:]
+        case c == 0x7f || c == 0x08:
+            keys = append(keys, key{code: keyBackspace})
+            b = b[1:]
+        case c == '\t':
+            keys = append(keys, key{code: keyTab})
+            b = b[1:]
+        case c == 0x03:
+            keys = append(keys, key{code: keyCtrlC})
+            b = b[1:]
+        case c < 0x20:
+            b = b[1:]
+        default:
+            r, size := utf8.DecodeRune(b)
+            keys = append(keys, key{code: keyRune, r: r})
+            b = b[size:]
+        }
+    }
+    return keys
+}
+
+// sequenceLength returns the length of the escape sequence at the start of b,
+// or 1 when the escape is not followed by a CSI or SS3 introducer.
+func sequenceLength(b []byte) int {
+    if len(b) < 2 || (b[1] != '[' && b[1] != 'O') {
+        return 1
+    }
+    if b[1] == 'O' {
+        return min(3, len(b))
+    }
+    for i := 2; i < len(b); i++ {
+        if b[i] >= 0x40 && b[i] <= 0x7e {
+            return i + 1
+        }
+    }
+    return len(b)
+}
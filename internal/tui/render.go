@@ -0,0 +1,209 @@
+package tui
+
+import (
+    "fmt"
+    "strconv"
+    "strings"
+)
+
+// ANSI attributes used when drawing.
+const (
+    styleReset   = "\x1b[0m"
+    styleReverse = "\x1b[7m"
+    styleBold    = "\x1b[1m"
+    styleDim     = "\x1b[2m"
+    styleRed     = "\x1b[31m"
+    styleYellow  = "\x1b[33m"
+)
+
+// fixedLines counts the lines that are always drawn: title, filter, list header,
+// expiring pane title, status and key help.
+const fixedLines = 6
+
+// expiringHeight returns the number of rows given to the expiring-soon pane.
+func (a *app) expiringHeight() int {
+    return max(1, min(len(a.expiring), (a.height-fixedLines)/3))
+}
+
+// listHeight returns the number of item rows that fit on screen.
+func (a *app) listHeight() int {
+    return max(1, a.height-fixedLines-a.expiringHeight())
+}
+
+// draw repaints the whole screen.
+func (a *app) draw() {
+    var lines []string
+    add := func(style, s string) {
+        line := fit(s, a.width)
+        if style != "" {
+            line = style + line + styleReset
+        }
+        lines = append(lines, line)
+    }
+
+    add(styleReverse+styleBold, fmt.Sprintf(" MyHomeInventory  |  %s  |  %d of %d items",
+        a.backend.Name(), len(a.visible), len(a.items)))
+
+    switch {
+    case a.mode == modeFilter:
+        add("", " Filter: "+a.filter+"_")
+    case a.filter != "":
+        add("", " Filter: "+a.filter+"  (Esc clears)")
+    default:
+        add(styleDim, " Press / to filter by name or type")
+    }
+
+    if a.mode == modeForm {
+        a.drawForm(add, a.listHeight()+1)
+    } else {
+        a.drawList(add)
+    }
+
+    add(styleBold, fmt.Sprintf(" Expiring within %d days", a.opts.ExpiringDays))
+    a.drawExpiring(add)
+
+    add("", " "+a.status)
+    add(styleReverse, " "+a.help())
+
+    fmt.Fprint(a.out, "\x1b[H"+strings.Join(lines, "\x1b[K\r\n")+"\x1b[K\x1b[J")
+}
+
+// drawList draws the item table, scrolled so the selected row is visible.
+func (a *app) drawList(add func(style, s string)) {
+    height := a.listHeight()
+    if a.selected < a.offset {
+        a.offset = a.selected
+    }
+    if a.selected >= a.offset+height {
+        a.offset = a.selected - height + 1
+    }
+    a.offset = max(0, min(a.offset, len(a.visible)-height))
+
+    nameWidth := 10
+    typeWidth := 8
+    for _, item := range a.visible {
+        nameWidth = max(nameWidth, len([]rune(item.ItemName)))
+        typeWidth = max(typeWidth, len([]rune(item.ItemTypeName)))
+    }
+    nameWidth = min(nameWidth, max(10, a.width/3))
+    typeWidth = min(typeWidth, 20)
+
+    row := func(name, qty, min, used, tossed, typeName, substitution string) string {
+        return fmt.Sprintf(" %s %5s %5s %6s %7s  %s %s",
+            fit(name, nameWidth), qty, min, used, tossed, fit(typeName, typeWidth), substitution)
+    }
+    add(styleBold, row("NAME", "QTY", "MIN", "USED", "TOSSED", "TYPE", "SUBSTITUTION"))
+
+    for i := 0; i < height; i++ {
+        index := a.offset + i
+        if index >= len(a.visible) {
+            if i == 0 {
+                add(styleDim, " No items match.")
+            } else {
+                add("", "")
+            }
+            continue
+        }
+        item := a.visible[index]
+        style := ""
+        if item.ItemQTY < item.MinimumQTY {
+            style = styleYellow
+        }
+        if index == a.selected && a.mode != modeFilter {
+            style += styleReverse
+        }
+        add(style, row(item.ItemName, strconv.Itoa(item.ItemQTY), strconv.Itoa(item.MinimumQTY),
+            strconv.Itoa(item.ItemUsedToDate), strconv.Itoa(item.ItemTotalTossed),
+            item.ItemTypeName, item.ItemSubstitutionName))
+    }
+}
+
+// drawExpiring draws the expiring-soon pane, expired units first.
+func (a *app) drawExpiring(add func(style, s string)) {
+    height := a.expiringHeight()
+    if len(a.expiring) == 0 {
+        add(styleDim, " Nothing expires soon.")
+        return
+    }
+    for i := 0; i < height; i++ {
+        if i == height-1 && len(a.expiring) > height {
+            add(styleDim, fmt.Sprintf(" ... and %d more", len(a.expiring)-i))
+            return
+        }
+        e := a.expiring[i]
+        style, when := "", fmt.Sprintf("in %d days", e.DaysLeft)
+        switch {
+        case e.DaysLeft < 0:
+            style, when = styleRed, fmt.Sprintf("expired %d days ago", -e.DaysLeft)
+        case e.DaysLeft == 0:
+            style, when = styleRed, "today"
+        case e.DaysLeft == 1:
+            style, when = styleYellow, "tomorrow"
+        }
+        add(style, fmt.Sprintf(" %-30s %3d unit(s)  %s  %s",
+            fit(e.ItemName, 30), e.Units, e.ExpirationDate.Format("2006-01-02"), when))
+    }
+}
+
+// drawForm draws the add-item form in place of the item table.
+func (a *app) drawForm(add func(style, s string), height int) {
+    f := a.form
+    lines := 0
+    emit := func(style, s string) {
+        if lines < height {
+            add(style, s)
+            lines++
+        }
+    }
+
+    emit(styleBold, " Add Item")
+    for i, field := range f.fields {
+        marker := "  "
+        style := ""
+        if i == f.focus {
+            marker = "> "
+            style = styleBold
+        }
+        value := field.value
+        switch {
+        case field.choices != nil && len(field.choices) == 0:
+            value = "(none defined)"
+        case field.choices != nil:
+            value = "< " + field.choices[field.choice] + " >"
+        case i == f.focus:
+            value += "_"
+        }
+        emit(style, fmt.Sprintf(" %s%-26s %s", marker, field.label+":", value))
+    }
+    if f.err != "" {
+        emit(styleRed, " "+f.err)
+    }
+    for lines < height {
+        emit("", "")
+    }
+}
+
+// help describes the keys available in the current mode.
+func (a *app) help() string {
+    switch a.mode {
+    case modeFilter:
+        return "type to filter  Enter done  Esc clear"
+    case modeConfirmDispose:
+        return "y dispose  any other key cancels"
+    case modeForm:
+        return "Tab/Up/Down field  Left/Right choose  Enter add  Esc cancel"
+    }
+    return "Up/Down move  / filter  +/- add or use one  d dispose  a add item  r refresh  q quit"
+}
+
+// fit truncates or pads s to exactly width characters.
+func fit(s string, width int) string {
+    r := []rune(s)
+    if len(r) > width {
+        if width <= 1 {
+            return string(r[:max(0, width)])
+        }
+        return string(r[:width-1]) + "~"
+    }
+    return s + strings.Repeat(" ", width-len(r))
+}
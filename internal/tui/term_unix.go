@@ -0,0 +1,65 @@
+//go:build !windows
+
+package tui
+
+import (
+    "os"
+    "os/exec"
+    "os/signal"
+    "strconv"
+    "strings"
+    "syscall"
+)
+
+// terminal puts the controlling terminal into raw mode using stty and restores it afterwards.
+type terminal struct {
+    saved string
+}
+
+// openTerminal switches standard input to raw mode with echo off.
+func openTerminal() (*terminal, error) {
+    saved, err := stty("-g")
+    if err != nil {
+        return nil, err
+    }
+    if _, err := stty("raw", "-echo"); err != nil {
+        return nil, err
+    }
+    return &terminal{saved: strings.TrimSpace(saved)}, nil
+}
+
+// Restore puts the terminal back into the state it was in before openTerminal.
+func (t *terminal) Restore() {
+    stty(t.saved)
+}
+
+// Size returns the terminal's width and height in characters.
+func (t *terminal) Size() (int, int) {
+    out, err := stty("size")
+    if err != nil {
+        return 80, 24
+    }
+    fields := strings.Fields(out)
+    if len(fields) != 2 {
+        return 80, 24
+    }
+    rows, err1 := strconv.Atoi(fields[0])
+    cols, err2 := strconv.Atoi(fields[1])
+    if err1 != nil || err2 != nil || rows <= 0 || cols <= 0 {
+        return 80, 24
+    }
+    return cols, rows
+}
+
+// notifyResize delivers a signal on ch whenever the terminal is resized.
+func notifyResize(ch chan<- os.Signal) {
+    signal.Notify(ch, syscall.SIGWINCH)
+}
+
+// stty runs stty against the terminal on standard input.
+func stty(args ...string) (string, error) {
+    cmd := exec.Command("stty", args...)
+    cmd.Stdin = os.Stdin
+    out, err := cmd.Output()
+    return string(out), err
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package tui
+
+import (
+    "errors"
+    "os"
+)
+
+// terminal is not implemented on Windows; run the TUI from WSL or over SSH instead.
+type terminal struct{}
+
+// openTerminal reports that raw terminal mode is unavailable.
+func openTerminal() (*terminal, error) {
+    return nil, errors.New("the terminal UI is not supported on Windows; use WSL or SSH")
+}
+
+// Restore does nothing.
+func (t *terminal) Restore() {}
+
+// Size returns a default size.
+func (t *terminal) Size() (int, int) {
+    return 80, 24
+}
+
+// notifyResize does nothing; Windows has no resize signal.
+func notifyResize(ch chan<- os.Signal) {}
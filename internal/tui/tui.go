@@ -0,0 +1,354 @@
+// Package tui is a full-screen terminal interface to the inventory for use over SSH or in tmux.
+// It needs no third-party libraries: the terminal is put into raw mode with stty and drawn
+// with ANSI escape sequences.
+package tui
+
+import (
+    "fmt"
+    "io"
+    "os"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// refreshInterval is how often the item list and expiring pane reload while idle.
+const refreshInterval = 10 * time.Second
+
+// mode is what the keyboard is currently driving.
+type mode int
+
+const (
+    modeList mode = iota
+    modeFilter
+    modeConfirmDispose
+    modeForm
+)
+
+// Options configures the terminal UI.
+type Options struct {
+    // ExpiringDays is how far ahead the expiring-soon pane looks.
+    ExpiringDays int
+    // Output is where the screen is drawn; standard output when nil.
+    Output io.Writer
+}
+
+// app holds the UI state.
+type app struct {
+    backend Backend
+    opts    Options
+    out     io.Writer
+    width   int
+    height  int
+
+    items         []inventory.InventoryItemWithDetails
+    visible       []inventory.InventoryItemWithDetails
+    expiring      []inventory.ExpiringUnits
+    types         []inventory.ItemType
+    substitutions []inventory.ItemSubstitution
+
+    mode       mode
+    filter     string
+    selectedID int
+    selected   int
+    offset     int
+    status     string
+    form       *itemForm
+    quit       bool
+}
+
+// Run takes over the terminal and runs the UI until the user quits.
+func Run(backend Backend, opts Options) error {
+    if opts.ExpiringDays <= 0 {
+        opts.ExpiringDays = 7
+    }
+    if opts.Output == nil {
+        opts.Output = os.Stdout
+    }
+
+    term, err := openTerminal()
+    if err != nil {
+        return err
+    }
+    defer term.Restore()
+
+    a := &app{backend: backend, opts: opts, out: opts.Output}
+    a.width, a.height = term.Size()
+
+    // Switch to the alternate screen and hide the cursor; undo both on exit.
+    fmt.Fprint(a.out, "\x1b[?1049h\x1b[?25l")
+    defer fmt.Fprint(a.out, "\x1b[?25h\x1b[?1049l")
+
+    a.reload()
+    a.draw()
+
+    input := make(chan []byte)
+    go readInput(os.Stdin, input)
+
+    resize := make(chan os.Signal, 1)
+    notifyResize(resize)
+
+    ticker := time.NewTicker(refreshInterval)
+    defer ticker.Stop()
+
+    for !a.quit {
+        select {
+        case b, ok := <-input:
+            if !ok {
+                return nil
+            }
+            for _, k := range parseKeys(b) {
+                a.handleKey(k)
+            }
+        case <-resize:
+            a.width, a.height = term.Size()
+        case <-ticker.C:
+            if a.mode == modeList {
+                a.reload()
+            }
+        }
+        a.draw()
+    }
+    return nil
+}
+
+// readInput forwards raw reads from r until it fails.
+func readInput(r io.Reader, ch chan<- []byte) {
+    buf := make([]byte, 64)
+    for {
+        n, err := r.Read(buf)
+        if n > 0 {
+            b := make([]byte, n)
+            copy(b, buf[:n])
+            ch <- b
+        }
+        if err != nil {
+            close(ch)
+            return
+        }
+    }
+}
+
+// reload fetches items, lookups and expiring units from the backend.
+func (a *app) reload() {
+    items, err := a.backend.Items()
+    if err != nil {
+        a.status = "Failed to load items: " + err.Error()
+        return
+    }
+    a.items = items
+
+    if a.expiring, err = a.backend.Expiring(a.opts.ExpiringDays); err != nil {
+        a.status = "Failed to load expiring items: " + err.Error()
+    }
+    if a.types, err = a.backend.Types(); err != nil {
+        a.status = "Failed to load item types: " + err.Error()
+    }
+    if a.substitutions, err = a.backend.Substitutions(); err != nil {
+        a.status = "Failed to load item substitutions: " + err.Error()
+    }
+    a.applyFilter()
+}
+
+// applyFilter rebuilds the visible rows from the filter, keeping the selected item if it is still shown.
+// The filter matches item names and type names, ignoring case.
+func (a *app) applyFilter() {
+    needle := strings.ToLower(strings.TrimSpace(a.filter))
+    a.visible = a.visible[:0]
+    for _, item := range a.items {
+        if needle == "" ||
+            strings.Contains(strings.ToLower(item.ItemName), needle) ||
+            strings.Contains(strings.ToLower(item.ItemTypeName), needle) {
+            a.visible = append(a.visible, item)
+        }
+    }
+
+    a.selected = 0
+    for i, item := range a.visible {
+        if item.ID == a.selectedID {
+            a.selected = i
+        }
+    }
+    a.moveSelection(0)
+}
+
+// moveSelection moves the cursor by delta rows, clamped to the visible rows.
+func (a *app) moveSelection(delta int) {
+    a.selected = max(0, min(a.selected+delta, len(a.visible)-1))
+    if len(a.visible) > 0 {
+        a.selectedID = a.visible[a.selected].ID
+    }
+}
+
+// current returns the selected item, if any.
+func (a *app) current() (inventory.InventoryItemWithDetails, bool) {
+    if a.selected < 0 || a.selected >= len(a.visible) {
+        return inventory.InventoryItemWithDetails{}, false
+    }
+    return a.visible[a.selected], true
+}
+
+// handleKey dispatches a key press according to the current mode.
+func (a *app) handleKey(k key) {
+    if k.code == keyCtrlC {
+        a.quit = true
+        return
+    }
+
+    switch a.mode {
+    case modeFilter:
+        a.handleFilterKey(k)
+    case modeConfirmDispose:
+        a.handleConfirmKey(k)
+    case modeForm:
+        a.handleFormKey(k)
+    default:
+        a.handleListKey(k)
+    }
+}
+
+// handleListKey handles keys while browsing the item list.
+func (a *app) handleListKey(k key) {
+    page := max(1, a.listHeight()-1)
+    switch k.code {
+    case keyUp:
+        a.moveSelection(-1)
+    case keyDown:
+        a.moveSelection(1)
+    case keyPageUp:
+        a.moveSelection(-page)
+    case keyPageDown:
+        a.moveSelection(page)
+    case keyHome:
+        a.moveSelection(-len(a.visible))
+    case keyEnd:
+        a.moveSelection(len(a.visible))
+    case keyEscape:
+        if a.filter != "" {
+            a.filter = ""
+            a.applyFilter()
+        }
+    case keyRune:
+        switch k.r {
+        case 'q':
+            a.quit = true
+        case 'k':
+            a.moveSelection(-1)
+        case 'j':
+            a.moveSelection(1)
+        case '/':
+            a.mode = modeFilter
+        case '+', '=':
+            a.adjust("+")
+        case '-', '_':
+            a.adjust("-")
+        case 'd':
+            if item, ok := a.current(); ok {
+                a.mode = modeConfirmDispose
+                a.status = fmt.Sprintf("Dispose of one %s? (y/n)", item.ItemName)
+            }
+        case 'a':
+            a.form = newItemForm(a.types, a.substitutions)
+            a.mode = modeForm
+            a.status = ""
+        case 'r':
+            a.reload()
+            a.status = "Refreshed."
+        }
+    }
+}
+
+// handleFilterKey edits the filter; the list updates as the user types.
+func (a *app) handleFilterKey(k key) {
+    switch k.code {
+    case keyEnter, keyDown:
+        a.mode = modeList
+    case keyEscape:
+        a.filter = ""
+        a.mode = modeList
+    case keyBackspace:
+        a.filter = dropLastRune(a.filter)
+    case keyRune:
+        a.filter += string(k.r)
+    default:
+        return
+    }
+    a.applyFilter()
+}
+
+// handleConfirmKey answers the dispose prompt.
+func (a *app) handleConfirmKey(k key) {
+    a.mode = modeList
+    item, ok := a.current()
+    if !ok || k.code != keyRune || (k.r != 'y' && k.r != 'Y') {
+        a.status = "Dispose cancelled."
+        return
+    }
+    if err := a.backend.Dispose(item.ItemName); err != nil {
+        a.status = "Failed to dispose item: " + err.Error()
+        return
+    }
+    a.reload()
+    a.status = fmt.Sprintf("Disposed of one %s.", item.ItemName)
+}
+
+// handleFormKey drives the add-item form and submits it.
+func (a *app) handleFormKey(k key) {
+    if k.code == keyEscape {
+        a.form = nil
+        a.mode = modeList
+        a.status = "Add item cancelled."
+        return
+    }
+    if k.code != keyEnter {
+        a.form.handleKey(k)
+        return
+    }
+
+    item, err := a.form.item()
+    if err == nil {
+        err = a.backend.AddItem(item)
+    }
+    if err != nil {
+        a.form.err = err.Error()
+        return
+    }
+    a.form = nil
+    a.mode = modeList
+    a.reload()
+    a.status = fmt.Sprintf("Added %s.", item.ItemName)
+    for i, it := range a.visible {
+        if it.ItemName == item.ItemName {
+            a.selected = i
+            a.moveSelection(0)
+        }
+    }
+}
+
+// adjust adds or uses up one unit of the selected item.
+func (a *app) adjust(action string) {
+    item, ok := a.current()
+    if !ok {
+        return
+    }
+    if err := a.backend.Adjust(item.ItemName, action); err != nil {
+        a.status = "Failed to update item: " + err.Error()
+        return
+    }
+    a.reload()
+    if action == "+" {
+        a.status = fmt.Sprintf("Added one %s.", item.ItemName)
+    } else {
+        a.status = fmt.Sprintf("Used one %s.", item.ItemName)
+    }
+}
+
+// dropLastRune removes the final character of s.
+func dropLastRune(s string) string {
+    r := []rune(s)
+    if len(r) == 0 {
+        return s
+    }
+    return string(r[:len(r)-1])
+}
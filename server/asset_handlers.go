@@ -0,0 +1,147 @@
+package server
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/report"
+)
+
+// makeHandleSetItemAsset returns an HTTP handler that records an item's durable-goods
+// details from the fields itemID, manufacturer, model, serialNumber, purchaseDate and
+// warrantyExpiry (YYYY-MM-DD), and purchasePrice and currentValue (e.g. 1234.56).
+// Blank fields are stored as unknown.
+func makeHandleSetItemAsset(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        asset, err := parseAssetForm(r)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        err = inventory.SetItemAsset(r.Context(), db, asset)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(asset)
+    }
+}
+
+// parseAssetForm reads the asset fields posted to /item/asset.
+func parseAssetForm(r *http.Request) (inventory.ItemAsset, error) {
+    itemID, err := strconv.Atoi(r.FormValue("itemID"))
+    if err != nil {
+        return inventory.ItemAsset{}, errors.New("Invalid item ID")
+    }
+    asset := inventory.ItemAsset{
+        ItemID:       itemID,
+        Manufacturer: r.FormValue("manufacturer"),
+        Model:        r.FormValue("model"),
+        SerialNumber: r.FormValue("serialNumber"),
+    }
+    for field, target := range map[string]**time.Time{"purchaseDate": &asset.PurchaseDate, "warrantyExpiry": &asset.WarrantyExpiry} {
+        s := strings.TrimSpace(r.FormValue(field))
+        if s == "" {
+            continue
+        }
+        t, err := time.Parse(time.DateOnly, s)
+        if err != nil {
+            return inventory.ItemAsset{}, fmt.Errorf("Invalid %s: use YYYY-MM-DD", field)
+        }
+        *target = &t
+    }
+    for field, target := range map[string]**int64{"purchasePrice": &asset.PurchasePrice, "currentValue": &asset.CurrentValue} {
+        s := strings.TrimSpace(r.FormValue(field))
+        if s == "" {
+            continue
+        }
+        cents, err := inventory.ParseMoney(s)
+        if err != nil {
+            return inventory.ItemAsset{}, fmt.Errorf("Invalid %s: %v", field, err)
+        }
+        *target = &cents
+    }
+    return asset, nil
+}
+
+// makeHandleRemoveItemAsset returns an HTTP handler that turns an asset back into a
+// consumable, discarding its durable-goods details.
+func makeHandleRemoveItemAsset(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        if err := inventory.RemoveItemAsset(r.Context(), db, itemID); err != nil {
+            slog.ErrorContext(r.Context(), "failed to remove item asset", "itemID", itemID, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"itemID": itemID})
+    }
+}
+
+// makeHandleInsuranceReport returns an HTTP handler that serves the insurance inventory,
+// grouped by room, as format=pdf (default), csv or json.
+func makeHandleInsuranceReport(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        format := r.URL.Query().Get("format")
+        if format == "" {
+            format = "pdf"
+        }
+        if format != "pdf" && format != "csv" && format != "json" {
+            http.Error(w, "Invalid format: must be pdf, csv or json", http.StatusBadRequest)
+            return
+        }
+
+        rep, err := inventory.GetInsuranceReport(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to build insurance report", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        filename := "insurance-inventory-" + rep.Generated.Format("20060102")
+        switch format {
+        case "pdf":
+            w.Header().Set("Content-Type", "application/pdf")
+            w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename+".pdf"))
+            err = report.InsurancePDF(w, rep)
+        case "csv":
+            w.Header().Set("Content-Type", "text/csv")
+            w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename+".csv"))
+            err = inventory.WriteInsuranceCSV(w, rep)
+        default:
+            w.Header().Set("Content-Type", "application/json")
+            err = json.NewEncoder(w).Encode(rep)
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to write insurance report", "format", format, "error", err)
+        }
+    }
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+    "fmt"
+    "html/template"
+    "io/fs"
+    "net/http"
+)
+
+// pages holds the HTML templates. Normally they are parsed once at startup from the
+// embedded files; in dev mode they are re-read on every request so edits show up
+// without a rebuild.
+type pages struct {
+    files fs.FS
+    dev   bool
+    tmpl  *template.Template
+}
+
+// newPages parses every template under templates/ in files.
+func newPages(files fs.FS, dev bool) (*pages, error) {
+    p := &pages{files: files, dev: dev}
+    tmpl, err := p.parse()
+    if err != nil {
+        return nil, err
+    }
+    p.tmpl = tmpl
+    return p, nil
+}
+
+func (p *pages) parse() (*template.Template, error) {
+    return template.ParseFS(p.files, "templates/*.html")
+}
+
+// lookup returns the named template, e.g. "index.html".
+func (p *pages) lookup(name string) (*template.Template, error) {
+    tmpl := p.tmpl
+    if p.dev {
+        var err error
+        if tmpl, err = p.parse(); err != nil {
+            return nil, err
+        }
+    }
+    if t := tmpl.Lookup(name); t != nil {
+        return t, nil
+    }
+    return nil, fmt.Errorf("template %s not found", name)
+}
+
+// staticHandler serves the files under static/ in files.
+func staticHandler(files fs.FS) (http.Handler, error) {
+    static, err := fs.Sub(files, "static")
+    if err != nil {
+        return nil, err
+    }
+    return http.FileServer(http.FS(static)), nil
+}
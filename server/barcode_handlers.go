@@ -0,0 +1,85 @@
+package server
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// makeHandleBarcodeLookup returns an HTTP handler that resolves a scanned barcode.
+// Known item barcodes increment the item; catalog matches are returned for pre-filling the add form.
+func makeHandleBarcodeLookup(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        code := r.FormValue("barcode")
+        if _, err := inventory.NormalizeBarcode(code); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        result, err := inventory.LookupBarcode(r.Context(), db, code)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to look up barcode", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(result)
+    }
+}
+
+// makeHandleAddItemBarcode returns an HTTP handler that attaches a barcode to an existing item.
+func makeHandleAddItemBarcode(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemIDStr := r.FormValue("itemID")
+        itemID, err := strconv.ParseInt(itemIDStr, 10, 64)
+        if err != nil {
+            slog.WarnContext(r.Context(), "invalid item ID", "value", itemIDStr)
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+
+        barcode, err := inventory.AddItemBarcode(r.Context(), db, itemID, r.FormValue("barcode"))
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        resp := map[string]interface{}{
+            "itemID":  itemID,
+            "barcode": barcode,
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(resp)
+    }
+}
+
+// makeHandleRemoveItemBarcode returns an HTTP handler that detaches a barcode from its item.
+func makeHandleRemoveItemBarcode(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        if err := inventory.RemoveItemBarcode(r.Context(), db, r.FormValue("barcode")); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        w.WriteHeader(http.StatusNoContent)
+    }
+}
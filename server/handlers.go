@@ -2,25 +2,86 @@ package server
 
 import (
     "encoding/json"
+    "errors"
     "fmt"
-    "html/template"
+    "log/slog"
     "net/http"
     "strconv"
 
     "myhomeinventory/internal/inventory"
 )
 
-// makeHandleItems returns an HTTP handler that retrieves the list of inventory items.
+// maxItemPageSize caps the limit parameter of /items.
+const maxItemPageSize = 500
+
+// makeHandleItems returns an HTTP handler that lists inventory items. Query parameters
+// filter the list (q, type, substitution, location, tag, kind, underMinimum,
+// expiringWithin), order it (sort, order=asc|desc) and page it (limit, cursor). A paged
+// request gets {items, total, nextCursor}; without limit or cursor every matching item is
+// returned as a plain array, as before paging existed.
 func makeHandleItems(db *inventory.Database) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        items, err := inventory.GetItemList(db, 0, "", false)
+        params := r.URL.Query()
+        query := inventory.ItemListQuery{
+            Name:         params.Get("q"),
+            ItemType:     params.Get("type"),
+            Substitution: params.Get("substitution"),
+            Location:     params.Get("location"),
+            Tags:         params["tag"],
+            Kind:         params.Get("kind"),
+            Sort:         params.Get("sort"),
+            Cursor:       params.Get("cursor"),
+        }
+
+        if s := params.Get("underMinimum"); s != "" {
+            v, err := strconv.ParseBool(s)
+            if err != nil {
+                http.Error(w, "Invalid underMinimum", http.StatusBadRequest)
+                return
+            }
+            query.UnderMinimum = v
+        }
+        if s := params.Get("expiringWithin"); s != "" {
+            days, err := strconv.Atoi(s)
+            if err != nil || days < 0 {
+                http.Error(w, "Invalid expiringWithin", http.StatusBadRequest)
+                return
+            }
+            query.ExpiringWithin = &days
+        }
+        switch params.Get("order") {
+        case "", "asc":
+        case "desc":
+            query.Descending = true
+        default:
+            http.Error(w, "Invalid order: use asc or desc", http.StatusBadRequest)
+            return
+        }
+        if s := params.Get("limit"); s != "" {
+            limit, err := strconv.Atoi(s)
+            if err != nil || limit < 1 || limit > maxItemPageSize {
+                http.Error(w, fmt.Sprintf("Invalid limit: use 1 to %d", maxItemPageSize), http.StatusBadRequest)
+                return
+            }
+            query.Limit = limit
+        }
+
+        page, err := inventory.ListItems(r.Context(), db, query)
+        if errors.Is(err, inventory.ErrInvalidItemQuery) {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
         if err != nil {
-            fmt.Println("Failed to get items:", err)
+            slog.ErrorContext(r.Context(), "failed to get items", "error", err)
             http.Error(w, err.Error(), http.StatusInternalServerError)
             return
         }
         w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(items)
+        if !params.Has("limit") && !params.Has("cursor") {
+            json.NewEncoder(w).Encode(page.Items)
+            return
+        }
+        json.NewEncoder(w).Encode(page)
     }
 }
 
@@ -34,7 +95,7 @@ func makeHandleUpdateItem(db *inventory.Database) http.HandlerFunc {
         itemName := r.FormValue("itemName")
         action := r.FormValue("action")
 
-        result, err := inventory.UpdateItemQty(db, itemName, action)
+        result, err := inventory.UpdateItemQty(r.Context(), db, itemName, action)
         if err != nil {
             http.Error(w, err.Error(), http.StatusBadRequest)
             return
@@ -45,25 +106,25 @@ func makeHandleUpdateItem(db *inventory.Database) http.HandlerFunc {
 }
 
 // makeHandleAddItemForm returns an HTTP handler that serves the Add Item form with dynamic dropdowns.
-func makeHandleAddItemForm(db *inventory.Database) http.HandlerFunc {
+func makeHandleAddItemForm(db *inventory.Database, pages *pages) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        itemTypes, err := inventory.GetItemTypes(db)
+        itemTypes, err := inventory.GetItemTypes(r.Context(), db)
         if err != nil {
-            fmt.Println("Failed to fetch item types:", err)
+            slog.ErrorContext(r.Context(), "failed to fetch item types", "error", err)
             http.Error(w, "Failed to load form", http.StatusInternalServerError)
             return
         }
 
-        itemSubstitutions, err := inventory.GetItemSubstitutions(db)
+        itemSubstitutions, err := inventory.GetItemSubstitutions(r.Context(), db)
         if err != nil {
-            fmt.Println("Failed to fetch item substitutions:", err)
+            slog.ErrorContext(r.Context(), "failed to fetch item substitutions", "error", err)
             http.Error(w, "Failed to load form", http.StatusInternalServerError)
             return
         }
 
-        tmpl, err := template.ParseFiles("templates/index.html")
+        tmpl, err := pages.lookup("index.html")
         if err != nil {
-            fmt.Println("Failed to parse template:", err)
+            slog.ErrorContext(r.Context(), "failed to parse template", "error", err)
             http.Error(w, "Failed to load form", http.StatusInternalServerError)
             return
         }
@@ -77,7 +138,7 @@ func makeHandleAddItemForm(db *inventory.Database) http.HandlerFunc {
         }
 
         if err := tmpl.Execute(w, data); err != nil {
-            fmt.Println("Failed to render template:", err)
+            slog.ErrorContext(r.Context(), "failed to render template", "error", err)
             http.Error(w, "Failed to load form", http.StatusInternalServerError)
         }
     }
@@ -100,35 +161,35 @@ func makeHandleAddItem(db *inventory.Database) http.HandlerFunc {
 
         qty, err := strconv.Atoi(qtyStr)
         if err != nil {
-            fmt.Println("Invalid quantity:", qtyStr)
+            slog.WarnContext(r.Context(), "invalid quantity", "value", qtyStr)
             http.Error(w, "Invalid quantity", http.StatusBadRequest)
             return
         }
 
         minQty, err := strconv.Atoi(minQtyStr)
         if err != nil {
-            fmt.Println("Invalid minimum quantity:", minQtyStr)
+            slog.WarnContext(r.Context(), "invalid minimum quantity", "value", minQtyStr)
             http.Error(w, "Invalid minimum quantity", http.StatusBadRequest)
             return
         }
 
         itemTypeID, err := strconv.Atoi(itemTypeIDStr)
         if err != nil {
-            fmt.Println("Invalid item type ID:", itemTypeIDStr)
+            slog.WarnContext(r.Context(), "invalid item type ID", "value", itemTypeIDStr)
             http.Error(w, "Invalid item type selection", http.StatusBadRequest)
             return
         }
 
         itemSubstitutionID, err := strconv.Atoi(itemSubstitutionIDStr)
         if err != nil {
-            fmt.Println("Invalid item substitution ID:", itemSubstitutionIDStr)
+            slog.WarnContext(r.Context(), "invalid item substitution ID", "value", itemSubstitutionIDStr)
             http.Error(w, "Invalid item substitution selection", http.StatusBadRequest)
             return
         }
 
         itemExpirationPeriod, err := strconv.Atoi(itemExpirationPeriodStr)
         if err != nil {
-            fmt.Println("Invalid item expiration period:", itemExpirationPeriodStr)
+            slog.WarnContext(r.Context(), "invalid item expiration period", "value", itemExpirationPeriodStr)
             http.Error(w, "Invalid item expiration period", http.StatusBadRequest)
             return
         }
@@ -143,9 +204,15 @@ func makeHandleAddItem(db *inventory.Database) http.HandlerFunc {
             ItemExpirationPeriod: itemExpirationPeriod,
         }
 
-        id, err := inventory.InsertItem(db, newItem)
+        for _, barcode := range r.Form["barcode"] {
+            if barcode != "" {
+                newItem.Barcodes = append(newItem.Barcodes, barcode)
+            }
+        }
+
+        id, err := inventory.InsertItem(r.Context(), db, newItem)
         if err != nil {
-            fmt.Println("Failed to insert item:", err)
+            slog.ErrorContext(r.Context(), "failed to insert item", "error", err)
             http.Error(w, err.Error(), http.StatusBadRequest)
             return
         }
@@ -172,7 +239,7 @@ func makeHandleDisposeItem(db *inventory.Database) http.HandlerFunc {
             return
         }
 
-        result, err := inventory.DisposeItem(db, itemName)
+        result, err := inventory.DisposeItem(r.Context(), db, itemName)
         if err != nil {
             http.Error(w, err.Error(), http.StatusBadRequest)
             return
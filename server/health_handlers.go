@@ -0,0 +1,129 @@
+package server
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "runtime/debug"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// readinessTimeout bounds all readiness checks together.
+const readinessTimeout = 3 * time.Second
+
+// startTime is when the process started serving, reported by /healthz.
+var startTime = time.Now()
+
+// check is the result of one readiness check.
+type check struct {
+    Name       string `json:"name"`
+    OK         bool   `json:"ok"`
+    Error      string `json:"error,omitempty"`
+    DurationMS int64  `json:"durationMs"`
+}
+
+// makeHandleHealthz returns an HTTP handler reporting that the process is up. It does not
+// touch the database, so a slow or restarting MySQL never gets the process restarted.
+func makeHandleHealthz() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        writeJSON(w, http.StatusOK, map[string]interface{}{
+            "status":  "ok",
+            "started": startTime.UTC().Format(time.RFC3339),
+            "uptime":  time.Since(startTime).Round(time.Second).String(),
+        })
+    }
+}
+
+// makeHandleReadyz returns an HTTP handler reporting whether the app can serve requests:
+// the database answers and its schema is at the version this build expects.
+// It responds 503 when any check fails.
+func makeHandleReadyz(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+        defer cancel()
+
+        checks := []check{
+            runCheck("database", func() error { return db.Health(ctx) }),
+        }
+
+        schemaVersion := 0
+        checks = append(checks, runCheck("schema", func() error {
+            if !checks[0].OK {
+                return fmt.Errorf("skipped: database unreachable")
+            }
+            version, err := db.GetSchemaVersion(ctx)
+            if err != nil {
+                return err
+            }
+            schemaVersion = version
+            if version != inventory.SchemaVersion {
+                return fmt.Errorf("schema is at version %d, expected %d", version, inventory.SchemaVersion)
+            }
+            return nil
+        }))
+
+        status, code := "ready", http.StatusOK
+        for _, c := range checks {
+            if !c.OK {
+                status, code = "not ready", http.StatusServiceUnavailable
+            }
+        }
+        writeJSON(w, code, map[string]interface{}{
+            "status": status,
+            "checks": checks,
+            "schemaVersion": map[string]int{
+                "expected": inventory.SchemaVersion,
+                "actual":   schemaVersion,
+            },
+        })
+    }
+}
+
+// runCheck times fn and records its outcome.
+func runCheck(name string, fn func() error) check {
+    start := time.Now()
+    err := fn()
+    c := check{Name: name, OK: err == nil, DurationMS: time.Since(start).Milliseconds()}
+    if err != nil {
+        c.Error = err.Error()
+    }
+    return c
+}
+
+// makeHandleVersion returns an HTTP handler describing the running build.
+func makeHandleVersion() http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        info := map[string]interface{}{
+            "schemaVersion": inventory.SchemaVersion,
+        }
+        if build, ok := debug.ReadBuildInfo(); ok {
+            info["module"] = build.Main.Path
+            info["version"] = build.Main.Version
+            info["goVersion"] = build.GoVersion
+            for _, s := range build.Settings {
+                switch s.Key {
+                case "vcs.revision":
+                    info["revision"] = s.Value
+                case "vcs.time":
+                    info["commitTime"] = s.Value
+                case "vcs.modified":
+                    info["modified"] = s.Value == "true"
+                case "GOOS", "GOARCH":
+                    info[s.Key] = s.Value
+                }
+            }
+        }
+        writeJSON(w, http.StatusOK, info)
+    }
+}
+
+// writeJSON writes v as a JSON response with the given status code.
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.Header().Set("Cache-Control", "no-store")
+    w.WriteHeader(code)
+    json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,320 @@
+package server
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "html/template"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/markdown"
+    "myhomeinventory/internal/photo"
+)
+
+// itemPagePrefix is the path item pages are served under; printed labels link there.
+const itemPagePrefix = "/items/"
+
+// itemPagePath returns the path of an item's page.
+func itemPagePath(id int) string {
+    return itemPagePrefix + strconv.Itoa(id)
+}
+
+// itemHistoryLimit is how many stock changes the item page shows.
+const itemHistoryLimit = 50
+
+// itemPageUnit is one stock unit as shown on the item page.
+type itemPageUnit struct {
+    inventory.ItemUnit
+    DaysLeft int
+}
+
+// itemPageTask is one maintenance task as shown on the item page. DaysLeft is negative
+// when the task is overdue and only meaningful when NextDue is set.
+type itemPageTask struct {
+    inventory.MaintenanceTask
+    DaysLeft int
+}
+
+// itemPageAsset is an item's durable-goods details formatted for the item page's form.
+// IsAsset is false for consumables, whose fields are all blank.
+type itemPageAsset struct {
+    IsAsset        bool
+    Manufacturer   string
+    Model          string
+    SerialNumber   string
+    PurchaseDate   string
+    PurchasePrice  string
+    WarrantyExpiry string
+    CurrentValue   string
+    // WarrantyDaysLeft is negative once the warranty has expired; it is only meaningful
+    // when WarrantyExpiry is set.
+    WarrantyDaysLeft int
+}
+
+// newItemPageAsset formats asset for the item page.
+func newItemPageAsset(asset inventory.ItemAsset, today time.Time) itemPageAsset {
+    view := itemPageAsset{
+        IsAsset:      true,
+        Manufacturer: asset.Manufacturer,
+        Model:        asset.Model,
+        SerialNumber: asset.SerialNumber,
+    }
+    if asset.PurchaseDate != nil {
+        view.PurchaseDate = asset.PurchaseDate.Format(time.DateOnly)
+    }
+    if asset.PurchasePrice != nil {
+        view.PurchasePrice = inventory.FormatMoney(*asset.PurchasePrice)
+    }
+    if asset.CurrentValue != nil {
+        view.CurrentValue = inventory.FormatMoney(*asset.CurrentValue)
+    }
+    if asset.WarrantyExpiry != nil {
+        view.WarrantyExpiry = asset.WarrantyExpiry.Format(time.DateOnly)
+        view.WarrantyDaysLeft = inventory.DaysBetween(today, *asset.WarrantyExpiry)
+    }
+    return view
+}
+
+// itemPageView is what the item page template renders.
+type itemPageView struct {
+    Item              inventory.InventoryItemWithDetails
+    Units             []itemPageUnit
+    Aliases           []string
+    Note              string
+    NoteHTML          template.HTML
+    Tags              []inventory.ItemTag
+    Photos            []inventory.ItemPhoto
+    Asset             itemPageAsset
+    Tasks             []itemPageTask
+    MaintenanceLog    []inventory.MaintenanceLogEntry
+    Consumables       []inventory.ItemRef
+    Loans             []inventory.ItemLoan
+    Borrowers         []string
+    History           []inventory.ItemHistoryEntry
+    HistoryLimit      int
+    ItemTypes         []inventory.ItemType
+    ItemSubstitutions []inventory.ItemSubstitution
+    ItemLocations     []inventory.ItemLocation
+}
+
+// loadItemPage gathers everything the item page shows. It returns sql.ErrNoRows when the
+// item does not exist; other errors name the section that failed.
+func loadItemPage(ctx context.Context, db *inventory.Database, id int) (itemPageView, error) {
+    view := itemPageView{HistoryLimit: itemHistoryLimit}
+    var err error
+    if view.Item, err = inventory.GetItemByID(ctx, db, id); err != nil {
+        return view, err
+    }
+
+    units, err := inventory.GetItemUnits(ctx, db, id)
+    if err != nil {
+        return view, fmt.Errorf("units: %w", err)
+    }
+    if view.Aliases, err = inventory.GetItemAliases(ctx, db, id); err != nil {
+        return view, fmt.Errorf("aliases: %w", err)
+    }
+    if view.Note, err = inventory.GetItemNote(ctx, db, id); err != nil {
+        return view, fmt.Errorf("note: %w", err)
+    }
+    view.NoteHTML = markdown.Render(view.Note)
+    if view.Tags, err = inventory.GetItemTags(ctx, db); err != nil {
+        return view, fmt.Errorf("tags: %w", err)
+    }
+    if view.Photos, err = inventory.GetItemPhotos(ctx, db, id); err != nil {
+        return view, fmt.Errorf("photos: %w", err)
+    }
+    asset, assetErr := inventory.GetItemAsset(ctx, db, id)
+    if assetErr != nil && !errors.Is(assetErr, sql.ErrNoRows) {
+        return view, fmt.Errorf("asset: %w", assetErr)
+    }
+    tasks, err := inventory.GetMaintenanceTasks(ctx, db, id)
+    if err != nil {
+        return view, fmt.Errorf("maintenance tasks: %w", err)
+    }
+    if view.MaintenanceLog, err = inventory.GetMaintenanceLog(ctx, db, id, maintenanceLogLimit); err != nil {
+        return view, fmt.Errorf("maintenance log: %w", err)
+    }
+    if view.Consumables, err = inventory.GetConsumableRefs(ctx, db); err != nil {
+        return view, fmt.Errorf("consumables: %w", err)
+    }
+    if view.Loans, err = inventory.GetLoans(ctx, db, inventory.LoanQuery{ItemID: id, IncludeReturned: true, Limit: itemLoanLimit}); err != nil {
+        return view, fmt.Errorf("loans: %w", err)
+    }
+    if view.Borrowers, err = inventory.GetBorrowers(ctx, db); err != nil {
+        return view, fmt.Errorf("borrowers: %w", err)
+    }
+    if view.History, err = inventory.GetItemHistory(ctx, db, id, itemHistoryLimit); err != nil {
+        return view, fmt.Errorf("history: %w", err)
+    }
+    if view.ItemTypes, err = inventory.GetItemTypes(ctx, db); err != nil {
+        return view, fmt.Errorf("item types: %w", err)
+    }
+    if view.ItemSubstitutions, err = inventory.GetItemSubstitutions(ctx, db); err != nil {
+        return view, fmt.Errorf("item substitutions: %w", err)
+    }
+    if view.ItemLocations, err = inventory.GetItemLocations(ctx, db); err != nil {
+        return view, fmt.Errorf("item locations: %w", err)
+    }
+
+    today := inventory.Today()
+    view.Units = make([]itemPageUnit, len(units))
+    for i, u := range units {
+        view.Units[i] = itemPageUnit{ItemUnit: u, DaysLeft: inventory.DaysBetween(today, u.ExpirationDate)}
+    }
+    view.Tasks = make([]itemPageTask, len(tasks))
+    for i, t := range tasks {
+        view.Tasks[i] = itemPageTask{MaintenanceTask: t}
+        if t.NextDue != nil {
+            view.Tasks[i].DaysLeft = inventory.DaysBetween(today, *t.NextDue)
+        }
+    }
+    if assetErr == nil {
+        view.Asset = newItemPageAsset(asset, today)
+    }
+    return view, nil
+}
+
+// makeHandleItemPage returns an HTTP handler that renders the detail page of one item:
+// every field, each unit's expiration date, its maintenance schedule, who has borrowed it,
+// the stock history, and controls to change the stock, edit the item or delete it.
+// Printed labels link here.
+func makeHandleItemPage(db *inventory.Database, pages *pages) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        id, err := strconv.Atoi(r.PathValue("id"))
+        if err != nil || id <= 0 {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+
+        view, err := loadItemPage(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.NotFound(w, r)
+            return
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to load item page", "id", id, "error", err)
+            http.Error(w, "Failed to load item", http.StatusInternalServerError)
+            return
+        }
+
+        tmpl, err := pages.lookup("item.html")
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to parse template", "error", err)
+            http.Error(w, "Failed to load item", http.StatusInternalServerError)
+            return
+        }
+        if err := tmpl.Execute(w, view); err != nil {
+            slog.ErrorContext(r.Context(), "failed to render template", "error", err)
+            http.Error(w, "Failed to load item", http.StatusInternalServerError)
+        }
+    }
+}
+
+// makeHandleEditItem returns an HTTP handler that changes an item's name, type,
+// substitution, location, minimum quantity and expiration period. A blank type,
+// substitution or location clears it. Setting recomputeExpirations=1
+// moves the expiration dates of units that have not expired yet to match the new period.
+func makeHandleEditItem(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        ints := map[string]int{}
+        for _, field := range []string{"id", "minimumQTY", "itemTypeID", "itemSubstitutionID", "itemExpirationPeriod"} {
+            if r.FormValue(field) == "" && (field == "itemTypeID" || field == "itemSubstitutionID") {
+                continue // none
+            }
+            v, err := strconv.Atoi(r.FormValue(field))
+            if err != nil {
+                slog.WarnContext(r.Context(), "invalid "+field, "value", r.FormValue(field))
+                http.Error(w, "Invalid "+field, http.StatusBadRequest)
+                return
+            }
+            ints[field] = v
+        }
+        locationID := 0
+        if v := r.FormValue("locationID"); v != "" {
+            var err error
+            if locationID, err = strconv.Atoi(v); err != nil {
+                http.Error(w, "Invalid locationID", http.StatusBadRequest)
+                return
+            }
+        }
+
+        item, err := inventory.UpdateItem(r.Context(), db, ints["id"], inventory.ItemUpdate{
+            ItemName:             r.FormValue("itemName"),
+            MinimumQTY:           ints["minimumQTY"],
+            ItemTypeID:           ints["itemTypeID"],
+            ItemSubstitutionID:   ints["itemSubstitutionID"],
+            ItemExpirationPeriod: ints["itemExpirationPeriod"],
+            LocationID:           locationID,
+            RecomputeExpirations: r.FormValue("recomputeExpirations") == "1",
+        })
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(item)
+    }
+}
+
+// makeHandleDeleteItem returns an HTTP handler that deletes an item with its units and
+// barcodes, keeping its history. Items with units lent out are refused with 409.
+func makeHandleDeleteItem(db *inventory.Database, store *photo.Store) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid id", http.StatusBadRequest)
+            return
+        }
+
+        photos, err := inventory.GetItemPhotos(r.Context(), db, id)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get item photos", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        name, err := inventory.DeleteItem(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if errors.Is(err, inventory.ErrItemLent) {
+            http.Error(w, err.Error(), http.StatusConflict)
+            return
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to delete item", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        files := make([]string, 0, len(photos))
+        for _, p := range photos {
+            files = append(files, p.FileName)
+        }
+        removeUnusedPhotos(r.Context(), db, store, files)
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "itemName": name})
+    }
+}
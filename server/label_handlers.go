@@ -0,0 +1,161 @@
+package server
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/label"
+)
+
+// labelDateFormat is the date format printed on labels.
+const labelDateFormat = "2006-01-02"
+
+// makeHandleLabels returns an HTTP handler that renders a PDF sheet of item labels.
+// Query parameters:
+//   itemID   one or more item IDs (all items when omitted)
+//   per      "item" for one label per item or "unit" for one label per stock unit
+//   layout   Avery product number, e.g. 5160, 5163, 22805
+//   skip     number of already used positions on the first sheet
+//   outline  "1" to draw label borders for test prints
+// QR codes link to publicURL, or to the host the request came in on when it is empty.
+func makeHandleLabels(db *inventory.Database, publicURL string) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        query := r.URL.Query()
+
+        layout, err := label.LookupLayout(query.Get("layout"))
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        per := query.Get("per")
+        if per == "" {
+            per = "item"
+        }
+        if per != "item" && per != "unit" {
+            http.Error(w, "Invalid per: must be item or unit", http.StatusBadRequest)
+            return
+        }
+
+        skip := 0
+        if skipStr := query.Get("skip"); skipStr != "" {
+            skip, err = strconv.Atoi(skipStr)
+            if err != nil || skip < 0 || skip >= layout.PerSheet() {
+                http.Error(w, fmt.Sprintf("Invalid skip: must be between 0 and %d", layout.PerSheet()-1), http.StatusBadRequest)
+                return
+            }
+        }
+
+        items, err := labelItems(r.Context(), db, query["itemID"])
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        var itemIDs []int
+        if len(query["itemID"]) > 0 {
+            itemIDs = make([]int, 0, len(items))
+            for _, item := range items {
+                itemIDs = append(itemIDs, item.ID)
+            }
+        }
+        units, err := inventory.GetUnitsByItem(r.Context(), db, itemIDs)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get item units", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        baseURL := strings.TrimRight(publicURL, "/")
+        if baseURL == "" {
+            baseURL = requestBaseURL(r)
+        }
+        labels := []label.Label{}
+        for _, item := range items {
+            labels = append(labels, itemLabels(item, units[item.ID], per, baseURL)...)
+        }
+
+        if len(labels) == 0 {
+            http.Error(w, "Nothing to print", http.StatusNotFound)
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/pdf")
+        w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"labels-%s.pdf\"", layout.Name))
+        if err := label.Render(w, layout, labels, label.Options{Skip: skip, Outline: query.Get("outline") == "1"}); err != nil {
+            slog.ErrorContext(r.Context(), "failed to render labels", "error", err)
+        }
+    }
+}
+
+// labelItems loads the requested items, or every item when no IDs are given.
+func labelItems(ctx context.Context, db *inventory.Database, ids []string) ([]inventory.InventoryItemWithDetails, error) {
+    if len(ids) == 0 {
+        return inventory.GetItemList(ctx, db, 0, "", false)
+    }
+
+    items := make([]inventory.InventoryItemWithDetails, 0, len(ids))
+    for _, idStr := range ids {
+        id, err := strconv.Atoi(idStr)
+        if err != nil {
+            return nil, fmt.Errorf("invalid item ID %q", idStr)
+        }
+        item, err := inventory.GetItemByID(ctx, db, id)
+        if err != nil {
+            return nil, fmt.Errorf("item %d not found", id)
+        }
+        items = append(items, item)
+    }
+    return items, nil
+}
+
+// itemLabels builds the labels for one item: a single label, or one per stock unit. Each
+// label's QR code opens the item's page.
+func itemLabels(item inventory.InventoryItemWithDetails, units []inventory.ItemUnit, per string, baseURL string) []label.Label {
+    url := baseURL + itemPagePath(item.ID)
+
+    if per == "unit" {
+        labels := make([]label.Label, 0, len(units))
+        for _, u := range units {
+            labels = append(labels, label.Label{
+                Title: item.ItemName,
+                Lines: []string{
+                    "Purchased " + u.CreationDate.Format(labelDateFormat),
+                    "Expires " + u.ExpirationDate.Format(labelDateFormat),
+                },
+                URL: url,
+            })
+        }
+        return labels
+    }
+
+    lines := []string{"Added " + item.CreateDate.Format(labelDateFormat)}
+    if len(units) > 0 {
+        lines = append(lines, "Next expiry "+units[0].ExpirationDate.Format(labelDateFormat))
+    }
+    if item.ItemTypeName != "" {
+        lines = append(lines, item.ItemTypeName)
+    }
+    return []label.Label{{Title: item.ItemName, Lines: lines, URL: url}}
+}
+
+// requestBaseURL returns the scheme and host the client used to reach the server. Only
+// http and https are taken from X-Forwarded-Proto; anything else is ignored.
+func requestBaseURL(r *http.Request) string {
+    scheme := "http"
+    if r.TLS != nil {
+        scheme = "https"
+    }
+    if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+        switch p := strings.ToLower(strings.TrimSpace(strings.Split(proto, ",")[0])); p {
+        case "http", "https":
+            scheme = p
+        }
+    }
+    return scheme + "://" + r.Host
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// itemLoanLimit is how many loans the item page's lending history shows.
+const itemLoanLimit = 50
+
+// makeHandleLoans returns an HTTP handler that lists loans still out, soonest due first.
+// ?overdue=true keeps only overdue ones; ?itemID= limits them to one item, and adding
+// ?history=true includes returned loans, newest first.
+func makeHandleLoans(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        itemID, ok := optionalItemID(w, r)
+        if !ok {
+            return
+        }
+        params := r.URL.Query()
+        loans, err := inventory.GetLoans(r.Context(), db, inventory.LoanQuery{
+            ItemID:          itemID,
+            Overdue:         params.Get("overdue") == "true",
+            IncludeReturned: params.Get("history") == "true",
+        })
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get loans", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(loans)
+    }
+}
+
+// makeHandleLendItem returns an HTTP handler that checks units of an item out from the
+// fields itemID, borrower, quantity (default 1), due (YYYY-MM-DD, optional) and note.
+func makeHandleLendItem(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        loan := inventory.ItemLoan{ItemID: itemID, Borrower: r.FormValue("borrower"), Quantity: 1, Note: r.FormValue("note")}
+        if s := r.FormValue("quantity"); s != "" {
+            if loan.Quantity, err = strconv.Atoi(s); err != nil {
+                http.Error(w, "Invalid quantity", http.StatusBadRequest)
+                return
+            }
+        }
+        if s := strings.TrimSpace(r.FormValue("due")); s != "" {
+            due, err := time.Parse(time.DateOnly, s)
+            if err != nil {
+                http.Error(w, "Invalid due date: use YYYY-MM-DD", http.StatusBadRequest)
+                return
+            }
+            loan.DueDate = &due
+        }
+
+        id, err := inventory.LendItem(r.Context(), db, loan)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        saved, err := inventory.GetLoan(r.Context(), db, int(id))
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get loan", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(saved)
+    }
+}
+
+// makeHandleReturnLoan returns an HTTP handler that checks the loan with the posted id
+// back in.
+func makeHandleReturnLoan(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid id", http.StatusBadRequest)
+            return
+        }
+        loan, err := inventory.ReturnLoan(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Loan not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(loan)
+    }
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// defaultExpiringDays is how far ahead /expiring looks when no days parameter is given.
+const defaultExpiringDays = 7
+
+// makeHandleTypes returns an HTTP handler that lists item types as JSON.
+func makeHandleTypes(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        types, err := inventory.GetItemTypes(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get item types", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if types == nil {
+            types = []inventory.ItemType{}
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(types)
+    }
+}
+
+// makeHandleSubstitutions returns an HTTP handler that lists item substitutions as JSON.
+func makeHandleSubstitutions(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        substitutions, err := inventory.GetItemSubstitutions(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get item substitutions", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if substitutions == nil {
+            substitutions = []inventory.ItemSubstitution{}
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(substitutions)
+    }
+}
+
+// makeHandleLocations returns an HTTP handler that lists item locations as JSON.
+func makeHandleLocations(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        locations, err := inventory.GetItemLocations(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get item locations", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if locations == nil {
+            locations = []inventory.ItemLocation{}
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(locations)
+    }
+}
+
+// makeHandleExpiring returns an HTTP handler that lists units expiring within ?days= days.
+func makeHandleExpiring(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        days := defaultExpiringDays
+        if s := r.URL.Query().Get("days"); s != "" {
+            n, err := strconv.Atoi(s)
+            if err != nil || n < 0 {
+                http.Error(w, "Invalid days", http.StatusBadRequest)
+                return
+            }
+            days = n
+        }
+
+        expiring, err := inventory.GetExpiringUnits(r.Context(), db, days)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get expiring units", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(expiring)
+    }
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// defaultMaintenanceDays is how far ahead /maintenance/due looks when no days parameter
+// is given.
+const defaultMaintenanceDays = 14
+
+// maintenanceLogLimit is how many completions /maintenance/log and the item page show.
+const maintenanceLogLimit = 50
+
+// makeHandleMaintenanceDue returns an HTTP handler that lists tasks due within ?days=
+// days, overdue ones included, and warranties ending in that time.
+func makeHandleMaintenanceDue(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        days := defaultMaintenanceDays
+        if s := r.URL.Query().Get("days"); s != "" {
+            n, err := strconv.Atoi(s)
+            if err != nil || n < 0 {
+                http.Error(w, "Invalid days", http.StatusBadRequest)
+                return
+            }
+            days = n
+        }
+
+        due, err := inventory.GetDueMaintenance(r.Context(), db, days)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get due maintenance", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(due)
+    }
+}
+
+// makeHandleMaintenanceTasks returns an HTTP handler that lists the maintenance tasks of
+// ?itemID=, or of every item when it is omitted.
+func makeHandleMaintenanceTasks(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        itemID, ok := optionalItemID(w, r)
+        if !ok {
+            return
+        }
+        tasks, err := inventory.GetMaintenanceTasks(r.Context(), db, itemID)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get maintenance tasks", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(tasks)
+    }
+}
+
+// makeHandleMaintenanceLog returns an HTTP handler that lists the most recent task
+// completions of ?itemID=, or of every item when it is omitted.
+func makeHandleMaintenanceLog(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        itemID, ok := optionalItemID(w, r)
+        if !ok {
+            return
+        }
+        entries, err := inventory.GetMaintenanceLog(r.Context(), db, itemID, maintenanceLogLimit)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get maintenance log", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(entries)
+    }
+}
+
+// optionalItemID reads the itemID query parameter, 0 when it is omitted. It writes a 400
+// response and returns false when it is invalid.
+func optionalItemID(w http.ResponseWriter, r *http.Request) (int, bool) {
+    s := r.URL.Query().Get("itemID")
+    if s == "" {
+        return 0, true
+    }
+    itemID, err := strconv.Atoi(s)
+    if err != nil || itemID <= 0 {
+        http.Error(w, "Invalid item ID", http.StatusBadRequest)
+        return 0, false
+    }
+    return itemID, true
+}
+
+// makeHandleSaveMaintenanceTask returns an HTTP handler that schedules a task, or changes
+// one when edit is set, from the fields itemID (id when editing), name, every (e.g. 90d,
+// 6m or monthly; blank for a one-off task), due (YYYY-MM-DD), consumeItemID and
+// consumeUnits.
+func makeHandleSaveMaintenanceTask(db *inventory.Database, edit bool) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        task, err := parseMaintenanceForm(r, edit)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        if edit {
+            err = inventory.UpdateMaintenanceTask(r.Context(), db, task)
+        } else {
+            var id int64
+            id, err = inventory.AddMaintenanceTask(r.Context(), db, task)
+            task.ID = int(id)
+        }
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        saved, err := inventory.GetMaintenanceTask(r.Context(), db, task.ID)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get maintenance task", "id", task.ID, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(saved)
+    }
+}
+
+// parseMaintenanceForm reads the task fields posted to /maintenance/add and /maintenance/edit.
+func parseMaintenanceForm(r *http.Request, edit bool) (inventory.MaintenanceTask, error) {
+    var task inventory.MaintenanceTask
+    var err error
+    if edit {
+        if task.ID, err = strconv.Atoi(r.FormValue("id")); err != nil {
+            return task, errors.New("Invalid task ID")
+        }
+    } else if task.ItemID, err = strconv.Atoi(r.FormValue("itemID")); err != nil {
+        return task, errors.New("Invalid item ID")
+    }
+    task.Name = r.FormValue("name")
+    if task.Every, err = inventory.ParseInterval(r.FormValue("every")); err != nil {
+        return task, err
+    }
+    if s := strings.TrimSpace(r.FormValue("due")); s != "" {
+        due, err := time.Parse(time.DateOnly, s)
+        if err != nil {
+            return task, errors.New("Invalid due date: use YYYY-MM-DD")
+        }
+        task.NextDue = &due
+    }
+    if s := r.FormValue("consumeItemID"); s != "" {
+        if task.ConsumeItemID, err = strconv.Atoi(s); err != nil {
+            return task, errors.New("Invalid consumeItemID")
+        }
+    }
+    if s := r.FormValue("consumeUnits"); s != "" {
+        if task.ConsumeUnits, err = strconv.Atoi(s); err != nil || task.ConsumeUnits < 1 {
+            return task, errors.New("Invalid consumeUnits")
+        }
+    }
+    return task, nil
+}
+
+// makeHandleDeleteMaintenanceTask returns an HTTP handler that removes a task, keeping
+// its log.
+func makeHandleDeleteMaintenanceTask(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid id", http.StatusBadRequest)
+            return
+        }
+        err = inventory.DeleteMaintenanceTask(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Task not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to delete maintenance task", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+    }
+}
+
+// makeHandleCompleteMaintenanceTask returns an HTTP handler that marks a task done from
+// the fields id, date (YYYY-MM-DD, default today), note and skipConsume=1, which leaves
+// the task's consumable in stock. It responds with the log entry and the updated task.
+func makeHandleCompleteMaintenanceTask(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid id", http.StatusBadRequest)
+            return
+        }
+        done := inventory.MaintenanceCompletion{
+            Note:        r.FormValue("note"),
+            SkipConsume: r.FormValue("skipConsume") == "1",
+        }
+        if s := strings.TrimSpace(r.FormValue("date")); s != "" {
+            if done.DoneDate, err = time.Parse(time.DateOnly, s); err != nil {
+                http.Error(w, "Invalid date: use YYYY-MM-DD", http.StatusBadRequest)
+                return
+            }
+        }
+
+        entry, err := inventory.CompleteMaintenanceTask(r.Context(), db, id, done)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Task not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        task, err := inventory.GetMaintenanceTask(r.Context(), db, id)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get maintenance task", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        slog.InfoContext(r.Context(), "maintenance task done", "task", task.Name, "item", task.ItemName, "consumed", entry.ConsumedUnits)
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"entry": entry, "task": task})
+    }
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+    "log/slog"
+    "net/http"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/metrics"
+)
+
+// makeHandleMetrics returns an HTTP handler exposing metrics in the Prometheus text format:
+// request and database latencies recorded in memory, connection pool statistics, and
+// inventory figures read from the database on each scrape.
+func makeHandleMetrics(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+        metrics.Write(w)
+
+        pool := db.Stats()
+        metrics.WriteGauge(w, "inventory_db_open_connections", "Open database connections, in use or idle.", float64(pool.OpenConnections))
+        metrics.WriteGauge(w, "inventory_db_in_use_connections", "Database connections currently in use.", float64(pool.InUse))
+        metrics.WriteGauge(w, "inventory_db_idle_connections", "Idle database connections.", float64(pool.Idle))
+        metrics.WriteGauge(w, "inventory_db_max_open_connections", "Maximum open database connections (0 is unlimited).", float64(pool.MaxOpenConnections))
+        metrics.WriteCounter(w, "inventory_db_wait_count_total", "Times a request waited for a free database connection.", float64(pool.WaitCount))
+        metrics.WriteCounter(w, "inventory_db_wait_duration_seconds_total", "Time spent waiting for a free database connection.", pool.WaitDuration.Seconds())
+        metrics.WriteCounter(w, "inventory_db_max_idle_closed_total", "Connections closed because of the idle pool limit.", float64(pool.MaxIdleClosed))
+        metrics.WriteCounter(w, "inventory_db_max_idle_time_closed_total", "Connections closed because they were idle too long.", float64(pool.MaxIdleTimeClosed))
+        metrics.WriteCounter(w, "inventory_db_max_lifetime_closed_total", "Connections closed because they reached their maximum lifetime.", float64(pool.MaxLifetimeClosed))
+
+        stats, err := inventory.GetInventoryStats(r.Context(), db, defaultExpiringDays)
+        if err != nil {
+            slog.WarnContext(r.Context(), "failed to read inventory stats for metrics", "error", err)
+            metrics.WriteGauge(w, "inventory_stats_up", "Whether the inventory figures could be read from the database.", 0)
+            return
+        }
+        metrics.WriteGauge(w, "inventory_stats_up", "Whether the inventory figures could be read from the database.", 1)
+        metrics.WriteGauge(w, "inventory_items", "Inventory items.", float64(stats.Items))
+        metrics.WriteGauge(w, "inventory_items_under_minimum", "Inventory items below their minimum quantity.", float64(stats.UnderMinimum))
+        metrics.WriteGauge(w, "inventory_units_expiring", "Units expired or expiring within 7 days.", float64(stats.ExpiringUnits))
+        // Summed over the items that exist now, so deleting an item lowers them: gauges, not counters.
+        metrics.WriteGauge(w, "inventory_units_used", "Units used to date by current items.", float64(stats.UnitsUsed))
+        metrics.WriteGauge(w, "inventory_units_tossed", "Units thrown out to date by current items.", float64(stats.UnitsTossed))
+    }
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+    "context"
+    "crypto/rand"
+    "encoding/hex"
+    "log/slog"
+    "net/http"
+    "strconv"
+    "time"
+
+    "myhomeinventory/internal/logging"
+    "myhomeinventory/internal/metrics"
+)
+
+// withRequestTimeout gives every request a context that expires after timeout. Handlers
+// pass the request context to the inventory functions, so a slow query is cancelled
+// instead of holding a database connection. A zero timeout leaves requests unbounded.
+func withRequestTimeout(next http.Handler, timeout time.Duration) http.Handler {
+    if timeout <= 0 {
+        return next
+    }
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        ctx, cancel := context.WithTimeout(r.Context(), timeout)
+        defer cancel()
+        next.ServeHTTP(w, r.WithContext(ctx))
+    })
+}
+
+// withMetrics counts requests and records their latency under the route pattern that
+// matched them, so paths with IDs or unknown URLs do not create a series each.
+func withMetrics(mux *http.ServeMux) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        _, route := mux.Handler(r)
+        if route == "" {
+            route = "unmatched"
+        }
+        start := time.Now()
+        rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+        mux.ServeHTTP(rec, r)
+        metrics.HTTPRequests.Inc(route, r.Method, strconv.Itoa(rec.status))
+        metrics.HTTPDuration.Observe(time.Since(start).Seconds(), route)
+    })
+}
+
+// requestIDHeader carries the request ID. An incoming value from a proxy is reused.
+const requestIDHeader = "X-Request-ID"
+
+// withRequestLogging assigns each request an ID, returns it in the X-Request-ID header,
+// puts it on the request context for downstream logs, and logs the request once it
+// has been served.
+func withRequestLogging(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        id := r.Header.Get(requestIDHeader)
+        if !validRequestID(id) {
+            id = newRequestID()
+        }
+        w.Header().Set(requestIDHeader, id)
+        ctx := logging.WithRequestID(r.Context(), id)
+
+        start := time.Now()
+        rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+        next.ServeHTTP(rec, r.WithContext(ctx))
+
+        level := slog.LevelInfo
+        if rec.status >= 500 {
+            level = slog.LevelError
+        }
+        slog.Log(ctx, level, "request",
+            "method", r.Method,
+            "path", r.URL.Path,
+            "status", rec.status,
+            "bytes", rec.bytes,
+            "duration", time.Since(start),
+        )
+    })
+}
+
+// newRequestID returns a random 16-character hex ID.
+func newRequestID() string {
+    b := make([]byte, 8)
+    rand.Read(b)
+    return hex.EncodeToString(b)
+}
+
+// validRequestID accepts short IDs of letters, digits, '-' and '_' so a client cannot
+// inject arbitrary text into the logs.
+func validRequestID(id string) bool {
+    if id == "" || len(id) > 64 {
+        return false
+    }
+    for _, c := range id {
+        if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+            return false
+        }
+    }
+    return true
+}
+
+// statusRecorder remembers the status code and body size written by a handler.
+type statusRecorder struct {
+    http.ResponseWriter
+    status      int
+    bytes       int
+    wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+    if !r.wroteHeader {
+        r.status = code
+        r.wroteHeader = true
+    }
+    r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+    r.wroteHeader = true
+    n, err := r.ResponseWriter.Write(b)
+    r.bytes += n
+    return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+    return r.ResponseWriter
+}
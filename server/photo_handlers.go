@@ -0,0 +1,213 @@
+package server
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "mime/multipart"
+    "net/http"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/photo"
+)
+
+// photoFormMemory is how much of a multipart upload is held in memory before the
+// rest spills to temporary files.
+const photoFormMemory = 8 << 20
+
+// maxPhotosPerUpload caps how many photos one request may add.
+const maxPhotosPerUpload = 20
+
+// makeHandleAddItemPhotos returns an HTTP handler that stores the images uploaded as
+// multipart "photo" fields (up to maxPhotosPerUpload) and attaches them to the item given
+// by itemID, with the optional caption. Requests larger than maxBytes are refused. Every
+// file is checked before any is stored, so a file that is not a usable JPEG or PNG
+// rejects the whole batch; the files are then decoded and stored one at a time.
+func makeHandleAddItemPhotos(db *inventory.Database, store *photo.Store, maxBytes int64) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+        if err := r.ParseMultipartForm(photoFormMemory); err != nil {
+            var tooLarge *http.MaxBytesError
+            if errors.As(err, &tooLarge) {
+                http.Error(w, fmt.Sprintf("Upload is larger than %d MB", maxBytes>>20), http.StatusRequestEntityTooLarge)
+                return
+            }
+            http.Error(w, "Expected a multipart/form-data upload", http.StatusBadRequest)
+            return
+        }
+        defer r.MultipartForm.RemoveAll()
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        files := r.MultipartForm.File["photo"]
+        if len(files) == 0 {
+            http.Error(w, "No photo uploaded", http.StatusBadRequest)
+            return
+        }
+        if len(files) > maxPhotosPerUpload {
+            http.Error(w, fmt.Sprintf("Upload at most %d photos at a time", maxPhotosPerUpload), http.StatusBadRequest)
+            return
+        }
+        caption := r.FormValue("caption")
+        if err := inventory.CheckPhotoCaption(caption); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        if _, err := inventory.GetItemByID(r.Context(), db, itemID); err != nil {
+            if errors.Is(err, sql.ErrNoRows) {
+                http.Error(w, "Item not found", http.StatusNotFound)
+                return
+            }
+            slog.ErrorContext(r.Context(), "failed to look up item", "id", itemID, "error", err)
+            http.Error(w, "Failed to look up item", http.StatusInternalServerError)
+            return
+        }
+
+        uploads := make([]*photo.Upload, len(files))
+        for i, header := range files {
+            data, err := readUpload(header)
+            if err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+            if uploads[i], err = photo.Check(data); err != nil {
+                http.Error(w, fmt.Sprintf("%s: %v", header.Filename, err), http.StatusBadRequest)
+                return
+            }
+        }
+
+        added := []inventory.ItemPhoto{}
+        for i, header := range files {
+            stored, err := store.Store(uploads[i])
+            uploads[i] = nil // let the stripped bytes go before decoding the next
+            if errors.Is(err, photo.ErrInvalidImage) {
+                http.Error(w, fmt.Sprintf("%s: %v", header.Filename, err), http.StatusBadRequest)
+                return
+            }
+            if err != nil {
+                slog.ErrorContext(r.Context(), "failed to store photo", "file", header.Filename, "error", err)
+                http.Error(w, "Failed to store photo", http.StatusInternalServerError)
+                return
+            }
+
+            p := inventory.ItemPhoto{
+                ItemID:      itemID,
+                FileName:    stored.FileName,
+                ContentType: stored.ContentType,
+                Width:       stored.Width,
+                Height:      stored.Height,
+                SizeBytes:   stored.Size,
+                Caption:     caption,
+            }
+            id, err := inventory.AddItemPhoto(r.Context(), db, p)
+            if errors.Is(err, sql.ErrNoRows) {
+                http.Error(w, "Item not found", http.StatusNotFound)
+                return
+            }
+            if err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+            p.ID = int(id)
+            added = append(added, p)
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(added)
+    }
+}
+
+// readUpload reads an uploaded file into memory.
+func readUpload(header *multipart.FileHeader) ([]byte, error) {
+    f, err := header.Open()
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    return io.ReadAll(f)
+}
+
+// makeHandleDeleteItemPhoto returns an HTTP handler that removes a photo from its item,
+// deleting the file too once no item uses it.
+func makeHandleDeleteItemPhoto(db *inventory.Database, store *photo.Store) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+            return
+        }
+        p, unused, err := inventory.DeleteItemPhoto(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Photo not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to delete photo", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        if unused {
+            if err := store.Remove(p.FileName); err != nil {
+                // The record is gone; the file is left for "photos prune".
+                slog.WarnContext(r.Context(), "failed to remove photo file", "file", p.FileName, "error", err)
+            }
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "itemID": p.ItemID})
+    }
+}
+
+// makeHandlePhoto returns an HTTP handler that serves a stored photo, or its thumbnail
+// when thumb is set. Names are content hashes, so responses may be cached forever.
+func makeHandlePhoto(store *photo.Store, thumb bool) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        path, err := store.Path(r.PathValue("name"), thumb)
+        if err != nil {
+            http.NotFound(w, r)
+            return
+        }
+        w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+        w.Header().Set("X-Content-Type-Options", "nosniff")
+        http.ServeFile(w, r, path)
+    }
+}
+
+// removeUnusedPhotos deletes the files among names that no item refers to any more,
+// such as the photos of an item that was just deleted. Failures are only logged.
+func removeUnusedPhotos(ctx context.Context, db *inventory.Database, store *photo.Store, names []string) {
+    if len(names) == 0 {
+        return
+    }
+    inUse, err := inventory.PhotoFileNames(ctx, db)
+    if err != nil {
+        slog.WarnContext(ctx, "failed to check photo files", "error", err)
+        return
+    }
+    for _, name := range names {
+        if inUse[name] {
+            continue
+        }
+        if err := store.Remove(name); err != nil {
+            slog.WarnContext(ctx, "failed to remove photo file", "file", name, "error", err)
+        }
+    }
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+    "encoding/json"
+    "log/slog"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// scanDebounceWindow is how long an identical scan is ignored after it was processed.
+// USB scanners held over a code can fire the same barcode several times in a row.
+const scanDebounceWindow = 1500 * time.Millisecond
+
+// scanDebouncer drops repeated scans of the same barcode in the same mode.
+type scanDebouncer struct {
+    mu     sync.Mutex
+    window time.Duration
+    seen   map[string]time.Time
+}
+
+// newScanDebouncer creates a scanDebouncer with the given window.
+func newScanDebouncer(window time.Duration) *scanDebouncer {
+    return &scanDebouncer{
+        window: window,
+        seen:   map[string]time.Time{},
+    }
+}
+
+// scanKey identifies a scan for debouncing. Barcodes are normalized first, so the same
+// code typed with spaces or dashes still counts as a repeat.
+func scanKey(code, mode string) string {
+    if barcode, err := inventory.NormalizeBarcode(code); err == nil {
+        code = barcode
+    } else {
+        code = strings.ToUpper(strings.TrimSpace(code))
+    }
+    return mode + ":" + code
+}
+
+// allow reports whether a scan with the given key should be processed.
+func (d *scanDebouncer) allow(key string, now time.Time) bool {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+
+    for k, at := range d.seen {
+        if now.Sub(at) >= d.window {
+            delete(d.seen, k)
+        }
+    }
+
+    _, ok := d.seen[key]
+    return !ok
+}
+
+// record marks a scan as processed, so repeats within the window are ignored. Only
+// scans that went through are recorded; a failed scan can be retried straight away.
+func (d *scanDebouncer) record(key string, now time.Time) {
+    d.mu.Lock()
+    defer d.mu.Unlock()
+    d.seen[key] = now
+}
+
+// makeHandleScanPage returns an HTTP handler that serves the rapid scan page.
+func makeHandleScanPage(pages *pages) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        tmpl, err := pages.lookup("scan.html")
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to parse template", "error", err)
+            http.Error(w, "Failed to load scan page", http.StatusInternalServerError)
+            return
+        }
+
+        if err := tmpl.Execute(w, nil); err != nil {
+            slog.ErrorContext(r.Context(), "failed to render template", "error", err)
+            http.Error(w, "Failed to load scan page", http.StatusInternalServerError)
+        }
+    }
+}
+
+// makeHandleScan returns an HTTP handler that applies a single barcode scan.
+func makeHandleScan(db *inventory.Database, debouncer *scanDebouncer) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        code := r.FormValue("barcode")
+        mode := r.FormValue("mode")
+        if code == "" {
+            http.Error(w, "Barcode required", http.StatusBadRequest)
+            return
+        }
+
+        var result inventory.ScanResult
+        key := scanKey(code, mode)
+        if !debouncer.allow(key, time.Now()) {
+            result = inventory.ScanResult{
+                Status:  "duplicate",
+                Mode:    mode,
+                Barcode: code,
+                Message: "Duplicate scan ignored",
+            }
+        } else {
+            var err error
+            result, err = inventory.ProcessScan(r.Context(), db, code, mode)
+            if err != nil {
+                slog.ErrorContext(r.Context(), "failed to process scan", "error", err)
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+            if result.Status != "error" {
+                debouncer.record(key, time.Now())
+            }
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(result)
+    }
+}
+
+// makeHandleScanQueue returns an HTTP handler that lists unknown scanned barcodes.
+func makeHandleScanQueue(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        scans, err := inventory.GetQueuedScans(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get scan queue", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(scans)
+    }
+}
+
+// makeHandleRemoveQueuedScan returns an HTTP handler that dismisses a barcode from the unknown scan queue.
+func makeHandleRemoveQueuedScan(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        barcode := r.FormValue("barcode")
+        if barcode == "" {
+            http.Error(w, "Barcode required", http.StatusBadRequest)
+            return
+        }
+
+        if err := inventory.RemoveQueuedScan(r.Context(), db, barcode); err != nil {
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// maxSearchResults caps the limit parameter of /search.
+const maxSearchResults = 100
+
+// makeHandleSearch returns an HTTP handler that searches item names, aliases and barcodes
+// for ?q= and lists the matches as JSON, best first. ?limit= sets how many (default 20).
+func makeHandleSearch(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        limit := 0
+        if s := r.URL.Query().Get("limit"); s != "" {
+            n, err := strconv.Atoi(s)
+            if err != nil || n < 1 || n > maxSearchResults {
+                http.Error(w, "Invalid limit", http.StatusBadRequest)
+                return
+            }
+            limit = n
+        }
+
+        results, err := inventory.SearchItems(r.Context(), db, r.URL.Query().Get("q"), limit)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to search items", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(results)
+    }
+}
+
+// makeHandleAddItemAlias returns an HTTP handler that gives an item another name to be
+// found by in search.
+func makeHandleAddItemAlias(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        alias := r.FormValue("alias")
+
+        err = inventory.AddItemAlias(r.Context(), db, itemID, alias)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        resp := map[string]interface{}{
+            "itemID": itemID,
+            "alias":  alias,
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(resp)
+    }
+}
+
+// makeHandleRemoveItemAlias returns an HTTP handler that removes one of an item's aliases.
+func makeHandleRemoveItemAlias(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        alias := r.FormValue("alias")
+
+        if err := inventory.RemoveItemAlias(r.Context(), db, itemID, alias); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        resp := map[string]interface{}{
+            "itemID": itemID,
+            "alias":  alias,
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(resp)
+    }
+}
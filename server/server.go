@@ -1,21 +1,99 @@
 package server
 
 import (
+    "io/fs"
     "net/http"
+    "time"
     "myhomeinventory/internal/inventory"
+    "myhomeinventory/internal/photo"
 )
 
+// Options configures NewRouter.
+type Options struct {
+    // RequestTimeout bounds each request's database work; zero leaves it unbounded.
+    RequestTimeout time.Duration
+    // PublicURL is the base URL printed labels link to; when empty it is taken from the request.
+    PublicURL string
+    // Assets holds the templates/ and static/ directories, normally embedded in the binary.
+    Assets fs.FS
+    // Dev re-reads templates on every request instead of parsing them once.
+    Dev bool
+    // Photos stores uploaded item photos.
+    Photos *photo.Store
+    // MaxPhotoUpload is the largest photo upload request accepted, in bytes.
+    MaxPhotoUpload int64
+}
+
 // NewRouter creates a new HTTP router with all the application's routes configured.
-// It serves static files, API endpoints, and the main application page.
-func NewRouter(db *inventory.Database) http.Handler {
+// It serves static files, API endpoints, and the main application page. Each request's
+// database work is cancelled after RequestTimeout, or when the client goes away, and
+// every request is logged with an ID that also tags the log lines it causes.
+func NewRouter(db *inventory.Database, opts Options) (http.Handler, error) {
+    pages, err := newPages(opts.Assets, opts.Dev)
+    if err != nil {
+        return nil, err
+    }
+    static, err := staticHandler(opts.Assets)
+    if err != nil {
+        return nil, err
+    }
+
     mux := http.NewServeMux()
 
-    mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("static"))))
+    mux.Handle("/static/", http.StripPrefix("/static/", static))
+    mux.HandleFunc("/healthz", makeHandleHealthz())
+    mux.HandleFunc("/readyz", makeHandleReadyz(db))
+    mux.HandleFunc("/version", makeHandleVersion())
+    mux.HandleFunc("/metrics", makeHandleMetrics(db))
     mux.HandleFunc("/items", makeHandleItems(db))
+    mux.HandleFunc("GET "+itemPagePrefix+"{id}", makeHandleItemPage(db, pages))
     mux.HandleFunc("/item/add", makeHandleAddItem(db))
     mux.HandleFunc("/item/update", makeHandleUpdateItem(db))
     mux.HandleFunc("/item/dispose", makeHandleDisposeItem(db)) // <-- New dispose route
-    mux.HandleFunc("/", makeHandleAddItemForm(db)) 
+    mux.HandleFunc("/item/edit", makeHandleEditItem(db))
+    mux.HandleFunc("/item/delete", makeHandleDeleteItem(db, opts.Photos))
+    mux.HandleFunc("/types", makeHandleTypes(db))
+    mux.HandleFunc("/substitutions", makeHandleSubstitutions(db))
+    mux.HandleFunc("/locations", makeHandleLocations(db))
+    mux.HandleFunc("/expiring", makeHandleExpiring(db))
+    mux.HandleFunc("/item/barcode/add", makeHandleAddItemBarcode(db))
+    mux.HandleFunc("/item/barcode/remove", makeHandleRemoveItemBarcode(db))
+    mux.HandleFunc("/item/alias/add", makeHandleAddItemAlias(db))
+    mux.HandleFunc("/item/alias/remove", makeHandleRemoveItemAlias(db))
+    mux.HandleFunc("/search", makeHandleSearch(db))
+    mux.HandleFunc("/tags", makeHandleTags(db))
+    mux.HandleFunc("/tags/add", makeHandleAddTag(db))
+    mux.HandleFunc("/tags/rename", makeHandleRenameTag(db))
+    mux.HandleFunc("/tags/delete", makeHandleDeleteTag(db))
+    mux.HandleFunc("/item/tag/add", makeHandleTagItems(db, true))
+    mux.HandleFunc("/item/tag/remove", makeHandleTagItems(db, false))
+    mux.HandleFunc("/item/note", makeHandleSetItemNote(db))
+    mux.HandleFunc("/item/photo/add", makeHandleAddItemPhotos(db, opts.Photos, opts.MaxPhotoUpload))
+    mux.HandleFunc("/item/photo/delete", makeHandleDeleteItemPhoto(db, opts.Photos))
+    mux.HandleFunc("/item/asset", makeHandleSetItemAsset(db))
+    mux.HandleFunc("/item/asset/remove", makeHandleRemoveItemAsset(db))
+    mux.HandleFunc("/reports/insurance", makeHandleInsuranceReport(db))
+    mux.HandleFunc("/maintenance/due", makeHandleMaintenanceDue(db))
+    mux.HandleFunc("/maintenance/tasks", makeHandleMaintenanceTasks(db))
+    mux.HandleFunc("/maintenance/log", makeHandleMaintenanceLog(db))
+    mux.HandleFunc("/maintenance/add", makeHandleSaveMaintenanceTask(db, false))
+    mux.HandleFunc("/maintenance/edit", makeHandleSaveMaintenanceTask(db, true))
+    mux.HandleFunc("/maintenance/delete", makeHandleDeleteMaintenanceTask(db))
+    mux.HandleFunc("/maintenance/done", makeHandleCompleteMaintenanceTask(db))
+    mux.HandleFunc("/loans", makeHandleLoans(db))
+    mux.HandleFunc("/item/lend", makeHandleLendItem(db))
+    mux.HandleFunc("/item/return", makeHandleReturnLoan(db))
+    mux.HandleFunc("GET /photos/{name}", makeHandlePhoto(opts.Photos, false))
+    mux.HandleFunc("GET /photos/thumbs/{name}", makeHandlePhoto(opts.Photos, true))
+    mux.HandleFunc("/barcode/lookup", makeHandleBarcodeLookup(db))
+    mux.HandleFunc("/labels", makeHandleLabels(db, opts.PublicURL))
+    mux.HandleFunc("/export", makeHandleExport(db))
+    mux.HandleFunc("/import", makeHandleImport(db))
+    mux.HandleFunc("/scan", makeHandleScanPage(pages))
+    mux.HandleFunc("/scan/submit", makeHandleScan(db, newScanDebouncer(scanDebounceWindow)))
+    mux.HandleFunc("/scan/queue", makeHandleScanQueue(db))
+    mux.HandleFunc("/scan/queue/remove", makeHandleRemoveQueuedScan(db))
+    mux.HandleFunc("/", makeHandleAddItemForm(db, pages)) 
 
-    return mux
+    return withRequestLogging(withRequestTimeout(withMetrics(mux), opts.RequestTimeout)), nil
 }
@@ -0,0 +1,170 @@
+package server
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "log/slog"
+    "net/http"
+    "strconv"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// makeHandleTags returns an HTTP handler that lists tags with their item counts as JSON.
+func makeHandleTags(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        tags, err := inventory.GetItemTags(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to get tags", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(tags)
+    }
+}
+
+// makeHandleAddTag returns an HTTP handler that creates a tag from the name field.
+func makeHandleAddTag(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := inventory.AddItemTag(r.Context(), db, r.FormValue("name"))
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+    }
+}
+
+// makeHandleRenameTag returns an HTTP handler that renames the tag with the given id.
+func makeHandleRenameTag(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+            return
+        }
+        err = inventory.RenameItemTag(r.Context(), db, id, r.FormValue("name"))
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Tag not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+    }
+}
+
+// makeHandleDeleteTag returns an HTTP handler that deletes a tag and removes it from its items.
+func makeHandleDeleteTag(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        id, err := strconv.Atoi(r.FormValue("id"))
+        if err != nil {
+            http.Error(w, "Invalid tag ID", http.StatusBadRequest)
+            return
+        }
+        err = inventory.DeleteItemTag(r.Context(), db, id)
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Tag not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to delete tag", "id", id, "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"id": id})
+    }
+}
+
+// makeHandleTagItems returns an HTTP handler that adds (tag=true) or removes (tag=false)
+// the tag named by the tag field on every item listed in itemID fields, so a whole
+// selection can be tagged at once.
+func makeHandleTagItems(db *inventory.Database, tag bool) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+        if err := r.ParseForm(); err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        itemIDs := make([]int, 0, len(r.Form["itemID"]))
+        for _, s := range r.Form["itemID"] {
+            id, err := strconv.Atoi(s)
+            if err != nil {
+                http.Error(w, "Invalid item ID", http.StatusBadRequest)
+                return
+            }
+            itemIDs = append(itemIDs, id)
+        }
+
+        name := r.FormValue("tag")
+        update := inventory.UntagItems
+        if tag {
+            update = inventory.TagItems
+        }
+        changed, err := update(r.Context(), db, name, itemIDs)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        resp := map[string]interface{}{
+            "tag":     name,
+            "changed": changed,
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(resp)
+    }
+}
+
+// makeHandleSetItemNote returns an HTTP handler that replaces an item's markdown note.
+func makeHandleSetItemNote(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        itemID, err := strconv.Atoi(r.FormValue("itemID"))
+        if err != nil {
+            http.Error(w, "Invalid item ID", http.StatusBadRequest)
+            return
+        }
+        err = inventory.SetItemNote(r.Context(), db, itemID, r.FormValue("note"))
+        if errors.Is(err, sql.ErrNoRows) {
+            http.Error(w, "Item not found", http.StatusNotFound)
+            return
+        }
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+        w.Header().Set("Content-Type", "application/json")
+        json.NewEncoder(w).Encode(map[string]interface{}{"itemID": itemID})
+    }
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "log/slog"
+    "net/http"
+    "strings"
+
+    "myhomeinventory/internal/inventory"
+)
+
+// maxImportSize caps the size of uploaded import files.
+const maxImportSize = 32 << 20
+
+// makeHandleExport returns an HTTP handler that exports the inventory.
+// format=json (default) returns a single JSON document; format=csv returns one table
+// selected by the table parameter, or a zip of every table when table is omitted.
+func makeHandleExport(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        format := r.URL.Query().Get("format")
+        table := r.URL.Query().Get("table")
+
+        doc, err := inventory.ExportInventory(r.Context(), db)
+        if err != nil {
+            slog.ErrorContext(r.Context(), "failed to export inventory", "error", err)
+            http.Error(w, err.Error(), http.StatusInternalServerError)
+            return
+        }
+
+        switch {
+        case format == "" || format == "json":
+            w.Header().Set("Content-Type", "application/json")
+            w.Header().Set("Content-Disposition", `attachment; filename="inventory.json"`)
+            enc := json.NewEncoder(w)
+            enc.SetIndent("", "  ")
+            enc.Encode(doc)
+        case format == "csv" && table == "":
+            w.Header().Set("Content-Type", "application/zip")
+            w.Header().Set("Content-Disposition", `attachment; filename="inventory-csv.zip"`)
+            if err := inventory.WriteExportZip(w, doc); err != nil {
+                slog.ErrorContext(r.Context(), "failed to write export", "error", err)
+            }
+        case format == "csv":
+            var buf bytes.Buffer
+            if err := inventory.WriteExportCSV(&buf, doc, table); err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+            w.Header().Set("Content-Type", "text/csv")
+            w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", table+".csv"))
+            w.Write(buf.Bytes())
+        default:
+            http.Error(w, "Invalid format: must be json or csv", http.StatusBadRequest)
+        }
+    }
+}
+
+// makeHandleImport returns an HTTP handler that imports an inventory document.
+// The file is taken from a multipart "file" field or the raw request body. Query or form
+// parameters: format (json, csv or zip; inferred when omitted), table (for CSV),
+// dryRun and createMissing ("1" or "true").
+func makeHandleImport(db *inventory.Database) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        if r.Method != http.MethodPost {
+            http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+            return
+        }
+
+        r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+        var data []byte
+        var filename string
+        if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+            file, header, err := r.FormFile("file")
+            if err != nil {
+                http.Error(w, "Import file required", http.StatusBadRequest)
+                return
+            }
+            defer file.Close()
+            filename = header.Filename
+            if data, err = io.ReadAll(file); err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+        } else {
+            var err error
+            if data, err = io.ReadAll(r.Body); err != nil {
+                http.Error(w, err.Error(), http.StatusBadRequest)
+                return
+            }
+        }
+
+        opts := inventory.ImportOptions{
+            DryRun:        formBool(r, "dryRun"),
+            CreateMissing: formBool(r, "createMissing"),
+        }
+
+        report, err := inventory.ImportData(r.Context(), db, data, r.FormValue("format"), r.FormValue("table"), filename, opts)
+        if err != nil {
+            http.Error(w, err.Error(), http.StatusBadRequest)
+            return
+        }
+
+        w.Header().Set("Content-Type", "application/json")
+        if len(report.Errors) > 0 {
+            w.WriteHeader(http.StatusUnprocessableEntity)
+        }
+        json.NewEncoder(w).Encode(report)
+    }
+}
+
+// formBool reports whether a query or form value is set to a true-like value.
+func formBool(r *http.Request, key string) bool {
+    switch strings.ToLower(r.FormValue(key)) {
+    case "1", "true", "yes", "on":
+        return true
+    }
+    return false
+}
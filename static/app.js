@@ -1,13 +1,46 @@
 document.addEventListener('DOMContentLoaded', function () {
     loadItems();
+    loadMaintenanceDue();
+    loadOverdueLoans();
     document.getElementById('addItemForm').addEventListener('submit', addItem);
+    document.getElementById('barcodeLookupForm').addEventListener('submit', lookupBarcode);
+    document.getElementById('searchForm').addEventListener('submit', event => event.preventDefault());
+    document.getElementById('searchQuery').addEventListener('input', searchItems);
+    document.getElementById('tagFilterForm').addEventListener('submit', event => {
+        event.preventDefault();
+        loadItems();
+    });
+    document.getElementById('kindFilter').addEventListener('change', loadItems);
+    document.getElementById('bulkTagForm').addEventListener('submit', tagSelectedItems);
+    document.getElementById('selectAllItems').addEventListener('change', event => {
+        document.querySelectorAll('input.select-item').forEach(box => box.checked = event.target.checked);
+    });
+
+    // Links from the scan queue pass the unknown barcode along to pre-fill the add form.
+    const barcode = new URLSearchParams(window.location.search).get('barcode');
+    if (barcode) {
+        document.getElementById('itemBarcode').value = barcode;
+        document.getElementById('itemName').focus();
+    }
 });
 
 /**
- * loadItems fetches the list of inventory items and populates the table.
+ * loadItems fetches the list of inventory items, limited to the tag in the tag filter
+ * box if there is one and to the selected kind, and populates the table.
  */
 function loadItems() {
-    fetch('/items')
+    const params = new URLSearchParams();
+    const tag = document.getElementById('tagFilter').value.trim();
+    if (tag) {
+        params.append('tag', tag);
+    }
+    const kind = document.getElementById('kindFilter').value;
+    if (kind) {
+        params.append('kind', kind);
+    }
+    document.getElementById('selectAllItems').checked = false;
+
+    fetch('/items?' + params.toString())
         .then(response => response.json())
         .then(data => {
             const tableBody = document.getElementById('inventoryTableBody');
@@ -17,10 +50,14 @@ function loadItems() {
                 const row = document.createElement('tr');
 
                 row.innerHTML = `
-                    <td>${item.itemName}</td>
+                    <td><input type="checkbox" class="select-item" value="${item.id}"></td>
+                    <td>
+                        <a href="/items/${item.id}">${item.itemName}</a>
+                        ${(item.barcodes || []).map(code => `<div class="barcode">${code}</div>`).join('')}
+                    </td>
                     <td>
                         <button class="decrement" onclick="updateItem('${item.itemName}', '-')">−</button>
-                        ${item.itemQTY}
+                        ${item.itemQTY}${item.lentQTY ? ` <small>(+${item.lentQTY} lent)</small>` : ''}
                         <button class="increment" onclick="updateItem('${item.itemName}', '+')">+</button>
                     </td>
                     <td>${item.itemUsedToDate}</td>
@@ -28,8 +65,10 @@ function loadItems() {
                     <td>${item.minimumQTY}</td>
                     <td>${item.itemTypeName}</td>
                     <td>${item.itemSubstitutionName}</td>
+                    <td>${(item.tags || []).map(tag => `<span class="tag">${tag}</span>`).join(' ')}</td>
                     <td>
                         <button class="dispose" onclick="disposeItem('${item.itemName}')">🗑️ Dispose</button>
+                        <a href="/labels?itemID=${item.id}&per=unit" target="_blank">🏷️ Labels</a>
                     </td>
                 `;
 
@@ -39,6 +78,110 @@ function loadItems() {
         .catch(error => console.error('Error loading items:', error));
 }
 
+let searchTimer;
+
+/**
+ * searchItems looks up the search box text once typing pauses and lists the matches,
+ * best first, linking to each item's page.
+ */
+function searchItems() {
+    clearTimeout(searchTimer);
+    searchTimer = setTimeout(() => {
+        const query = document.getElementById('searchQuery').value.trim();
+        const list = document.getElementById('searchResults');
+        if (!query) {
+            list.innerHTML = '';
+            return;
+        }
+
+        fetch('/search?q=' + encodeURIComponent(query))
+            .then(response => response.json())
+            .then(results => {
+                // Ignore responses for text the user has since changed.
+                if (document.getElementById('searchQuery').value.trim() !== query) {
+                    return;
+                }
+                list.innerHTML = '';
+                if (results.length === 0) {
+                    const li = document.createElement('li');
+                    li.textContent = 'No matching items.';
+                    list.appendChild(li);
+                    return;
+                }
+                results.forEach(result => {
+                    const li = document.createElement('li');
+                    const link = document.createElement('a');
+                    link.href = `/items/${result.item.id}`;
+                    link.textContent = result.item.itemName;
+                    li.appendChild(link);
+                    li.appendChild(document.createTextNode(
+                        ` — ${result.item.itemQTY} in stock, ${result.item.itemTypeName}` +
+                        (result.fuzzy ? ' (close match)' : '')));
+                    list.appendChild(li);
+                });
+            })
+            .catch(error => console.error('Error searching items:', error));
+    }, 250);
+}
+
+/**
+ * loadMaintenanceDue lists the maintenance tasks and warranties due in the next two
+ * weeks, overdue ones first, linking to each item's page where tasks are marked done.
+ */
+function loadMaintenanceDue() {
+    fetch('/maintenance/due')
+        .then(response => response.json())
+        .then(due => {
+            const list = document.getElementById('maintenanceDue');
+            list.innerHTML = '';
+            due.forEach(entry => {
+                const li = document.createElement('li');
+                if (entry.overdue) {
+                    li.className = 'warning';
+                }
+                const link = document.createElement('a');
+                link.href = `/items/${entry.itemID}`;
+                link.textContent = entry.itemName;
+                let when = `in ${entry.daysLeft} days`;
+                if (entry.overdue) {
+                    when = `overdue by ${-entry.daysLeft} days`;
+                } else if (entry.daysLeft === 0) {
+                    when = 'today';
+                }
+                li.appendChild(document.createTextNode(`${entry.name}: `));
+                li.appendChild(link);
+                li.appendChild(document.createTextNode(` — due ${when}`));
+                list.appendChild(li);
+            });
+        })
+        .catch(error => console.error('Error loading maintenance:', error));
+}
+
+/**
+ * loadOverdueLoans lists the loans not returned by their due date, linking to each
+ * item's page where they are checked back in.
+ */
+function loadOverdueLoans() {
+    fetch('/loans?overdue=true')
+        .then(response => response.json())
+        .then(loans => {
+            const list = document.getElementById('overdueLoans');
+            list.innerHTML = '';
+            loans.forEach(loan => {
+                const li = document.createElement('li');
+                li.className = 'warning';
+                const link = document.createElement('a');
+                link.href = `/items/${loan.itemID}`;
+                link.textContent = loan.itemName;
+                li.appendChild(document.createTextNode(`${loan.borrower} has ${loan.quantity} × `));
+                li.appendChild(link);
+                li.appendChild(document.createTextNode(` — overdue by ${loan.daysOverdue} days`));
+                list.appendChild(li);
+            });
+        })
+        .catch(error => console.error('Error loading loans:', error));
+}
+
 /**
  * addItem handles form submission to add a new inventory item.
  */
@@ -51,6 +194,7 @@ function addItem(event) {
     const itemQTY = document.getElementById('itemQTY').value.trim();
     const minimumQTY = document.getElementById('minimumQTY').value.trim();
     const itemExpirationPeriod = document.getElementById('itemExpirationPeriod').value.trim();
+    const itemBarcode = document.getElementById('itemBarcode').value.trim();
 
     if (!itemName || !itemTypeID || !itemSubstitutionID || !itemQTY || !minimumQTY || !itemExpirationPeriod) {
         console.error('All fields are required.');
@@ -64,6 +208,9 @@ function addItem(event) {
     formData.append('itemQTY', itemQTY);
     formData.append('minimumQTY', minimumQTY);
     formData.append('itemExpirationPeriod', itemExpirationPeriod);
+    if (itemBarcode) {
+        formData.append('barcode', itemBarcode);
+    }
 
     fetch('/item/add', {
         method: 'POST',
@@ -77,12 +224,48 @@ function addItem(event) {
             document.getElementById('addItemForm').reset();
             loadItems();
         } else {
-            console.error('Failed to add item.');
+            response.text().then(text => console.error('Failed to add item.', text));
         }
     })
     .catch(error => console.error('Error adding item:', error));
 }
 
+/**
+ * tagSelectedItems adds the bulk tag box's tag to, or removes it from, every checked item.
+ */
+function tagSelectedItems(event) {
+    event.preventDefault();
+    const status = document.getElementById('bulkTagStatus');
+    const action = event.submitter ? event.submitter.dataset.action : 'add';
+
+    const formData = new URLSearchParams();
+    formData.append('tag', document.getElementById('bulkTag').value.trim());
+    document.querySelectorAll('input.select-item:checked').forEach(box => formData.append('itemID', box.value));
+    if (!formData.has('itemID')) {
+        status.textContent = 'Select some items first.';
+        return;
+    }
+
+    fetch('/item/tag/' + action, {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/x-www-form-urlencoded'
+        },
+        body: formData.toString()
+    })
+    .then(response => {
+        if (response.ok) {
+            return response.json();
+        }
+        return response.text().then(text => { throw new Error(text.trim()); });
+    })
+    .then(data => {
+        status.textContent = `${action === 'add' ? 'Tagged' : 'Untagged'} ${data.changed} item(s) "${data.tag}".`;
+        loadItems();
+    })
+    .catch(error => status.textContent = error.message);
+}
+
 /**
  * updateItem sends a request to update the quantity of an inventory item.
  */
@@ -135,3 +318,59 @@ function disposeItem(itemName) {
     })
     .catch(error => console.error('Error disposing item:', error));
 }
+
+/**
+ * lookupBarcode resolves a scanned barcode. Known items are incremented;
+ * catalog matches and unknown codes pre-fill the add item form.
+ */
+function lookupBarcode(event) {
+    event.preventDefault();
+
+    const input = document.getElementById('lookupBarcode');
+    const status = document.getElementById('barcodeStatus');
+    const barcode = input.value.trim();
+    if (!barcode) {
+        return;
+    }
+
+    const formData = new URLSearchParams();
+    formData.append('barcode', barcode);
+
+    fetch('/barcode/lookup', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/x-www-form-urlencoded'
+        },
+        body: formData.toString()
+    })
+    .then(response => {
+        if (response.ok) {
+            return response.json();
+        }
+        return response.text().then(text => { throw new Error(text); });
+    })
+    .then(result => {
+        input.value = '';
+        if (result.status === 'item') {
+            status.textContent = `Added one ${result.item.itemName} (now ${result.item.itemQTY}).`;
+            loadItems();
+            return;
+        }
+
+        document.getElementById('itemBarcode').value = result.barcode;
+        if (result.status === 'catalog') {
+            const product = result.product;
+            document.getElementById('itemName').value = product.brand
+                ? `${product.brand} ${product.productName}`
+                : product.productName;
+            status.textContent = `Found "${product.productName}" in the catalog. Complete the form to add it.`;
+        } else {
+            status.textContent = `Barcode ${result.barcode} is not known. Fill in the form to add it.`;
+        }
+        document.getElementById('itemName').focus();
+    })
+    .catch(error => {
+        status.textContent = `Lookup failed: ${error.message}`;
+        console.error('Error looking up barcode:', error);
+    });
+}
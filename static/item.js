@@ -0,0 +1,199 @@
+document.addEventListener('DOMContentLoaded', function () {
+    const controls = document.getElementById('itemControls');
+    const itemName = controls.dataset.itemName;
+
+    controls.querySelectorAll('button[data-action]').forEach(button => {
+        button.addEventListener('click', () => {
+            const action = button.dataset.action;
+            if (action === 'dispose') {
+                postAndReload('/item/dispose', { itemName: itemName });
+            } else {
+                postAndReload('/item/update', { itemName: itemName, action: action });
+            }
+        });
+    });
+
+    document.querySelectorAll('button.remove-barcode').forEach(button => {
+        button.addEventListener('click', () => {
+            postAndReload('/item/barcode/remove', { barcode: button.dataset.barcode });
+        });
+    });
+
+    const editForm = document.getElementById('editItemForm');
+    editForm.addEventListener('submit', event => {
+        event.preventDefault();
+        const fields = Object.fromEntries(new FormData(editForm));
+        fields.id = editForm.dataset.itemId;
+        postAndReload('/item/edit', fields);
+    });
+
+    document.getElementById('deleteItemButton').addEventListener('click', () => {
+        if (!confirm(`Delete ${itemName} and all of its units? Its history is kept.`)) {
+            return;
+        }
+        postAndReload('/item/delete', { id: editForm.dataset.itemId }, '/');
+    });
+
+    const aliasForm = document.getElementById('addAliasForm');
+    aliasForm.addEventListener('submit', event => {
+        event.preventDefault();
+        postAndReload('/item/alias/add', {
+            itemID: aliasForm.dataset.itemId,
+            alias: aliasForm.elements.alias.value.trim()
+        });
+    });
+
+    document.querySelectorAll('button.remove-alias').forEach(button => {
+        button.addEventListener('click', () => {
+            postAndReload('/item/alias/remove', { itemID: aliasForm.dataset.itemId, alias: button.dataset.alias });
+        });
+    });
+
+    const tagForm = document.getElementById('addTagForm');
+    tagForm.addEventListener('submit', event => {
+        event.preventDefault();
+        postAndReload('/item/tag/add', {
+            itemID: tagForm.dataset.itemId,
+            tag: tagForm.elements.tag.value.trim()
+        });
+    });
+
+    document.querySelectorAll('button.remove-tag').forEach(button => {
+        button.addEventListener('click', () => {
+            postAndReload('/item/tag/remove', { itemID: tagForm.dataset.itemId, tag: button.dataset.tag });
+        });
+    });
+
+    const noteForm = document.getElementById('noteForm');
+    noteForm.addEventListener('submit', event => {
+        event.preventDefault();
+        postAndReload('/item/note', {
+            itemID: noteForm.dataset.itemId,
+            note: noteForm.elements.note.value
+        });
+    });
+
+    const photoForm = document.getElementById('photoForm');
+    photoForm.addEventListener('submit', event => {
+        event.preventDefault();
+        const fields = new FormData(photoForm);
+        fields.append('itemID', photoForm.dataset.itemId);
+        document.getElementById('itemStatus').textContent = 'Uploading...';
+        postAndReload('/item/photo/add', fields);
+    });
+
+    document.querySelectorAll('button.remove-photo').forEach(button => {
+        button.addEventListener('click', () => {
+            if (confirm('Remove this photo?')) {
+                postAndReload('/item/photo/delete', { id: button.dataset.photoId });
+            }
+        });
+    });
+
+    const assetForm = document.getElementById('assetForm');
+    assetForm.addEventListener('submit', event => {
+        event.preventDefault();
+        const fields = Object.fromEntries(new FormData(assetForm));
+        fields.itemID = assetForm.dataset.itemId;
+        postAndReload('/item/asset', fields);
+    });
+
+    const removeAssetButton = document.getElementById('removeAssetButton');
+    if (removeAssetButton) {
+        removeAssetButton.addEventListener('click', () => {
+            if (confirm(`Stop tracking ${itemName} as an asset? Its purchase and warranty details are discarded.`)) {
+                postAndReload('/item/asset/remove', { itemID: assetForm.dataset.itemId });
+            }
+        });
+    }
+
+    const taskForm = document.getElementById('taskForm');
+    taskForm.addEventListener('submit', event => {
+        event.preventDefault();
+        const fields = Object.fromEntries(new FormData(taskForm));
+        fields.itemID = taskForm.dataset.itemId;
+        if (!fields.consumeItemID) {
+            delete fields.consumeUnits;
+        }
+        postAndReload('/maintenance/add', fields);
+    });
+
+    document.querySelectorAll('button.complete-task').forEach(button => {
+        button.addEventListener('click', () => {
+            const note = prompt(`Mark "${button.dataset.taskName}" done. Note (optional):`, '');
+            if (note === null) {
+                return;
+            }
+            const fields = { id: button.dataset.taskId, note: note };
+            if (button.dataset.consumes && !confirm(`Use ${button.dataset.consumes} from stock?`)) {
+                fields.skipConsume = '1';
+            }
+            postAndReload('/maintenance/done', fields);
+        });
+    });
+
+    document.querySelectorAll('button.remove-task').forEach(button => {
+        button.addEventListener('click', () => {
+            if (confirm(`Remove the task "${button.dataset.taskName}"? Its log is kept.`)) {
+                postAndReload('/maintenance/delete', { id: button.dataset.taskId });
+            }
+        });
+    });
+
+    const lendForm = document.getElementById('lendForm');
+    lendForm.addEventListener('submit', event => {
+        event.preventDefault();
+        const fields = Object.fromEntries(new FormData(lendForm));
+        fields.itemID = lendForm.dataset.itemId;
+        postAndReload('/item/lend', fields);
+    });
+
+    document.querySelectorAll('button.return-loan').forEach(button => {
+        button.addEventListener('click', () => {
+            if (confirm(`Mark the units lent to ${button.dataset.borrower} as returned?`)) {
+                postAndReload('/item/return', { id: button.dataset.loanId });
+            }
+        });
+    });
+
+    const barcodeForm = document.getElementById('addBarcodeForm');
+    barcodeForm.addEventListener('submit', event => {
+        event.preventDefault();
+        postAndReload('/item/barcode/add', {
+            itemID: barcodeForm.dataset.itemId,
+            barcode: barcodeForm.elements.barcode.value.trim()
+        });
+    });
+});
+
+/**
+ * postAndReload submits a form-encoded POST, or a multipart one when fields is a
+ * FormData, and reloads the page to show the change, or goes to nextPage when given,
+ * or shows the server's error message.
+ */
+function postAndReload(url, fields, nextPage) {
+    const status = document.getElementById('itemStatus');
+    const request = { method: 'POST', body: fields };
+    if (!(fields instanceof FormData)) {
+        request.headers = {
+            'Content-Type': 'application/x-www-form-urlencoded'
+        };
+        request.body = new URLSearchParams(fields).toString();
+    }
+    fetch(url, request)
+    .then(response => {
+        if (response.ok) {
+            if (nextPage) {
+                window.location.href = nextPage;
+            } else {
+                window.location.reload();
+            }
+            return;
+        }
+        return response.text().then(text => { throw new Error(text); });
+    })
+    .catch(error => {
+        status.textContent = error.message;
+        console.error('Error updating item:', error);
+    });
+}
@@ -0,0 +1,179 @@
+let scanMode = 'in';
+let audioContext = null;
+
+document.addEventListener('DOMContentLoaded', function () {
+    document.getElementById('scanForm').addEventListener('submit', submitScan);
+    document.querySelectorAll('.scan-mode-button').forEach(button => {
+        button.addEventListener('click', () => setScanMode(button.dataset.mode));
+    });
+
+    // Keep the scan input focused so the scanner's keystrokes always land in it.
+    document.addEventListener('click', () => document.getElementById('scanInput').focus());
+
+    loadScanQueue();
+});
+
+/**
+ * setScanMode switches between stock-in and stock-out and updates the toggle buttons.
+ */
+function setScanMode(mode) {
+    scanMode = mode;
+    document.querySelectorAll('.scan-mode-button').forEach(button => {
+        button.classList.toggle('active', button.dataset.mode === mode);
+    });
+    document.body.classList.toggle('scan-out', mode === 'out');
+}
+
+/**
+ * submitScan sends the scanned barcode to the server. Scanners type the digits
+ * followed by Enter, which submits the form.
+ */
+function submitScan(event) {
+    event.preventDefault();
+
+    const input = document.getElementById('scanInput');
+    const barcode = input.value.trim();
+    input.value = '';
+    if (!barcode) {
+        return;
+    }
+
+    const formData = new URLSearchParams();
+    formData.append('barcode', barcode);
+    formData.append('mode', scanMode);
+
+    fetch('/scan/submit', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/x-www-form-urlencoded'
+        },
+        body: formData.toString()
+    })
+    .then(response => {
+        if (response.ok) {
+            return response.json();
+        }
+        return response.text().then(text => { throw new Error(text); });
+    })
+    .then(result => {
+        if (result.status === 'mode') {
+            setScanMode(result.mode);
+        }
+        showScanResult(result.status, result.message);
+        if (result.status === 'queued') {
+            loadScanQueue();
+        }
+    })
+    .catch(error => {
+        showScanResult('error', error.message);
+        console.error('Error submitting scan:', error);
+    });
+}
+
+/**
+ * showScanResult flashes the result banner, beeps, and adds an entry to the scan log.
+ */
+function showScanResult(status, message) {
+    const banner = document.getElementById('scanResult');
+    banner.className = `scan-result scan-${status}`;
+    banner.textContent = message;
+
+    const entry = document.createElement('li');
+    entry.className = `scan-${status}`;
+    entry.textContent = `${new Date().toLocaleTimeString()} — ${message}`;
+    const log = document.getElementById('scanLog');
+    log.insertBefore(entry, log.firstChild);
+    while (log.children.length > 20) {
+        log.removeChild(log.lastChild);
+    }
+
+    beep(status);
+}
+
+/**
+ * beep plays a short tone: high for success, low for problems, double for mode changes.
+ */
+function beep(status) {
+    try {
+        audioContext = audioContext || new (window.AudioContext || window.webkitAudioContext)();
+    } catch (e) {
+        return;
+    }
+
+    const tones = {
+        in: [880],
+        out: [660],
+        mode: [990, 990],
+        queued: [440, 330],
+        duplicate: [],
+        error: [220]
+    }[status] || [220];
+
+    tones.forEach((frequency, i) => {
+        const oscillator = audioContext.createOscillator();
+        const gain = audioContext.createGain();
+        oscillator.frequency.value = frequency;
+        oscillator.connect(gain);
+        gain.connect(audioContext.destination);
+        const start = audioContext.currentTime + i * 0.15;
+        gain.gain.setValueAtTime(0.2, start);
+        oscillator.start(start);
+        oscillator.stop(start + 0.1);
+    });
+}
+
+/**
+ * loadScanQueue fetches the unknown barcodes waiting to be assigned to items.
+ */
+function loadScanQueue() {
+    fetch('/scan/queue')
+        .then(response => response.json())
+        .then(data => {
+            const tableBody = document.getElementById('scanQueueBody');
+            tableBody.innerHTML = '';
+
+            data.forEach(scan => {
+                const row = document.createElement('tr');
+                const match = scan.product ? scan.product.productName : '—';
+
+                row.innerHTML = `
+                    <td class="barcode">${scan.barcode}</td>
+                    <td>${match}</td>
+                    <td>${scan.scanMode}</td>
+                    <td>${scan.scanCount}</td>
+                    <td>${new Date(scan.lastScanned).toLocaleString()}</td>
+                    <td>
+                        <a href="/?barcode=${encodeURIComponent(scan.barcode)}">Add Item</a>
+                        <button class="dispose" onclick="dismissQueuedScan('${scan.barcode}')">Dismiss</button>
+                    </td>
+                `;
+
+                tableBody.appendChild(row);
+            });
+        })
+        .catch(error => console.error('Error loading scan queue:', error));
+}
+
+/**
+ * dismissQueuedScan removes a barcode from the unknown scan queue.
+ */
+function dismissQueuedScan(barcode) {
+    const formData = new URLSearchParams();
+    formData.append('barcode', barcode);
+
+    fetch('/scan/queue/remove', {
+        method: 'POST',
+        headers: {
+            'Content-Type': 'application/x-www-form-urlencoded'
+        },
+        body: formData.toString()
+    })
+    .then(response => {
+        if (response.ok) {
+            loadScanQueue();
+        } else {
+            console.error('Failed to dismiss barcode.', response.statusText);
+        }
+    })
+    .catch(error => console.error('Error dismissing barcode:', error));
+}